@@ -56,6 +56,10 @@ func (c *CreateAclsResponse) APIVersion() int16 {
 	return c.Version
 }
 
+func (c *CreateAclsResponse) setVersion(v int16) {
+	c.Version = v
+}
+
 func (c *CreateAclsResponse) HeaderVersion() int16 {
 	return 0
 }
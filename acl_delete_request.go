@@ -48,6 +48,10 @@ func (d *DeleteAclsRequest) APIVersion() int16 {
 	return int16(d.Version)
 }
 
+func (d *DeleteAclsRequest) setVersion(v int16) {
+	d.Version = int(v)
+}
+
 func (d *DeleteAclsRequest) HeaderVersion() int16 {
 	return 1
 }
@@ -25,6 +25,10 @@ func (d *DescribeAclsRequest) APIVersion() int16 {
 	return int16(d.Version)
 }
 
+func (d *DescribeAclsRequest) setVersion(v int16) {
+	d.Version = int(v)
+}
+
 func (d *DescribeAclsRequest) HeaderVersion() int16 {
 	return 1
 }
@@ -73,6 +73,10 @@ func (a *AddPartitionsToTxnRequest) APIVersion() int16 {
 	return a.Version
 }
 
+func (a *AddPartitionsToTxnRequest) setVersion(v int16) {
+	a.Version = v
+}
+
 func (a *AddPartitionsToTxnRequest) HeaderVersion() int16 {
 	return 1
 }
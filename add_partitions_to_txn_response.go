@@ -81,6 +81,10 @@ func (a *AddPartitionsToTxnResponse) APIVersion() int16 {
 	return a.Version
 }
 
+func (a *AddPartitionsToTxnResponse) setVersion(v int16) {
+	a.Version = v
+}
+
 func (a *AddPartitionsToTxnResponse) HeaderVersion() int16 {
 	return 0
 }
@@ -0,0 +1,83 @@
+package sarama
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// AddressMapper rewrites the address (host:port) advertised by a broker into
+// the address the client connects to.
+type AddressMapper interface {
+	MapAddress(addr string) string
+}
+
+// AddressMapperFunc is an AddressMapper calling the function.
+type AddressMapperFunc func(addr string) string
+
+func (f AddressMapperFunc) MapAddress(addr string) string {
+	return f(addr)
+}
+
+// StaticAddressMapper maps advertised addresses to the addresses to connect
+// to, keeping the addresses missing from the map as they are.
+type StaticAddressMapper map[string]string
+
+func (m StaticAddressMapper) MapAddress(addr string) string {
+	if mapped, ok := m[addr]; ok {
+		return mapped
+	}
+	return addr
+}
+
+// RegexpAddressMapper rewrites the advertised addresses matching Pattern with
+// Replacement, which can refer to the submatches as in
+// regexp.Regexp.ReplaceAllString, e.g. Pattern
+// `^kafka-(\d+)\.internal:9092$` and Replacement "localhost:1909$1".
+type RegexpAddressMapper struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+func (m *RegexpAddressMapper) MapAddress(addr string) string {
+	return m.Pattern.ReplaceAllString(addr, m.Replacement)
+}
+
+// allDNSIPsDialer resolves the host names itself and dials each of their IP
+// addresses in turn with the whole timeout, for Net.UseAllDNSIPs.
+type allDNSIPsDialer struct {
+	forward    proxy.Dialer
+	timeout    time.Duration
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+}
+
+func (d *allDNSIPsDialer) Dial(network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return d.forward.Dial(network, addr)
+	}
+
+	ctx := context.Background()
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+	ips, err := d.lookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ip := range ips {
+		var conn net.Conn
+		conn, err = d.forward.Dial(network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		DebugLogger.Printf("Failed to connect to %s at %s: %s\n", addr, ip, err)
+	}
+	return nil, err
+}
@@ -0,0 +1,71 @@
+package sarama
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestAddressMappers(t *testing.T) {
+	static := StaticAddressMapper{"kafka-1:9092": "localhost:19092"}
+	if addr := static.MapAddress("kafka-1:9092"); addr != "localhost:19092" {
+		t.Errorf("Expected a mapped address, got %s", addr)
+	}
+	if addr := static.MapAddress("kafka-2:9092"); addr != "kafka-2:9092" {
+		t.Errorf("Expected an unknown address to be kept, got %s", addr)
+	}
+
+	var mapper AddressMapper = AddressMapperFunc(func(addr string) string { return "proxy-" + addr })
+	if addr := mapper.MapAddress("kafka-1:9092"); addr != "proxy-kafka-1:9092" {
+		t.Errorf("Expected the function to map the address, got %s", addr)
+	}
+}
+
+func TestAllDNSIPsDialer(t *testing.T) {
+	mockBroker := NewMockBroker(t, 0)
+	defer mockBroker.Close()
+	_, port, err := net.SplitHostPort(mockBroker.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dialed []string
+	dialer := &allDNSIPsDialer{
+		forward: dialerFunc(func(network, addr string) (net.Conn, error) {
+			dialed = append(dialed, addr)
+			return (&net.Dialer{}).Dial(network, addr)
+		}),
+		lookupHost: func(ctx context.Context, host string) ([]string, error) {
+			if host != "kafka" {
+				t.Errorf("Unexpected lookup of %s", host)
+			}
+			// nothing listens on the port of the mock broker on 127.0.0.2
+			return []string{"127.0.0.2", "127.0.0.1"}, nil
+		},
+	}
+	conn, err := dialer.Dial("tcp", net.JoinHostPort("kafka", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+	if len(dialed) != 2 || dialed[1] != mockBroker.Addr() {
+		t.Errorf("Expected to try each IP in turn, got %v", dialed)
+	}
+
+	// IP addresses are not resolved
+	dialed = nil
+	conn, err = dialer.Dial("tcp", mockBroker.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+	if len(dialed) != 1 {
+		t.Errorf("Expected a single connection attempt, got %v", dialed)
+	}
+}
+
+type dialerFunc func(network, addr string) (net.Conn, error)
+
+func (f dialerFunc) Dial(network, addr string) (net.Conn, error) {
+	return f(network, addr)
+}
@@ -1,6 +1,7 @@
 package sarama
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -20,6 +21,13 @@ type ClusterAdmin interface {
 	// may not return information about the new topic.The validateOnly option is supported from version 0.10.2.0.
 	CreateTopic(topic string, detail *TopicDetail, validateOnly bool) error
 
+	// Creates several topics in a single request and returns the result of each topic.
+	// Unless options.ValidateOnly is set, it waits until the created topics appear in the
+	// metadata with a leader for every partition, or until Admin.Timeout elapses, in which
+	// case the topic result is ErrRequestTimedOut. From version 2.4.0.0 the result contains
+	// the configuration, partition count and replication factor applied by the broker.
+	CreateTopics(topics map[string]*TopicDetail, options *CreateTopicsOptions) (map[string]*CreateTopicResult, error)
+
 	// List the topics available in the cluster with the default options.
 	ListTopics() (map[string]TopicDetail, error)
 
@@ -34,6 +42,12 @@ type ClusterAdmin interface {
 	// This operation is supported by brokers with version 0.10.1.0 or higher.
 	DeleteTopic(topic string) error
 
+	// Deletes several topics in a single request and returns the error of each topic,
+	// which is nil on success. It waits until the deleted topics disappear from the
+	// metadata, or until Admin.Timeout elapses, in which case the topic error is
+	// ErrRequestTimedOut. This operation is supported by brokers with version 0.10.1.0 or higher.
+	DeleteTopics(topics []string) (map[string]error, error)
+
 	// Increase the number of partitions of the topics  according to the corresponding values.
 	// If partitions are increased for a topic that has a key, the partition logic or ordering of
 	// the messages will be affected. It may take several seconds after this method returns
@@ -50,6 +64,33 @@ type ClusterAdmin interface {
 	// This operation is supported by brokers with version 2.4.0.0 or higher.
 	ListPartitionReassignments(topics string, partitions []int32) (topicStatus map[string]map[int32]*PartitionReplicaReassignmentsStatus, err error)
 
+	// Plans a reassignment of the partitions of the given topics, or of all topics if topics
+	// is empty, onto the target brokers of options, for example to decommission a broker.
+	// Replicas already on a target broker stay where they are, missing replicas are placed on
+	// the least loaded brokers spread across racks, and preferred leaders are balanced.
+	// The plan only contains the partitions whose replicas change.
+	PlanReassignments(topics []string, options *ReassignmentPlanOptions) (*ReassignmentPlan, error)
+
+	// Starts the reassignment of a plan. If throttleRate is positive, the replication traffic
+	// of the moved partitions is first throttled to throttleRate bytes per second on every
+	// involved broker; call AwaitReassignments or ClearReassignmentThrottles to remove it.
+	// This operation is supported by brokers with version 2.4.0.0 or higher.
+	ExecuteReassignments(plan *ReassignmentPlan, throttleRate int64) error
+
+	// Reports how many partitions of a plan are still being reassigned.
+	// This operation is supported by brokers with version 2.4.0.0 or higher.
+	ReassignmentProgress(plan *ReassignmentPlan) (*ReassignmentProgress, error)
+
+	// Polls the progress of a plan every pollInterval, calling onProgress if it is not nil,
+	// until no partition is being reassigned anymore, and then clears the throttles.
+	// If ctx is done first, its error is returned and the throttles are kept, as the
+	// reassignment goes on; call ClearReassignmentThrottles once it is over.
+	// This operation is supported by brokers with version 2.4.0.0 or higher.
+	AwaitReassignments(ctx context.Context, plan *ReassignmentPlan, pollInterval time.Duration, onProgress func(*ReassignmentProgress)) error
+
+	// Removes the replication throttles set by ExecuteReassignments for a plan.
+	ClearReassignmentThrottles(plan *ReassignmentPlan) error
+
 	// Delete records whose offset is smaller than the given offset of the corresponding partition.
 	// This operation is supported by brokers with version 0.11.0.0 or higher.
 	DeleteRecords(topic string, partitionOffsets map[int32]int64) error
@@ -63,6 +104,14 @@ type ClusterAdmin interface {
 	// This operation is supported by brokers with version 0.11.0.0 or higher.
 	DescribeConfig(resource ConfigResource) ([]ConfigEntry, error)
 
+	// Describes the configuration of several resources at once and returns the result of each
+	// resource in the same order. Broker and broker logger resources are sent to the broker in
+	// question and all other resources are batched into a single request. Each config entry
+	// contains its source and type, its synonyms in order of precedence if includeSynonyms is
+	// set (version 1.1.0.0 or higher) and its documentation if includeDocumentation is set
+	// (version 2.6.0.0 or higher).
+	DescribeConfigs(resources []ConfigResource, includeSynonyms, includeDocumentation bool) ([]*DescribeConfigsResult, error)
+
 	// Update the configuration for the specified resources with the default options.
 	// This operation is supported by brokers with version 0.11.0.0 or higher.
 	// The resources with their configs (topic is the only resource type with configs
@@ -102,6 +151,11 @@ type ClusterAdmin interface {
 	// List the consumer groups available in the cluster.
 	ListConsumerGroups() (map[string]string, error)
 
+	// List the consumer groups available in the cluster that match the given options,
+	// together with their state and type. Brokers that fail to list their groups are
+	// reported in the result instead of failing the whole call.
+	ListConsumerGroupsWithOptions(options *ListConsumerGroupsOptions) (*ListConsumerGroupsResult, error)
+
 	// Describe the given consumer groups.
 	DescribeConsumerGroups(groups []string) ([]*GroupDescription, error)
 
@@ -111,6 +165,31 @@ type ClusterAdmin interface {
 	// Deletes a consumer group offset
 	DeleteConsumerGroupOffset(group string, topic string, partition int32) error
 
+	// Alter the committed offsets of a consumer group outside of any group generation.
+	// The coordinator rejects the commit while the group has active members.
+	AlterConsumerGroupOffsets(group string, offsets map[string]map[int32]int64) (*OffsetCommitResponse, error)
+
+	// Compute a plan that resets the offsets of a consumer group for the given topics
+	// and partitions, like the reset modes of the kafka-consumer-groups tool. A nil map
+	// plans all partitions the group has committed offsets for, and a topic without
+	// partitions plans all of its partitions. Planning does not change any offsets.
+	PlanConsumerGroupOffsetsReset(group string, topicPartitions map[string][]int32, spec OffsetResetSpec) (*OffsetResetPlan, error)
+
+	// Apply a plan computed by PlanConsumerGroupOffsetsReset. It returns ErrNonEmptyGroup
+	// without changing any offsets while the group has active members.
+	ApplyConsumerGroupOffsetsReset(plan *OffsetResetPlan) error
+
+	// List the offsets of the given partitions as specified by their OffsetSpec. Partitions
+	// are grouped into a single request per leader. Partitions that fail because their
+	// leadership moved are retried after refreshing the metadata, other errors are returned
+	// in the per-partition results.
+	ListOffsets(partitions map[string]map[int32]OffsetSpec, isolationLevel IsolationLevel) (map[string]map[int32]*ListOffsetsResult, error)
+
+	// Describe the lag of the given consumer groups per partition, together with the
+	// member each partition is assigned to. The end offsets of all groups are listed in
+	// a single batch. A nil options uses the high water mark and no time lag.
+	DescribeConsumerGroupLag(groups []string, options *ConsumerGroupLagOptions) ([]*ConsumerGroupLag, error)
+
 	// Delete a consumer group.
 	DeleteConsumerGroup(group string) error
 
@@ -238,13 +317,13 @@ func (ca *clusterAdmin) CreateTopic(topic string, detail *TopicDetail, validateO
 		Timeout:      ca.conf.Admin.Timeout,
 	}
 
-	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+	if ca.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 		// Version 3 is the same as version 2 (brokers response before throttling)
 		request.Version = 3
-	} else if ca.conf.Version.IsAtLeast(V0_11_0_0) {
+	} else if ca.conf.versionCeiling().IsAtLeast(V0_11_0_0) {
 		// Version 2 is the same as version 1 (response has ThrottleTime)
 		request.Version = 2
-	} else if ca.conf.Version.IsAtLeast(V0_10_2_0) {
+	} else if ca.conf.versionCeiling().IsAtLeast(V0_10_2_0) {
 		// Version 1 adds validateOnly.
 		request.Version = 1
 	}
@@ -283,7 +362,7 @@ func (ca *clusterAdmin) DescribeTopics(topics []string) (metadata []*TopicMetada
 		if err != nil {
 			return err
 		}
-		request := NewMetadataRequest(ca.conf.Version, topics)
+		request := NewMetadataRequest(ca.conf.versionCeiling(), topics)
 		response, err = controller.GetMetadata(request)
 		if isErrNotController(err) {
 			_, _ = ca.refreshController()
@@ -304,7 +383,7 @@ func (ca *clusterAdmin) DescribeCluster() (brokers []*Broker, controllerID int32
 			return err
 		}
 
-		request := NewMetadataRequest(ca.conf.Version, nil)
+		request := NewMetadataRequest(ca.conf.versionCeiling(), nil)
 		response, err = controller.GetMetadata(request)
 		if isErrNotController(err) {
 			_, _ = ca.refreshController()
@@ -350,7 +429,7 @@ func (ca *clusterAdmin) ListTopics() (map[string]TopicDetail, error) {
 	}
 	_ = b.Open(ca.client.Config())
 
-	metadataReq := NewMetadataRequest(ca.conf.Version, nil)
+	metadataReq := NewMetadataRequest(ca.conf.versionCeiling(), nil)
 	metadataResp, err := b.GetMetadata(metadataReq)
 	if err != nil {
 		return nil, err
@@ -382,17 +461,8 @@ func (ca *clusterAdmin) ListTopics() (map[string]TopicDetail, error) {
 	}
 
 	// Send the DescribeConfigsRequest
-	describeConfigsReq := &DescribeConfigsRequest{
-		Resources: describeConfigsResources,
-	}
-
-	if ca.conf.Version.IsAtLeast(V1_1_0_0) {
-		describeConfigsReq.Version = 1
-	}
-
-	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
-		describeConfigsReq.Version = 2
-	}
+	describeConfigsReq := newDescribeConfigsRequest(ca.conf.versionCeiling())
+	describeConfigsReq.Resources = describeConfigsResources
 
 	describeConfigsResp, err := b.DescribeConfigs(describeConfigsReq)
 	if err != nil {
@@ -430,11 +500,11 @@ func (ca *clusterAdmin) DeleteTopic(topic string) error {
 	}
 
 	// Versions 0, 1, 2, and 3 are the same.
-	if ca.conf.Version.IsAtLeast(V2_1_0_0) {
+	if ca.conf.versionCeiling().IsAtLeast(V2_1_0_0) {
 		request.Version = 3
-	} else if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+	} else if ca.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 		request.Version = 2
-	} else if ca.conf.Version.IsAtLeast(V0_11_0_0) {
+	} else if ca.conf.versionCeiling().IsAtLeast(V0_11_0_0) {
 		request.Version = 1
 	}
 
@@ -478,7 +548,7 @@ func (ca *clusterAdmin) CreatePartitions(topic string, count int32, assignment [
 		Timeout:         ca.conf.Admin.Timeout,
 		ValidateOnly:    validateOnly,
 	}
-	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+	if ca.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 		request.Version = 1
 	}
 
@@ -523,6 +593,10 @@ func (ca *clusterAdmin) AlterPartitionReassignments(topic string, assignment [][
 		request.AddBlock(topic, int32(i), assignment[i])
 	}
 
+	return ca.alterPartitionReassignments(request)
+}
+
+func (ca *clusterAdmin) alterPartitionReassignments(request *AlterPartitionReassignmentsRequest) error {
 	return ca.retryOnError(isErrNotController, func() error {
 		b, err := ca.Controller()
 		if err != nil {
@@ -569,7 +643,15 @@ func (ca *clusterAdmin) ListPartitionReassignments(topic string, partitions []in
 
 	request.AddBlock(topic, partitions)
 
-	var rsp *ListPartitionReassignmentsResponse
+	rsp, err := ca.listPartitionReassignments(request)
+	if err == nil && rsp != nil {
+		return rsp.TopicStatus, nil
+	} else {
+		return nil, err
+	}
+}
+
+func (ca *clusterAdmin) listPartitionReassignments(request *ListPartitionReassignmentsRequest) (rsp *ListPartitionReassignmentsResponse, err error) {
 	err = ca.retryOnError(isErrNotController, func() error {
 		b, err := ca.Controller()
 		if err != nil {
@@ -583,12 +665,7 @@ func (ca *clusterAdmin) ListPartitionReassignments(topic string, partitions []in
 		}
 		return err
 	})
-
-	if err == nil && rsp != nil {
-		return rsp.TopicStatus, nil
-	} else {
-		return nil, err
-	}
+	return rsp, err
 }
 
 func (ca *clusterAdmin) DeleteRecords(topic string, partitionOffsets map[int32]int64) error {
@@ -618,7 +695,7 @@ func (ca *clusterAdmin) DeleteRecords(topic string, partitionOffsets map[int32]i
 			Topics:  topics,
 			Timeout: ca.conf.Admin.Timeout,
 		}
-		if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+		if ca.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 			request.Version = 1
 		}
 		rsp, err := broker.DeleteRecords(request)
@@ -660,17 +737,8 @@ func (ca *clusterAdmin) DescribeConfig(resource ConfigResource) ([]ConfigEntry,
 	var resources []*ConfigResource
 	resources = append(resources, &resource)
 
-	request := &DescribeConfigsRequest{
-		Resources: resources,
-	}
-
-	if ca.conf.Version.IsAtLeast(V1_1_0_0) {
-		request.Version = 1
-	}
-
-	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
-		request.Version = 2
-	}
+	request := newDescribeConfigsRequest(ca.conf.versionCeiling())
+	request.Resources = resources
 
 	var (
 		b   *Broker
@@ -726,7 +794,7 @@ func (ca *clusterAdmin) AlterConfig(resourceType ConfigResourceType, name string
 		Resources:    resources,
 		ValidateOnly: validateOnly,
 	}
-	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+	if ca.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 		request.Version = 1
 	}
 
@@ -826,7 +894,7 @@ func (ca *clusterAdmin) CreateACL(resource Resource, acl Acl) error {
 	acls = append(acls, &AclCreation{resource, acl})
 	request := &CreateAclsRequest{AclCreations: acls}
 
-	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+	if ca.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 		request.Version = 1
 	}
 
@@ -848,7 +916,7 @@ func (ca *clusterAdmin) CreateACLs(resourceACLs []*ResourceAcls) error {
 	}
 	request := &CreateAclsRequest{AclCreations: acls}
 
-	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+	if ca.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 		request.Version = 1
 	}
 
@@ -864,7 +932,7 @@ func (ca *clusterAdmin) CreateACLs(resourceACLs []*ResourceAcls) error {
 func (ca *clusterAdmin) ListAcls(filter AclFilter) ([]ResourceAcls, error) {
 	request := &DescribeAclsRequest{AclFilter: filter}
 
-	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+	if ca.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 		request.Version = 1
 	}
 
@@ -890,7 +958,7 @@ func (ca *clusterAdmin) DeleteACL(filter AclFilter, validateOnly bool) ([]Matchi
 	filters = append(filters, &filter)
 	request := &DeleteAclsRequest{Filters: filters}
 
-	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+	if ca.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 		request.Version = 1
 	}
 
@@ -926,19 +994,20 @@ func (ca *clusterAdmin) DescribeConsumerGroups(groups []string) (result []*Group
 
 	for broker, brokerGroups := range groupsPerBroker {
 		describeReq := &DescribeGroupsRequest{
-			Groups: brokerGroups,
+			Groups:                      brokerGroups,
+			IncludeAuthorizedOperations: true,
 		}
 
-		if ca.conf.Version.IsAtLeast(V2_4_0_0) {
+		if ca.conf.versionCeiling().IsAtLeast(V2_4_0_0) {
 			// Starting in version 4, the response will include group.instance.id info for members.
 			describeReq.Version = 4
-		} else if ca.conf.Version.IsAtLeast(V2_3_0_0) {
+		} else if ca.conf.versionCeiling().IsAtLeast(V2_3_0_0) {
 			// Starting in version 3, authorized operations can be requested.
 			describeReq.Version = 3
-		} else if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+		} else if ca.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 			// Version 2 is the same as version 0.
 			describeReq.Version = 2
-		} else if ca.conf.Version.IsAtLeast(V1_1_0_0) {
+		} else if ca.conf.versionCeiling().IsAtLeast(V1_1_0_0) {
 			// Version 1 is the same as version 0.
 			describeReq.Version = 1
 		}
@@ -947,6 +1016,9 @@ func (ca *clusterAdmin) DescribeConsumerGroups(groups []string) (result []*Group
 			return nil, err
 		}
 
+		for _, group := range response.Groups {
+			group.decodeConsumerMembers()
+		}
 		result = append(result, response.Groups...)
 	}
 	return result, nil
@@ -967,20 +1039,7 @@ func (ca *clusterAdmin) ListConsumerGroups() (allGroups map[string]string, err e
 			defer wg.Done()
 			_ = b.Open(conf) // Ensure that broker is opened
 
-			request := &ListGroupsRequest{}
-			if ca.conf.Version.IsAtLeast(V2_6_0_0) {
-				// Version 4 adds the StatesFilter field (KIP-518).
-				request.Version = 4
-			} else if ca.conf.Version.IsAtLeast(V2_4_0_0) {
-				// Version 3 is the first flexible version.
-				request.Version = 3
-			} else if ca.conf.Version.IsAtLeast(V2_0_0_0) {
-				// Version 2 is the same as version 0.
-				request.Version = 2
-			} else if ca.conf.Version.IsAtLeast(V0_11_0_0) {
-				// Version 1 is the same as version 0.
-				request.Version = 1
-			}
+			request := newListGroupsRequest(ca.conf.versionCeiling())
 
 			response, err := b.ListGroups(request)
 			if err != nil {
@@ -1018,7 +1077,7 @@ func (ca *clusterAdmin) ListConsumerGroupOffsets(group string, topicPartitions m
 		return nil, err
 	}
 
-	request := NewOffsetFetchRequest(ca.conf.Version, group, topicPartitions)
+	request := NewOffsetFetchRequest(ca.conf.versionCeiling(), group, topicPartitions)
 
 	return coordinator.FetchOffset(request)
 }
@@ -1051,6 +1110,26 @@ func (ca *clusterAdmin) DeleteConsumerGroupOffset(group string, topic string, pa
 	return nil
 }
 
+func (ca *clusterAdmin) AlterConsumerGroupOffsets(group string, offsets map[string]map[int32]int64) (*OffsetCommitResponse, error) {
+	coordinator, err := ca.client.Coordinator(group)
+	if err != nil {
+		return nil, err
+	}
+
+	request := newOffsetCommitRequest(ca.conf, group, "", GroupGenerationUndefined, nil)
+	var commitTimestamp int64
+	if request.Version == 1 {
+		commitTimestamp = ReceiveTime
+	}
+	for topic, partitions := range offsets {
+		for partition, offset := range partitions {
+			request.AddBlockWithLeaderEpoch(topic, partition, offset, invalidLeaderEpoch, commitTimestamp, "")
+		}
+	}
+
+	return coordinator.CommitOffset(request)
+}
+
 func (ca *clusterAdmin) DeleteConsumerGroup(group string) error {
 	coordinator, err := ca.client.Coordinator(group)
 	if err != nil {
@@ -1060,7 +1139,7 @@ func (ca *clusterAdmin) DeleteConsumerGroup(group string) error {
 	request := &DeleteGroupsRequest{
 		Groups: []string{group},
 	}
-	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+	if ca.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 		request.Version = 1
 	}
 
@@ -1101,7 +1180,7 @@ func (ca *clusterAdmin) DescribeLogDirs(brokerIds []int32) (allLogDirs map[int32
 			_ = b.Open(conf) // Ensure that broker is opened
 
 			request := &DescribeLogDirsRequest{}
-			if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+			if ca.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 				request.Version = 1
 			}
 			response, err := b.DescribeLogDirs(request)
@@ -1255,7 +1334,7 @@ func (ca *clusterAdmin) AlterClientQuotas(entity []QuotaEntityComponent, op Clie
 }
 
 func (ca *clusterAdmin) RemoveMemberFromConsumerGroup(groupId string, groupInstanceIds []string) (*LeaveGroupResponse, error) {
-	if !ca.conf.Version.IsAtLeast(V2_4_0_0) {
+	if !ca.conf.versionCeiling().IsAtLeast(V2_4_0_0) {
 		return nil, ConfigurationError("Removing members from a consumer group headers requires Kafka version of at least v2.4.0")
 	}
 
@@ -1401,6 +1401,41 @@ func TestDescribeTopicWithVersion0_11(t *testing.T) {
 	}
 }
 
+func TestDescribeTopicNegotiated(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	// the broker only supports MetadataRequest v2
+	apiVersions := NewMockApiVersionsResponse(t)
+	for i := range apiVersions.apiKeys {
+		if apiVersions.apiKeys[i].ApiKey == 3 {
+			apiVersions.apiKeys[i].MaxVersion = 2
+		}
+	}
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": apiVersions,
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+	})
+
+	// Version is not set by default
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, NewConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	topics, err := admin.DescribeTopics([]string{"my_topic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 1 || topics[0].Name != "my_topic" {
+		t.Fatalf("Expected my_topic, got %v", topics)
+	}
+}
+
 func TestDescribeConsumerGroup(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	defer seedBroker.Close()
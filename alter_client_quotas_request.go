@@ -186,6 +186,10 @@ func (a *AlterClientQuotasRequest) APIVersion() int16 {
 	return a.Version
 }
 
+func (a *AlterClientQuotasRequest) setVersion(v int16) {
+	a.Version = v
+}
+
 func (a *AlterClientQuotasRequest) HeaderVersion() int16 {
 	return 1
 }
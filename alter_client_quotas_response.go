@@ -137,6 +137,10 @@ func (a *AlterClientQuotasResponse) APIVersion() int16 {
 	return a.Version
 }
 
+func (a *AlterClientQuotasResponse) setVersion(v int16) {
+	a.Version = v
+}
+
 func (a *AlterClientQuotasResponse) HeaderVersion() int16 {
 	return 0
 }
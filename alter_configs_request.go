@@ -118,6 +118,10 @@ func (a *AlterConfigsRequest) APIVersion() int16 {
 	return a.Version
 }
 
+func (a *AlterConfigsRequest) setVersion(v int16) {
+	a.Version = v
+}
+
 func (a *AlterConfigsRequest) HeaderVersion() int16 {
 	return 1
 }
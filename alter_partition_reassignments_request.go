@@ -109,6 +109,10 @@ func (r *AlterPartitionReassignmentsRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *AlterPartitionReassignmentsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *AlterPartitionReassignmentsRequest) HeaderVersion() int16 {
 	return 2
 }
@@ -150,6 +150,10 @@ func (r *AlterPartitionReassignmentsResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *AlterPartitionReassignmentsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *AlterPartitionReassignmentsResponse) HeaderVersion() int16 {
 	return 1
 }
@@ -50,6 +50,10 @@ func (r *ApiVersionsRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *ApiVersionsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ApiVersionsRequest) HeaderVersion() int16 {
 	if r.Version >= 3 {
 		return 2
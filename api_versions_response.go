@@ -97,6 +97,11 @@ func (r *ApiVersionsResponse) Decode(pd packetDecoder, version int16) (err error
 	if r.ErrorCode, err = pd.getInt16(); err != nil {
 		return err
 	}
+	// a broker which does not support the version of the request answers
+	// with a version 0 response listing the versions it supports (KIP-511)
+	if r.ErrorCode == int16(ErrUnsupportedVersion) {
+		r.Version = 0
+	}
 
 	var numApiKeys int
 	if r.Version >= 3 {
@@ -142,6 +147,10 @@ func (r *ApiVersionsResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *ApiVersionsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ApiVersionsResponse) HeaderVersion() int16 {
 	// ApiVersionsResponse always includes a v0 header.
 	// See KIP-511 for details
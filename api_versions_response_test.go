@@ -57,3 +57,16 @@ func TestApiVersionsResponseV3(t *testing.T) {
 		t.Error("Decoding error: expected 0x01 but got", response.ApiKeys[0].MaxVersion)
 	}
 }
+
+func TestApiVersionsResponseUnsupportedVersion(t *testing.T) {
+	// a broker which does not support v3 answers with a v0 response
+	unsupported := append([]byte{0x00, 0x23}, apiVersionResponse[2:]...)
+	response := new(ApiVersionsResponse)
+	testVersionDecodable(t, "unsupported version", response, unsupported, 3)
+	if response.ErrorCode != int16(ErrUnsupportedVersion) {
+		t.Error("Decoding error failed: ErrUnsupportedVersion expected but found", response.ErrorCode)
+	}
+	if response.Version != 0 || len(response.ApiKeys) != 1 || response.ApiKeys[0].ApiKey != 0x03 {
+		t.Errorf("Expected the v0 response to be decoded, got %+v", response)
+	}
+}
@@ -443,7 +443,7 @@ func (p *asyncProducer) dispatcher() {
 		}
 
 		version := 1
-		if p.conf.Version.IsAtLeast(V0_11_0_0) {
+		if p.conf.versionCeiling().IsAtLeast(V0_11_0_0) {
 			version = 2
 		} else if msg.Headers != nil {
 			p.returnError(msg, ConfigurationError("Producing headers requires Kafka at least v0.11"))
@@ -785,7 +785,6 @@ func (p *asyncProducer) newBrokerProducer(broker *Broker) *brokerProducer {
 		input:          input,
 		output:         bridge,
 		responses:      responses,
-		buffer:         newProduceSet(p),
 		currentRetries: make(map[string]map[int32]error),
 	}
 	go withRecover(bp.run)
@@ -900,6 +899,7 @@ type brokerProducer struct {
 	responses <-chan *brokerProducerResponse
 	abandoned chan struct{}
 
+	version    int16
 	buffer     *produceSet
 	timer      *time.Timer
 	timerFired bool
@@ -913,6 +913,16 @@ func (bp *brokerProducer) run() {
 	var timerChan <-chan time.Time
 	Logger.Printf("producer/broker/%d starting up\n", bp.broker.ID())
 
+	// the format of the messages depends on the version of the
+	// ProduceRequest supported by the broker
+	bp.version = produceRequestVersion(bp.parent.conf)
+	if version, err := bp.broker.negotiatedVersion(&ProduceRequest{Version: bp.version}); err != nil {
+		Logger.Printf("producer/broker/%d failed to negotiate the ProduceRequest version: %s\n", bp.broker.ID(), err)
+	} else {
+		bp.version = version
+	}
+	bp.buffer = newProduceSet(bp.parent, bp.version)
+
 	for {
 		select {
 		case msg, ok := <-bp.input:
@@ -1052,7 +1062,7 @@ func (bp *brokerProducer) rollOver() {
 	}
 	bp.timer = nil
 	bp.timerFired = false
-	bp.buffer = newProduceSet(bp.parent)
+	bp.buffer = newProduceSet(bp.parent, bp.version)
 }
 
 func (bp *brokerProducer) handleResponse(response *brokerProducerResponse) {
@@ -1087,7 +1097,7 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 		switch block.Err {
 		// Success
 		case ErrNoError:
-			if bp.parent.conf.Version.IsAtLeast(V0_10_0_0) && !block.Timestamp.IsZero() {
+			if bp.parent.conf.versionCeiling().IsAtLeast(V0_10_0_0) && !block.Timestamp.IsZero() {
 				for _, msg := range pSet.msgs {
 					msg.Timestamp = block.Timestamp
 				}
@@ -1142,7 +1152,7 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 				}
 				bp.currentRetries[topic][partition] = block.Err
 				if bp.parent.conf.Producer.Idempotent {
-					go bp.parent.retryBatch(topic, partition, pSet, block.Err)
+					go bp.parent.retryBatch(topic, partition, pSet, sent.version, block.Err)
 				} else {
 					bp.parent.retryMessages(pSet.msgs, block.Err)
 				}
@@ -1153,9 +1163,9 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 	}
 }
 
-func (p *asyncProducer) retryBatch(topic string, partition int32, pSet *partitionSet, kerr KError) {
+func (p *asyncProducer) retryBatch(topic string, partition int32, pSet *partitionSet, version int16, kerr KError) {
 	Logger.Printf("Retrying batch for %v-%d because of %s\n", topic, partition, kerr)
-	produceSet := newProduceSet(p)
+	produceSet := newProduceSet(p, version)
 	produceSet.msgs[topic] = make(map[int32]*partitionSet)
 	produceSet.msgs[topic][partition] = pSet
 	produceSet.bufferBytes += pSet.bufferBytes
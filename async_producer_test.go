@@ -1148,6 +1148,60 @@ func TestAsyncProducerNoReturns(t *testing.T) {
 	leader.Close()
 }
 
+func TestAsyncProducerNegotiatesMessageFormat(t *testing.T) {
+	broker := NewMockBroker(t, 1)
+	defer broker.Close()
+
+	// the broker only supports the message sets of ProduceRequest v2
+	apiVersions := NewMockApiVersionsResponse(t)
+	for i := range apiVersions.apiKeys {
+		if apiVersions.apiKeys[i].ApiKey == 0 {
+			apiVersions.apiKeys[i].MaxVersion = 2
+		}
+	}
+	broker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": apiVersions,
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("my_topic", 0, broker.BrokerID()),
+		"ProduceRequest": NewMockProduceResponse(t),
+	})
+
+	// Version is not set by default
+	config := NewConfig()
+	config.Producer.Return.Successes = true
+	config.Producer.Retry.Backoff = 0
+	producer, err := NewAsyncProducer([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	expectResults(t, producer, 1, 0)
+	// the headers cannot be sent in a message set
+	producer.Input() <- &ProducerMessage{
+		Topic: "my_topic", Value: StringEncoder(TestMessage),
+		Headers: []RecordHeader{{Key: []byte("key"), Value: []byte("value")}},
+	}
+	select {
+	case err := <-producer.Errors():
+		if !errors.Is(err, ErrUnsupportedVersion) {
+			t.Errorf("Expected ErrUnsupportedVersion for the headers, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected an error for the headers")
+	}
+	closeProducer(t, producer)
+
+	for _, rr := range broker.History() {
+		if req, ok := rr.Request.(*ProduceRequest); ok {
+			if req.Version != 2 || req.Records["my_topic"][0].MsgSet == nil {
+				t.Errorf("Expected a message set in ProduceRequest v2, got v%d", req.Version)
+			}
+		}
+	}
+}
+
 func TestAsyncProducerIdempotentGoldenPath(t *testing.T) {
 	broker := NewMockBroker(t, 1)
 
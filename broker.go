@@ -8,6 +8,7 @@ import (
 	"io"
 	"math/rand"
 	"net"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -25,6 +26,7 @@ type Broker struct {
 
 	id            int32
 	addr          string
+	connection    string // names the connection when there are several to the broker
 	correlationID int32
 	conn          net.Conn
 	connErr       error
@@ -58,8 +60,25 @@ type Broker struct {
 
 	kerberosAuthenticator               GSSAPIKerberosAuth
 	clientSessionReauthenticationTimeMs int64
+	reauthenticationTimer               *time.Timer
 
 	throttleTimer *time.Timer
+
+	// apiVersions are the version ranges supported by the broker, by API key
+	apiVersions map[int16]ApiVersionsResponseKey
+
+	// connectFailures counts the consecutive failed connection attempts,
+	// which delay the next one until nextConnect
+	connectFailures int
+	nextConnect     time.Time
+
+	// lastActivity is when a request was last sent or a response received,
+	// in Unix nanoseconds, to close the connection once idle, unless some of
+	// the inFlight requests still await their response
+	lastActivity int64
+	inFlight     int64
+	idleTimer    *time.Timer
+	idleClosed   bool
 }
 
 // SASLMechanism specifies the SASL mechanism the client uses to authenticate with the broker
@@ -173,8 +192,6 @@ func (b *Broker) Open(conf *Config) error {
 		return err
 	}
 
-	usingApiVersionsRequests := conf.Version.IsAtLeast(V2_4_0_0) && conf.ApiVersionsRequest
-
 	b.lock.Lock()
 
 	if b.metricRegistry == nil {
@@ -183,107 +200,147 @@ func (b *Broker) Open(conf *Config) error {
 
 	go withRecover(
 		func() {
-			defer func() {
-				b.lock.Unlock()
-
-				// Send an ApiVersionsRequest to identify the client (KIP-511).
-				// Ideally Sarama would use the response to control protocol versions,
-				// but for now just fire-and-forget just to send
-				if usingApiVersionsRequests {
-					_, err = b.ApiVersions(
-						&ApiVersionsRequest{
-							Version:               3,
-							ClientSoftwareName:    defaultClientSoftwareName,
-							ClientSoftwareVersion: version(),
-						},
-					)
-					if err != nil {
-						Logger.Printf("Error while sending ApiVersionsRequest to broker %s: %s\n", b.addr, err)
-					}
-				}
-			}()
-			dialer := conf.getDialer()
-			b.conn, b.connErr = dialer.Dial("tcp", b.addr)
-			if b.connErr != nil {
-				Logger.Printf("Failed to connect to broker %s: %s\n", b.addr, b.connErr)
-				b.conn = nil
-				atomic.StoreInt32(&b.opened, 0)
-				return
-			}
-			if conf.Net.TLS.Enable {
-				b.conn = tls.Client(b.conn, validServerNameTLS(b.addr, conf.Net.TLS.Config))
-			}
+			defer b.lock.Unlock()
+			b.connect(conf)
+		},
+	)
 
-			b.conn = newBufConn(b.conn)
-			b.conf = conf
-
-			// Create or reuse the global metrics shared between brokers
-			b.incomingByteRate = metrics.GetOrRegisterMeter("incoming-byte-rate", b.metricRegistry)
-			b.requestRate = metrics.GetOrRegisterMeter("request-rate", b.metricRegistry)
-			b.fetchRate = metrics.GetOrRegisterMeter("consumer-fetch-rate", b.metricRegistry)
-			b.requestSize = getOrRegisterHistogram("request-size", b.metricRegistry)
-			b.requestLatency = getOrRegisterHistogram("request-latency-in-ms", b.metricRegistry)
-			b.outgoingByteRate = metrics.GetOrRegisterMeter("outgoing-byte-rate", b.metricRegistry)
-			b.responseRate = metrics.GetOrRegisterMeter("response-rate", b.metricRegistry)
-			b.responseSize = getOrRegisterHistogram("response-size", b.metricRegistry)
-			b.requestsInFlight = metrics.GetOrRegisterCounter("requests-in-flight", b.metricRegistry)
-			b.protocolRequestsRate = map[int16]metrics.Meter{}
-			// Do not gather metrics for seeded broker (only used during bootstrap) because they share
-			// the same id (-1) and are already exposed through the global metrics above
-			if b.id >= 0 && !metrics.UseNilMetrics {
-				b.registerMetrics()
-			}
+	return nil
+}
 
-			if conf.Net.SASL.Mechanism == SASLTypeOAuth && conf.Net.SASL.Version == SASLHandshakeV0 {
-				conf.Net.SASL.Version = SASLHandshakeV1
-			}
+// connect dials the broker and authenticates, waiting first for the backoff
+// left by previous failed attempts if any.
+// b.lock must be held by caller
+func (b *Broker) connect(conf *Config) {
+	if wait := time.Until(b.nextConnect); wait > 0 {
+		DebugLogger.Printf("Waiting %s before reconnecting to broker %s\n", wait, b.addr)
+		time.Sleep(wait)
+	}
 
-			useSaslV0 := conf.Net.SASL.Version == SASLHandshakeV0 || conf.Net.SASL.Mechanism == SASLTypeGSSAPI
-			if conf.Net.SASL.Enable && useSaslV0 {
-				b.connErr = b.authenticateViaSASLv0()
-
-				if b.connErr != nil {
-					err = b.conn.Close()
-					if err == nil {
-						DebugLogger.Printf("Closed connection to broker %s\n", b.addr)
-					} else {
-						Logger.Printf("Error while closing connection to broker %s: %s\n", b.addr, err)
-					}
-					b.conn = nil
-					atomic.StoreInt32(&b.opened, 0)
-					return
-				}
-			}
+	dialer := conf.getDialer()
+	b.conn, b.connErr = dialer.Dial("tcp", b.addr)
+	if b.connErr != nil {
+		Logger.Printf("Failed to connect to broker %s: %s\n", b.addr, b.connErr)
+		b.connectFailed(conf)
+		return
+	}
+	if conf.Net.TLS.Enable {
+		b.conn = tls.Client(b.conn, validServerNameTLS(b.addr, conf.Net.TLS.Config))
+	}
 
-			b.done = make(chan bool)
-			b.responses = make(chan *responsePromise, b.conf.Net.MaxOpenRequests-1)
-
-			go withRecover(b.responseReceiver)
-			if conf.Net.SASL.Enable && !useSaslV0 {
-				b.connErr = b.authenticateViaSASLv1()
-				if b.connErr != nil {
-					close(b.responses)
-					<-b.done
-					err = b.conn.Close()
-					if err == nil {
-						DebugLogger.Printf("Closed connection to broker %s\n", b.addr)
-					} else {
-						Logger.Printf("Error while closing connection to broker %s: %s\n", b.addr, err)
-					}
-					b.conn = nil
-					atomic.StoreInt32(&b.opened, 0)
-					return
-				}
-			}
-			if b.id >= 0 {
-				DebugLogger.Printf("Connected to broker at %s (registered as #%d)\n", b.addr, b.id)
+	b.conn = newBufConn(b.conn)
+	b.conf = conf
+
+	// Create or reuse the global metrics shared between brokers
+	b.incomingByteRate = metrics.GetOrRegisterMeter("incoming-byte-rate", b.metricRegistry)
+	b.requestRate = metrics.GetOrRegisterMeter("request-rate", b.metricRegistry)
+	b.fetchRate = metrics.GetOrRegisterMeter("consumer-fetch-rate", b.metricRegistry)
+	b.requestSize = getOrRegisterHistogram("request-size", b.metricRegistry)
+	b.requestLatency = getOrRegisterHistogram("request-latency-in-ms", b.metricRegistry)
+	b.outgoingByteRate = metrics.GetOrRegisterMeter("outgoing-byte-rate", b.metricRegistry)
+	b.responseRate = metrics.GetOrRegisterMeter("response-rate", b.metricRegistry)
+	b.responseSize = getOrRegisterHistogram("response-size", b.metricRegistry)
+	b.requestsInFlight = metrics.GetOrRegisterCounter("requests-in-flight", b.metricRegistry)
+	b.protocolRequestsRate = map[int16]metrics.Meter{}
+	// Do not gather metrics for seeded broker (only used during bootstrap) because they share
+	// the same id (-1) and are already exposed through the global metrics above
+	if b.id >= 0 && !metrics.UseNilMetrics {
+		b.registerMetrics()
+	}
+
+	if conf.Net.SASL.Mechanism == SASLTypeOAuth && conf.Net.SASL.Version == SASLHandshakeV0 {
+		conf.Net.SASL.Version = SASLHandshakeV1
+	}
+
+	useSaslV0 := conf.Net.SASL.Version == SASLHandshakeV0 || conf.Net.SASL.Mechanism == SASLTypeGSSAPI
+	if conf.Net.SASL.Enable && useSaslV0 {
+		b.connErr = b.authenticateViaSASLv0()
+
+		if b.connErr != nil {
+			err := b.conn.Close()
+			if err == nil {
+				DebugLogger.Printf("Closed connection to broker %s\n", b.addr)
 			} else {
-				DebugLogger.Printf("Connected to broker at %s (unregistered)\n", b.addr)
+				Logger.Printf("Error while closing connection to broker %s: %s\n", b.addr, err)
 			}
-		},
-	)
+			b.connectFailed(conf)
+			return
+		}
+	}
 
-	return nil
+	b.done = make(chan bool)
+	b.responses = make(chan *responsePromise, b.conf.Net.MaxOpenRequests-1)
+
+	go withRecover(b.responseReceiver)
+	if conf.negotiatesVersions() {
+		if err := b.requestApiVersions(); err != nil {
+			if conf.Version == (KafkaVersion{}) {
+				// the versions of the requests cannot be chosen without them
+				b.connErr = fmt.Errorf("failed to negotiate the API versions with broker %s, Version must be set for brokers older than Kafka 0.10: %w", b.addr, err)
+				b.abortConnect(conf)
+				return
+			}
+			Logger.Printf("Error while sending ApiVersionsRequest to broker %s: %s\n", b.addr, err)
+		}
+	}
+	if conf.Net.SASL.Enable && !useSaslV0 {
+		b.connErr = b.authenticateViaSASLv1()
+		if b.connErr != nil {
+			b.abortConnect(conf)
+			return
+		}
+		b.scheduleReauthentication()
+	}
+	b.connectFailures = 0
+	b.nextConnect = time.Time{}
+	b.markActive()
+	b.scheduleIdleCheck()
+
+	if b.id >= 0 {
+		DebugLogger.Printf("Connected to broker at %s (registered as #%d)\n", b.addr, b.id)
+	} else {
+		DebugLogger.Printf("Connected to broker at %s (unregistered)\n", b.addr)
+	}
+}
+
+// abortConnect closes a connection which failed to be set up after the
+// responses started to be received.
+// b.lock must be held by caller
+func (b *Broker) abortConnect(conf *Config) {
+	close(b.responses)
+	<-b.done
+	b.apiVersions = nil
+	err := b.conn.Close()
+	if err == nil {
+		DebugLogger.Printf("Closed connection to broker %s\n", b.addr)
+	} else {
+		Logger.Printf("Error while closing connection to broker %s: %s\n", b.addr, err)
+	}
+	b.connectFailed(conf)
+}
+
+// connectFailed records a failed connection attempt, so that the next one is
+// delayed by an exponentially increasing backoff.
+// b.lock must be held by caller
+func (b *Broker) connectFailed(conf *Config) {
+	b.conn = nil
+	atomic.StoreInt32(&b.opened, 0)
+	b.connectFailures++
+	b.nextConnect = time.Now().Add(reconnectBackoff(conf, b.connectFailures))
+}
+
+// reconnectBackoff returns how long to wait before reconnecting after the
+// given number of consecutive failures: Net.ReconnectBackoff doubled for each
+// failure up to Net.ReconnectBackoffMax, with up to 20% of jitter either way.
+func reconnectBackoff(conf *Config, failures int) time.Duration {
+	backoff := conf.Net.ReconnectBackoff
+	for i := 1; i < failures && backoff < conf.Net.ReconnectBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > conf.Net.ReconnectBackoffMax {
+		backoff = conf.Net.ReconnectBackoffMax
+	}
+	return time.Duration(float64(backoff) * (0.8 + 0.4*rand.Float64()))
 }
 
 func (b *Broker) ResponseSize() int {
@@ -326,9 +383,36 @@ func (b *Broker) Close() error {
 	defer b.lock.Unlock()
 
 	if b.conn == nil {
+		if b.idleClosed {
+			b.idleClosed = false
+			atomic.StoreInt32(&b.opened, 0)
+			return nil
+		}
 		return ErrNotConnected
 	}
 
+	err := b.closeConnection()
+
+	atomic.StoreInt32(&b.opened, 0)
+
+	return err
+}
+
+// closeConnection closes the connection to the broker and resets the state
+// of the session.
+// b.lock must be held by caller
+func (b *Broker) closeConnection() error {
+	if b.reauthenticationTimer != nil {
+		b.reauthenticationTimer.Stop()
+		b.reauthenticationTimer = nil
+	}
+	if b.idleTimer != nil {
+		b.idleTimer.Stop()
+		b.idleTimer = nil
+	}
+	b.clientSessionReauthenticationTimeMs = 0
+	b.apiVersions = nil
+
 	close(b.responses)
 	<-b.done
 
@@ -347,8 +431,6 @@ func (b *Broker) Close() error {
 		Logger.Printf("Error while closing connection to broker %s: %s\n", b.addr, err)
 	}
 
-	atomic.StoreInt32(&b.opened, 0)
-
 	return err
 }
 
@@ -413,6 +495,7 @@ func (b *Broker) FindCoordinator(request *FindCoordinatorRequest) (*FindCoordina
 // GetAvailableOffsets return an offset response or error
 func (b *Broker) GetAvailableOffsets(request *OffsetRequest) (*OffsetResponse, error) {
 	response := new(OffsetResponse)
+	response.Version = request.Version // Required to ensure use of the correct response header version
 
 	err := b.sendAndReceive(request, response)
 	if err != nil {
@@ -442,6 +525,13 @@ func (b *Broker) AsyncProduce(request *ProduceRequest, cb ProduceCallback) error
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
+	b.reopenIfIdle()
+	negotiated, err := b.negotiateVersion(request, nil)
+	if err != nil {
+		return err
+	}
+	request = negotiated.(*ProduceRequest)
+
 	needAcks := request.RequiredAcks != NoResponse
 	// Use a nil promise when no acks is required
 	var promise *responsePromise
@@ -632,6 +722,7 @@ func (b *Broker) ApiVersions(request *ApiVersionsRequest) (*ApiVersionsResponse,
 // CreateTopics send a create topic request and returns create topic response
 func (b *Broker) CreateTopics(request *CreateTopicsRequest) (*CreateTopicsResponse, error) {
 	response := new(CreateTopicsResponse)
+	response.Version = request.Version // Required to ensure use of the correct response header version
 
 	err := b.sendAndReceive(request, response)
 	if err != nil {
@@ -826,6 +917,7 @@ func (b *Broker) TxnOffsetCommit(request *TxnOffsetCommitRequest) (*TxnOffsetCom
 // error
 func (b *Broker) DescribeConfigs(request *DescribeConfigsRequest) (*DescribeConfigsResponse, error) {
 	response := new(DescribeConfigsResponse)
+	response.Version = request.Version // Required to ensure use of the correct response header version
 
 	err := b.sendAndReceive(request, response)
 	if err != nil {
@@ -1003,8 +1095,8 @@ func (b *Broker) sendWithPromise(rb ProtocolBody, promise *responsePromise) erro
 		return ErrNotConnected
 	}
 
-	if b.clientSessionReauthenticationTimeMs > 0 && currentUnixMilli() > b.clientSessionReauthenticationTimeMs {
-		err := b.authenticateViaSASLv1()
+	if b.reauthenticationDue() {
+		err := b.reauthenticate()
 		if err != nil {
 			return err
 		}
@@ -1015,7 +1107,7 @@ func (b *Broker) sendWithPromise(rb ProtocolBody, promise *responsePromise) erro
 
 // b.lock must be held by caller
 func (b *Broker) sendInternal(rb ProtocolBody, promise *responsePromise) error {
-	if !b.conf.Version.IsAtLeast(rb.RequiredVersion()) {
+	if !b.conf.versionCeiling().IsAtLeast(rb.RequiredVersion()) {
 		return ErrUnsupportedVersion
 	}
 
@@ -1039,6 +1131,7 @@ func (b *Broker) sendInternal(rb ProtocolBody, promise *responsePromise) error {
 		return err
 	}
 	b.correlationID++
+	b.markActive()
 
 	if promise == nil {
 		// Record request latency without the response
@@ -1056,6 +1149,12 @@ func (b *Broker) sendInternal(rb ProtocolBody, promise *responsePromise) error {
 func (b *Broker) sendAndReceive(req ProtocolBody, res ProtocolBody) error {
 	b.lock.Lock()
 	defer b.lock.Unlock()
+
+	b.reopenIfIdle()
+	req, err := b.negotiateVersion(req, res)
+	if err != nil {
+		return err
+	}
 	responseHeaderVersion := int16(-1)
 	if res != nil {
 		responseHeaderVersion = res.HeaderVersion()
@@ -1080,6 +1179,134 @@ func (b *Broker) sendAndReceive(req ProtocolBody, res ProtocolBody) error {
 	return nil
 }
 
+// requestApiVersions sends an ApiVersionsRequest to identify the client
+// (KIP-511) and remembers the version ranges supported by the broker, which
+// are used to negotiate the version of the requests sent to it. A broker which
+// does not support the version of the request answers with the versions it
+// supports and ErrUnsupportedVersion, the request is then sent again at the
+// highest version it supports.
+// b.lock must be held by caller
+func (b *Broker) requestApiVersions() error {
+	request := &ApiVersionsRequest{
+		Version:               3,
+		ClientSoftwareName:    defaultClientSoftwareName,
+		ClientSoftwareVersion: version(),
+	}
+	for request.Version > 0 && !b.conf.versionCeiling().IsAtLeast(request.RequiredVersion()) {
+		request.Version--
+	}
+
+	for {
+		response := &ApiVersionsResponse{Version: request.Version}
+		promise := makeResponsePromise(response.HeaderVersion())
+
+		err := b.sendInternal(request, promise)
+		if err == nil {
+			err = handleResponsePromise(request, response, promise, b.metricRegistry)
+		}
+		if err != nil {
+			return err
+		}
+
+		switch KError(response.ErrorCode) {
+		case ErrNoError:
+			b.apiVersions = make(map[int16]ApiVersionsResponseKey, len(response.ApiKeys))
+			for _, key := range response.ApiKeys {
+				b.apiVersions[key.ApiKey] = key
+			}
+			return nil
+		case ErrUnsupportedVersion:
+			if request.Version == 0 {
+				return ErrUnsupportedVersion
+			}
+			retry := &ApiVersionsRequest{Version: 0}
+			for _, key := range response.ApiKeys {
+				if key.ApiKey == request.APIKey() && key.MaxVersion < request.Version {
+					retry.Version = key.MaxVersion
+				}
+			}
+			DebugLogger.Printf("Broker %s does not support ApiVersionsRequest v%d, retrying with v%d\n", b.addr, request.Version, retry.Version)
+			request = retry
+		default:
+			return KError(response.ErrorCode)
+		}
+	}
+}
+
+// versionedBody is implemented by the requests and responses whose version
+// can be negotiated.
+type versionedBody interface {
+	setVersion(v int16)
+}
+
+// versionChecker is implemented by the requests with optional fields which
+// are only sent from some version: checkVersion returns an error if a field
+// which is set would be dropped when sending the request at version v.
+type versionChecker interface {
+	checkVersion(v int16) error
+}
+
+// supportedVersion returns the highest version of the API key up to version
+// which is supported by the broker, or version as is if the versions
+// supported by the broker are not known.
+// b.lock must be held by caller
+func (b *Broker) supportedVersion(key, version int16) (int16, error) {
+	if b.apiVersions == nil {
+		return version, nil
+	}
+	supported, ok := b.apiVersions[key]
+	switch {
+	case !ok:
+		return 0, fmt.Errorf("%w: broker %s does not support API key %d",
+			ErrUnsupportedVersion, b.addr, key)
+	case version < supported.MinVersion:
+		return 0, fmt.Errorf("%w: broker %s requires version %d to %d of API key %d, version %d was requested",
+			ErrUnsupportedVersion, b.addr, supported.MinVersion, supported.MaxVersion, key, version)
+	case version > supported.MaxVersion:
+		return supported.MaxVersion, nil
+	}
+	return version, nil
+}
+
+// negotiateVersion returns req at the highest version supported by both the
+// broker and req, whose version was chosen from Config.Version. If lowered,
+// req is copied so that it can still be sent as is to other brokers, and the
+// version of its response res, if not nil, is lowered too. An error is
+// returned if a field of req which is set would be dropped.
+// b.lock must be held by caller
+func (b *Broker) negotiateVersion(req, res ProtocolBody) (ProtocolBody, error) {
+	version, err := b.supportedVersion(req.APIKey(), req.APIVersion())
+	if err != nil || version == req.APIVersion() {
+		return req, err
+	}
+	if checker, ok := req.(versionChecker); ok {
+		if err := checker.checkVersion(version); err != nil {
+			return nil, fmt.Errorf("%w: broker %s supports up to version %d of API key %d, %s",
+				ErrUnsupportedVersion, b.addr, version, req.APIKey(), err)
+		}
+	}
+
+	value := reflect.ValueOf(req).Elem()
+	lowered := reflect.New(value.Type())
+	lowered.Elem().Set(value)
+	req = lowered.Interface().(ProtocolBody)
+	req.(versionedBody).setVersion(version)
+	if res, ok := res.(versionedBody); ok {
+		res.setVersion(version)
+	}
+	return req, nil
+}
+
+// negotiatedVersion returns the version req is sent at to the broker, for
+// the requests whose fields depend on it, waiting for the broker to connect.
+func (b *Broker) negotiatedVersion(req ProtocolBody) (int16, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.reopenIfIdle()
+	return b.supportedVersion(req.APIKey(), req.APIVersion())
+}
+
 func handleResponsePromise(
 	req ProtocolBody,
 	res ProtocolBody,
@@ -1236,6 +1463,7 @@ func (b *Broker) responseReceiver() {
 			continue
 		}
 
+		b.markActive()
 		response.handle(buf, nil)
 	}
 	close(b.done)
@@ -1598,9 +1826,12 @@ func (b *Broker) sendAndReceiveSASLSCRAMv1(
 
 func (b *Broker) createSaslAuthenticateRequest(msg []byte) *SaslAuthenticateRequest {
 	authenticateRequest := SaslAuthenticateRequest{SaslAuthBytes: msg}
-	if b.conf.Version.IsAtLeast(V2_2_0_0) {
+	if b.conf.versionCeiling().IsAtLeast(V2_2_0_0) {
 		authenticateRequest.Version = 1
 	}
+	if version, err := b.supportedVersion(authenticateRequest.APIKey(), authenticateRequest.Version); err == nil {
+		authenticateRequest.Version = version
+	}
 
 	return &authenticateRequest
 }
@@ -1656,6 +1887,115 @@ func (b *Broker) computeSaslSessionLifetime(res *SaslAuthenticateResponse) {
 	}
 }
 
+// reauthenticationDue reports whether the SASL session of the connection must
+// be re-authenticated (KIP-368) before sending the next request.
+// b.lock must be held by caller
+func (b *Broker) reauthenticationDue() bool {
+	return b.clientSessionReauthenticationTimeMs > 0 && currentUnixMilli() > b.clientSessionReauthenticationTimeMs
+}
+
+// reauthenticate re-authenticates the SASL session of the connection with
+// fresh credentials, e.g. a new token from the AccessTokenProvider. Requests
+// wait for b.lock, so they are paused rather than failed in the meantime.
+// b.lock must be held by caller
+func (b *Broker) reauthenticate() error {
+	DebugLogger.Printf("Re-authenticating SASL session with broker %s\n", b.addr)
+	if err := b.authenticateViaSASLv1(); err != nil {
+		Logger.Printf("Error while re-authenticating SASL session with broker %s: %s\n", b.addr, err)
+		return err
+	}
+	b.scheduleReauthentication()
+	return nil
+}
+
+// scheduleReauthentication arms a timer to re-authenticate the SASL session
+// once it is due, so that idle connections are not closed by the broker when
+// the session expires. Busy connections usually re-authenticate earlier, when
+// sending a request.
+// b.lock must be held by caller
+func (b *Broker) scheduleReauthentication() {
+	if b.reauthenticationTimer != nil {
+		b.reauthenticationTimer.Stop()
+		b.reauthenticationTimer = nil
+	}
+	if b.clientSessionReauthenticationTimeMs <= 0 {
+		return
+	}
+
+	delay := time.Duration(b.clientSessionReauthenticationTimeMs-currentUnixMilli()+1) * time.Millisecond
+	b.reauthenticationTimer = time.AfterFunc(delay, func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+
+		if b.conn == nil || !b.reauthenticationDue() {
+			return
+		}
+		_ = b.reauthenticate()
+	})
+}
+
+// markActive records that the connection is in use, postponing its closing
+// for being idle.
+func (b *Broker) markActive() {
+	atomic.StoreInt64(&b.lastActivity, time.Now().UnixNano())
+}
+
+// scheduleIdleCheck arms a timer to close the connection once it has been
+// idle for Net.ConnectionsMaxIdle. It is then reopened by the next request.
+// b.lock must be held by caller
+func (b *Broker) scheduleIdleCheck() {
+	if b.idleTimer != nil {
+		b.idleTimer.Stop()
+		b.idleTimer = nil
+	}
+	maxIdle := b.conf.Net.ConnectionsMaxIdle
+	if maxIdle <= 0 {
+		return
+	}
+
+	delay := maxIdle - time.Since(time.Unix(0, atomic.LoadInt64(&b.lastActivity)))
+	if atomic.LoadInt64(&b.inFlight) > 0 {
+		delay = maxIdle
+	}
+	b.idleTimer = time.AfterFunc(delay, func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+
+		if b.conn == nil {
+			return
+		}
+		if atomic.LoadInt64(&b.inFlight) > 0 || time.Since(time.Unix(0, atomic.LoadInt64(&b.lastActivity))) < maxIdle {
+			b.scheduleIdleCheck()
+			return
+		}
+		DebugLogger.Printf("Closing connection to broker %s idle for %s\n", b.addr, maxIdle)
+		b.idleTimer = nil
+		_ = b.closeConnection()
+		b.idleClosed = true
+	})
+}
+
+// closedForIdle returns whether the connection was closed for being idle,
+// in which case the broker is still open.
+func (b *Broker) closedForIdle() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.conn == nil && b.idleClosed
+}
+
+// reopenIfIdle reconnects to the broker if the connection was closed for
+// being idle.
+// b.lock must be held by caller
+func (b *Broker) reopenIfIdle() {
+	if b.conn != nil || !b.idleClosed {
+		return
+	}
+	b.idleClosed = false
+	DebugLogger.Printf("Reopening idle connection to broker %s\n", b.addr)
+	b.connect(b.conf)
+}
+
 func (b *Broker) updateIncomingCommunicationMetrics(bytes int, requestLatency time.Duration) {
 	b.updateRequestLatencyAndInFlightMetrics(requestLatency)
 	b.responseRate.Mark(1)
@@ -1688,6 +2028,7 @@ func (b *Broker) updateRequestLatencyAndInFlightMetrics(requestLatency time.Dura
 }
 
 func (b *Broker) addRequestInFlightMetrics(i int64) {
+	atomic.AddInt64(&b.inFlight, i)
 	b.requestsInFlight.Inc(i)
 	if b.brokerRequestsInFlight != nil {
 		b.brokerRequestsInFlight.Inc(i)
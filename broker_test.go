@@ -116,7 +116,10 @@ func TestSimpleBrokerCommunication(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			Logger.Printf("Testing broker communication for %s", tt.name)
 			mb := NewMockBroker(t, 0)
-			mb.Returns(&mockEncoder{tt.response})
+			// the mock broker answers ApiVersionsRequest itself
+			if tt.name != "ApiVersionsRequest" {
+				mb.Returns(&mockEncoder{tt.response})
+			}
 			pendingNotify := make(chan brokerMetrics)
 			// Register a callback to be notified about successful requests
 			mb.SetNotifier(func(bytesRead, bytesWritten int) {
@@ -967,6 +970,263 @@ loop:
 	mockBroker.Close()
 }
 
+func TestKip368ReAuthenticationOfIdleConnection(t *testing.T) {
+	sessionLifetimeMs := int64(100)
+
+	mockBroker := NewMockBroker(t, 0)
+	defer mockBroker.Close()
+
+	countSaslAuthRequests := func() (count int) {
+		for _, rr := range mockBroker.History() {
+			if _, ok := rr.Request.(*SaslAuthenticateRequest); ok {
+				count++
+			}
+		}
+		return
+	}
+
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"SaslAuthenticateRequest": NewMockSaslAuthenticateResponse(t).
+			SetAuthBytes([]byte(`response_payload`)).
+			SetSessionLifetimeMs(sessionLifetimeMs),
+		"SaslHandshakeRequest": NewMockSaslHandshakeResponse(t).
+			SetEnabledMechanisms([]string{SASLTypeOAuth}),
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+	})
+
+	tokenProvider := newTokenProvider(&AccessToken{Token: "access-token-123"}, nil)
+
+	conf := NewTestConfig()
+	conf.Version = V2_2_0_0
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.Mechanism = SASLTypeOAuth
+	conf.Net.SASL.TokenProvider = tokenProvider
+
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	if connected, err := broker.Connected(); err != nil || !connected {
+		t.Fatal(err)
+	}
+
+	// no traffic is put on the wire, the session is re-authenticated on a timer
+	timeout := time.After(time.Duration(3*sessionLifetimeMs) * time.Millisecond)
+	for countSaslAuthRequests() < 3 {
+		select {
+		case <-timeout:
+			t.Fatalf("sasl reauth of idle connection has not occurred within expected timeframe, %d SaslAuthRequests", countSaslAuthRequests())
+		default:
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	if err := broker.Close(); err != nil {
+		t.Fatal(err)
+	}
+	closedCount := countSaslAuthRequests()
+	time.Sleep(time.Duration(2*sessionLifetimeMs) * time.Millisecond)
+	if count := countSaslAuthRequests(); count != closedCount {
+		t.Errorf("expected no sasl reauth after the broker was closed, got %d SaslAuthRequests instead of %d", count, closedCount)
+	}
+}
+
+func TestBrokerNegotiatesRequestVersions(t *testing.T) {
+	mockBroker := NewMockBroker(t, 0)
+	defer mockBroker.Close()
+
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t).SetApiKeys([]ApiVersionsResponseKey{
+			{ApiKey: 0, MinVersion: 0, MaxVersion: 2},  // Produce
+			{ApiKey: 3, MinVersion: 1, MaxVersion: 4},  // Metadata
+			{ApiKey: 16, MinVersion: 0, MaxVersion: 4}, // ListGroups
+			{ApiKey: 18, MinVersion: 0, MaxVersion: 2}, // ApiVersions
+		}),
+		"MetadataRequest": NewMockMetadataResponse(t),
+	})
+
+	// Version is not set by default
+	conf := NewConfig()
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+
+	// the version is lowered to the highest version supported by the broker,
+	// without changing the request of the caller
+	request := &MetadataRequest{Version: 9}
+	response, err := broker.GetMetadata(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Version != 9 || response.Version != 4 {
+		t.Errorf("Expected request version 9 and response version 4, got %d and %d", request.Version, response.Version)
+	}
+	// and never raised
+	if _, err := broker.GetMetadata(&MetadataRequest{Version: 2}); err != nil {
+		t.Fatal(err)
+	}
+	var versions, apiVersions []int16
+	for _, rr := range mockBroker.History() {
+		switch req := rr.Request.(type) {
+		case *MetadataRequest:
+			versions = append(versions, req.Version)
+		case *ApiVersionsRequest:
+			apiVersions = append(apiVersions, req.Version)
+		}
+	}
+	if !reflect.DeepEqual(versions, []int16{4, 2}) {
+		t.Errorf("Expected metadata requests v4 and v2 on the wire, got %v", versions)
+	}
+	// ApiVersionsRequest is retried with the version supported by the broker
+	if !reflect.DeepEqual(apiVersions, []int16{3, 2}) {
+		t.Errorf("Expected ApiVersions requests v3 and v2 on the wire, got %v", apiVersions)
+	}
+
+	if _, err := broker.GetMetadata(&MetadataRequest{Version: 0}); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion below the minimum version of the broker, got %v", err)
+	}
+	if _, err := broker.DescribeGroups(&DescribeGroupsRequest{}); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion for an API key not supported by the broker, got %v", err)
+	}
+	// the fields which would be dropped at the version of the broker are not
+	produce := &ProduceRequest{Version: 7, RequiredAcks: WaitForLocal}
+	produce.AddBatch("my_topic", 0, &RecordBatch{Version: 2})
+	if _, err := broker.Produce(produce); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion for record batches, got %v", err)
+	}
+	listGroups := &ListGroupsRequest{Version: 5, TypesFilter: []string{"classic"}}
+	if _, err := broker.ListGroups(listGroups); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion for the types filter, got %v", err)
+	}
+	for _, rr := range mockBroker.History() {
+		switch rr.Request.(type) {
+		case *ProduceRequest, *ListGroupsRequest, *DescribeGroupsRequest:
+			t.Errorf("Expected no %T to be sent", rr.Request)
+		}
+	}
+}
+
+func TestBrokerReconnectBackoff(t *testing.T) {
+	conf := NewTestConfig()
+	conf.Net.ReconnectBackoff = 100 * time.Millisecond
+	conf.Net.ReconnectBackoffMax = 400 * time.Millisecond
+	for failures, expected := range map[int]time.Duration{1: 100, 2: 200, 3: 400, 10: 400} {
+		expected *= time.Millisecond
+		if backoff := reconnectBackoff(conf, failures); backoff < expected*8/10 || backoff > expected*12/10 {
+			t.Errorf("Expected a backoff of %v +/- 20%% after %d failures, got %v", expected, failures, backoff)
+		}
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+
+	broker := NewBroker(addr)
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		if err := broker.Open(conf); err != nil {
+			t.Fatal(err)
+		}
+		if connected, err := broker.Connected(); connected || err == nil {
+			t.Fatalf("Expected the connection to fail, got %v %v", connected, err)
+		}
+		if elapsed := time.Since(start); i > 0 && elapsed < 80*time.Millisecond {
+			t.Errorf("Expected the reconnection to wait for the backoff, took %v", elapsed)
+		}
+	}
+	if broker.connectFailures != 2 {
+		t.Errorf("Expected 2 failed attempts, got %d", broker.connectFailures)
+	}
+}
+
+func TestBrokerClosesIdleConnections(t *testing.T) {
+	mockBroker := NewMockBroker(t, 0)
+	defer mockBroker.Close()
+
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t),
+		"ProduceRequest":  NewMockProduceResponse(t),
+	})
+
+	conf := NewTestConfig()
+	conf.Net.ConnectionsMaxIdle = 100 * time.Millisecond
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	waitIdle := func() {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			if connected, _ := broker.Connected(); !connected {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("Expected the idle connection to be closed")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		// the connection is reopened by the next request
+		if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		if connected, err := broker.Connected(); !connected || err != nil {
+			t.Fatalf("Expected the broker to be connected, got %v %v", connected, err)
+		}
+		waitIdle()
+	}
+
+	if err := broker.Close(); err != nil {
+		t.Errorf("Expected an idle broker to close, got %v", err)
+	}
+	if err := broker.Open(conf); err != nil {
+		t.Errorf("Expected a closed broker to open again, got %v", err)
+	}
+	waitIdle()
+
+	// the client closes idle brokers too, which then stay closed
+	safeAsyncClose(broker)
+	for deadline := time.Now().Add(5 * time.Second); broker.closedForIdle(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the idle broker to be closed")
+		}
+	}
+	if _, err := broker.GetMetadata(&MetadataRequest{}); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("Expected a closed broker not to reconnect, got %v", err)
+	}
+
+	// the connection is not idle while a response is awaited
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	mockBroker.SetLatency(3 * conf.Net.ConnectionsMaxIdle)
+	produced := make(chan error, 1)
+	request := &ProduceRequest{RequiredAcks: WaitForLocal}
+	request.AddMessage("my_topic", 0, &Message{Value: []byte("value")})
+	err := broker.AsyncProduce(request, func(_ *ProduceResponse, err error) {
+		produced <- err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-produced; err != nil {
+		t.Errorf("Expected the slow response to be received, got %v", err)
+	}
+	if connected, err := broker.Connected(); !connected || err != nil {
+		t.Errorf("Expected the broker to stay connected, got %v %v", connected, err)
+	}
+	safeClose(t, broker)
+}
+
 // We're not testing encoding/decoding here, so most of the requests/responses will be empty for simplicity's sake
 var brokerTestTable = []struct {
 	version  KafkaVersion
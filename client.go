@@ -157,6 +157,7 @@ type client struct {
 	metadataTopics          map[string]none                         // topics that need to collect metadata
 	coordinators            map[string]int32                        // Maps consumer group names to coordinating broker IDs
 	transactionCoordinators map[string]int32                        // Maps transaction ids to coordinating broker IDs
+	coordinatorBrokers      map[int32]*Broker                       // Maps broker ids to dedicated coordinator connections
 
 	// If the number of partitions is large, we can get some churn calling cachedPartitions,
 	// so the result is cached.  It is important to update this value whenever metadata is changed
@@ -184,7 +185,7 @@ func NewClient(addrs []string, conf *Config) (Client, error) {
 	}
 
 	if strings.Contains(addrs[0], ".servicebus.windows.net") {
-		if conf.Version.IsAtLeast(V1_1_0_0) || !conf.Version.IsAtLeast(V0_11_0_0) {
+		if conf.versionCeiling().IsAtLeast(V1_1_0_0) || !conf.versionCeiling().IsAtLeast(V0_11_0_0) {
 			Logger.Println("Connecting to Azure Event Hubs, forcing version to V1_0_0_0 for compatibility")
 			conf.Version = V1_0_0_0
 		}
@@ -200,6 +201,7 @@ func NewClient(addrs []string, conf *Config) (Client, error) {
 		cachedPartitionsResults: make(map[string][maxPartitionIndex][]int32),
 		coordinators:            make(map[string]int32),
 		transactionCoordinators: make(map[string]int32),
+		coordinatorBrokers:      make(map[int32]*Broker),
 	}
 
 	if conf.Net.ResolveCanonicalBootstrapServers {
@@ -246,6 +248,21 @@ func (client *client) Brokers() []*Broker {
 	return brokers
 }
 
+// allBrokers returns the brokers like Brokers, along with the dedicated
+// coordinator connections.
+func (client *client) allBrokers() []*Broker {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+	brokers := make([]*Broker, 0, len(client.brokers)+len(client.coordinatorBrokers))
+	for _, broker := range client.brokers {
+		brokers = append(brokers, broker)
+	}
+	for _, broker := range client.coordinatorBrokers {
+		brokers = append(brokers, broker)
+	}
+	return brokers
+}
+
 func (client *client) Broker(brokerID int32) (*Broker, error) {
 	client.lock.RLock()
 	defer client.lock.RUnlock()
@@ -261,18 +278,18 @@ func (client *client) InitProducerID() (*InitProducerIDResponse, error) {
 	// FIXME: this InitProducerID seems to only be called from client_test.go (TestInitProducerIDConnectionRefused) and has been superceded by transaction_manager.go?
 	brokerErrors := make([]error, 0)
 	for broker := client.LeastLoadedBroker(); broker != nil; broker = client.LeastLoadedBroker() {
-		request := &InitProducerIDRequest{}
+		request := &InitProducerIDRequest{ProducerID: noProducerID, ProducerEpoch: noProducerEpoch}
 
-		if client.conf.Version.IsAtLeast(V2_7_0_0) {
+		if client.conf.versionCeiling().IsAtLeast(V2_7_0_0) {
 			// Version 4 adds the support for new error code PRODUCER_FENCED.
 			request.Version = 4
-		} else if client.conf.Version.IsAtLeast(V2_5_0_0) {
+		} else if client.conf.versionCeiling().IsAtLeast(V2_5_0_0) {
 			// Version 3 adds ProducerId and ProducerEpoch, allowing producers to try to resume after an INVALID_PRODUCER_EPOCH error
 			request.Version = 3
-		} else if client.conf.Version.IsAtLeast(V2_4_0_0) {
+		} else if client.conf.versionCeiling().IsAtLeast(V2_4_0_0) {
 			// Version 2 is the first flexible version.
 			request.Version = 2
-		} else if client.conf.Version.IsAtLeast(V2_0_0_0) {
+		} else if client.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 			// Version 1 is the same as version 0.
 			request.Version = 1
 		}
@@ -316,7 +333,12 @@ func (client *client) Close() error {
 		safeAsyncClose(broker)
 	}
 
+	for _, broker := range client.coordinatorBrokers {
+		safeAsyncClose(broker)
+	}
+
 	client.brokers = nil
+	client.coordinatorBrokers = nil
 	client.metadata = nil
 	client.metadataTopics = nil
 
@@ -581,7 +603,7 @@ func (client *client) Controller() (*Broker, error) {
 		return nil, ErrClosedClient
 	}
 
-	if !client.conf.Version.IsAtLeast(V0_10_0_0) {
+	if !client.conf.versionCeiling().IsAtLeast(V0_10_0_0) {
 		return nil, ErrUnsupportedVersion
 	}
 
@@ -651,6 +673,7 @@ func (client *client) Coordinator(consumerGroup string) (*Broker, error) {
 		return nil, ErrConsumerCoordinatorNotAvailable
 	}
 
+	coordinator = client.coordinatorConnection(coordinator)
 	_ = coordinator.Open(client.conf)
 	return coordinator, nil
 }
@@ -690,6 +713,7 @@ func (client *client) TransactionCoordinator(transactionID string) (*Broker, err
 		return nil, ErrConsumerCoordinatorNotAvailable
 	}
 
+	coordinator = client.coordinatorConnection(coordinator)
 	_ = coordinator.Open(client.conf)
 	return coordinator, nil
 }
@@ -724,12 +748,14 @@ func (client *client) updateBroker(brokers []*Broker) {
 	currentBroker := make(map[int32]*Broker, len(brokers))
 
 	for _, broker := range brokers {
+		client.mapBrokerAddress(broker)
 		currentBroker[broker.ID()] = broker
 		if client.brokers[broker.ID()] == nil { // add new broker
 			client.brokers[broker.ID()] = broker
 			DebugLogger.Printf("client/brokers registered new broker #%d at %s", broker.ID(), broker.Addr())
 		} else if broker.Addr() != client.brokers[broker.ID()].Addr() { // replace broker with new address
 			safeAsyncClose(client.brokers[broker.ID()])
+			client.closeCoordinatorConnection(broker.ID())
 			client.brokers[broker.ID()] = broker
 			Logger.Printf("client/brokers replaced registered broker #%d with %s", broker.ID(), broker.Addr())
 		}
@@ -738,6 +764,7 @@ func (client *client) updateBroker(brokers []*Broker) {
 	for id, broker := range client.brokers {
 		if _, exist := currentBroker[id]; !exist { // remove old broker
 			safeAsyncClose(broker)
+			client.closeCoordinatorConnection(id)
 			delete(client.brokers, id)
 			Logger.Printf("client/broker remove invalid broker #%d with %s", broker.ID(), broker.Addr())
 		}
@@ -752,17 +779,31 @@ func (client *client) registerBroker(broker *Broker) {
 		Logger.Printf("cannot register broker #%d at %s, client already closed", broker.ID(), broker.Addr())
 		return
 	}
+	client.mapBrokerAddress(broker)
 
 	if client.brokers[broker.ID()] == nil {
 		client.brokers[broker.ID()] = broker
 		DebugLogger.Printf("client/brokers registered new broker #%d at %s", broker.ID(), broker.Addr())
 	} else if broker.Addr() != client.brokers[broker.ID()].Addr() {
 		safeAsyncClose(client.brokers[broker.ID()])
+		client.closeCoordinatorConnection(broker.ID())
 		client.brokers[broker.ID()] = broker
 		Logger.Printf("client/brokers replaced registered broker #%d with %s", broker.ID(), broker.Addr())
 	}
 }
 
+// mapBrokerAddress rewrites the address advertised by a broker with the
+// Net.AddressMapper, if any.
+func (client *client) mapBrokerAddress(broker *Broker) {
+	if client.conf.Net.AddressMapper == nil {
+		return
+	}
+	if addr := client.conf.Net.AddressMapper.MapAddress(broker.addr); addr != broker.addr {
+		DebugLogger.Printf("client/brokers mapped address %s of broker #%d to %s", broker.addr, broker.id, addr)
+		broker.addr = addr
+	}
+}
+
 // deregisterBroker removes a broker from the broker list, and if it's
 // not in the broker list, removes it from seedBrokers.
 func (client *client) deregisterBroker(broker *Broker) {
@@ -902,22 +943,7 @@ func (client *client) getOffset(topic string, partitionID int32, timestamp int64
 		return -1, err
 	}
 
-	request := &OffsetRequest{}
-	if client.conf.Version.IsAtLeast(V2_1_0_0) {
-		// Version 4 adds the current leader epoch, which is used for fencing.
-		request.Version = 4
-	} else if client.conf.Version.IsAtLeast(V2_0_0_0) {
-		// Version 3 is the same as version 2.
-		request.Version = 3
-	} else if client.conf.Version.IsAtLeast(V0_11_0_0) {
-		// Version 2 adds the isolation level, which is used for transactional reads.
-		request.Version = 2
-	} else if client.conf.Version.IsAtLeast(V0_10_1_0) {
-		// Version 1 removes MaxNumOffsets.  From this version forward, only a single
-		// offset can be returned.
-		request.Version = 1
-	}
-
+	request := newOffsetRequest(client.conf.versionCeiling())
 	request.AddBlock(topic, partitionID, timestamp, 1)
 
 	response, err := broker.GetAvailableOffsets(request)
@@ -1027,8 +1053,9 @@ func (client *client) tryRefreshMetadata(topics []string, attemptsRemaining int,
 			DebugLogger.Printf("client/metadata fetching metadata for all topics from broker %s\n", broker.addr)
 		}
 
-		req := NewMetadataRequest(client.conf.Version, topics)
+		req := NewMetadataRequest(client.conf.versionCeiling(), topics)
 		req.AllowAutoTopicCreation = allowAutoTopicCreation
+		req.autoTopicCreationDisabled = !allowAutoTopicCreation
 		atomic.StoreInt64(&client.updateMetadataMs, time.Now().UnixMilli())
 
 		response, err := broker.GetMetadata(req)
@@ -1174,6 +1201,40 @@ func (client *client) cachedTransactionCoordinator(transactionID string) *Broker
 	return nil
 }
 
+// coordinatorConnection returns the dedicated connection to the coordinator
+// broker if Net.DedicatedCoordinatorConnections is enabled, or broker itself
+// otherwise.
+func (client *client) coordinatorConnection(broker *Broker) *Broker {
+	if !client.conf.Net.DedicatedCoordinatorConnections {
+		return broker
+	}
+
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	if client.coordinatorBrokers == nil {
+		return broker
+	}
+
+	coordinator := client.coordinatorBrokers[broker.ID()]
+	if coordinator == nil || coordinator.Addr() != broker.Addr() {
+		client.closeCoordinatorConnection(broker.ID())
+		coordinator = &Broker{id: broker.id, addr: broker.addr, rack: broker.rack, connection: "coordinator"}
+		client.coordinatorBrokers[broker.ID()] = coordinator
+		DebugLogger.Printf("client/coordinator opening a dedicated connection to broker #%d at %s", broker.ID(), broker.Addr())
+	}
+	return coordinator
+}
+
+// closeCoordinatorConnection closes the dedicated coordinator connection to
+// the broker with the given id, if any. You must hold the write lock before
+// calling this function.
+func (client *client) closeCoordinatorConnection(id int32) {
+	if coordinator, ok := client.coordinatorBrokers[id]; ok {
+		safeAsyncClose(coordinator)
+		delete(client.coordinatorBrokers, id)
+	}
+}
+
 func (client *client) cachedController() *Broker {
 	client.lock.RLock()
 	defer client.lock.RUnlock()
@@ -1211,11 +1272,11 @@ func (client *client) findCoordinator(coordinatorKey string, coordinatorType Coo
 		request.CoordinatorType = coordinatorType
 
 		// Version 1 adds KeyType.
-		if client.conf.Version.IsAtLeast(V0_11_0_0) {
+		if client.conf.versionCeiling().IsAtLeast(V0_11_0_0) {
 			request.Version = 1
 		}
 		// Version 2 is the same as version 1.
-		if client.conf.Version.IsAtLeast(V2_0_0_0) {
+		if client.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 			request.Version = 2
 		}
 
@@ -3,6 +3,7 @@ package sarama
 import (
 	"errors"
 	"io"
+	"regexp"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -974,6 +975,133 @@ func TestClientCoordinatorWithConsumerOffsetsTopic(t *testing.T) {
 	safeClose(t, client)
 }
 
+func TestClientDedicatedCoordinatorConnections(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	coordinator := NewMockBroker(t, 2)
+	defer coordinator.Close()
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(coordinator.Addr(), coordinator.BrokerID())
+	seedBroker.Returns(metadataResponse)
+
+	conf := NewTestConfig()
+	conf.Net.DedicatedCoordinatorConnections = true
+	client, err := NewClient([]string{seedBroker.Addr()}, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coordinatorResponse := new(ConsumerMetadataResponse)
+	coordinatorResponse.CoordinatorID = coordinator.BrokerID()
+	coordinatorResponse.CoordinatorHost = "127.0.0.1"
+	coordinatorResponse.CoordinatorPort = coordinator.Port()
+	coordinator.Returns(coordinatorResponse)
+
+	broker, err := client.Coordinator("my_group")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataBroker, err := client.Broker(coordinator.BrokerID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if broker == dataBroker {
+		t.Fatal("Expected a dedicated connection to the coordinator")
+	}
+	if broker.ID() != coordinator.BrokerID() || broker.Addr() != coordinator.Addr() {
+		t.Errorf("Expected the coordinator #%d at %s, got #%d at %s", coordinator.BrokerID(), coordinator.Addr(), broker.ID(), broker.Addr())
+	}
+	if cached, err := client.Coordinator("my_group"); err != nil || cached != broker {
+		t.Errorf("Expected the dedicated connection to be reused, got %v %v", cached, err)
+	}
+
+	coordinator.Returns(new(HeartbeatResponse))
+	if _, err := broker.Heartbeat(&HeartbeatRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	// the usage metrics are kept per connection
+	if meter, ok := conf.MetricRegistry.Get("request-rate-for-broker-2-coordinator").(metrics.Meter); !ok || meter.Count() != 1 {
+		t.Errorf("Expected one request on the coordinator connection, got %v", meter)
+	}
+	// the FindCoordinator request was sent on the data connection
+	if meter, ok := conf.MetricRegistry.Get("request-rate-for-broker-2").(metrics.Meter); !ok || meter.Count() != 1 {
+		t.Errorf("Expected one request on the data connection, got %v", meter)
+	}
+
+	// connections are closed asynchronously
+	awaitClosed := func(message string) {
+		t.Helper()
+		for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+			if connected, _ := broker.Connected(); !connected {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal(message)
+			}
+		}
+	}
+
+	// rotated TLS certificates reach the coordinator connection too
+	drainBrokerConnections(client)
+	awaitClosed("Expected the coordinator connection to be drained")
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	coordinator.Returns(new(HeartbeatResponse))
+	if _, err := broker.Heartbeat(&HeartbeatRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	safeClose(t, client)
+	awaitClosed("Expected the coordinator connection to be closed with the client")
+}
+
+func TestClientAddressMapper(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	broker2 := NewMockBroker(t, 2)
+	defer broker2.Close()
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker("kafka-2.internal:9092", broker2.BrokerID())
+	seedBroker.Returns(metadataResponse)
+
+	conf := NewTestConfig()
+	conf.Net.AddressMapper = &RegexpAddressMapper{
+		Pattern:     regexp.MustCompile(`^kafka-(\d+)\.internal:9092$`),
+		Replacement: broker2.Addr(),
+	}
+	client, err := NewClient([]string{seedBroker.Addr()}, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	broker, err := client.Broker(broker2.BrokerID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if broker.Addr() != broker2.Addr() {
+		t.Errorf("Expected the address of broker #2 to be mapped to %s, got %s", broker2.Addr(), broker.Addr())
+	}
+
+	// the coordinator address is mapped as well, so the broker is kept
+	coordinatorResponse := new(ConsumerMetadataResponse)
+	coordinatorResponse.CoordinatorID = broker2.BrokerID()
+	coordinatorResponse.CoordinatorHost = "kafka-2.internal"
+	coordinatorResponse.CoordinatorPort = 9092
+	broker2.Returns(coordinatorResponse)
+
+	coordinator, err := client.Coordinator("my_group")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coordinator != broker {
+		t.Errorf("Expected the coordinator to be broker #2 at %s, got #%d at %s", broker2.Addr(), coordinator.ID(), coordinator.Addr())
+	}
+}
+
 func TestClientCoordinatorChangeWithConsumerOffsetsTopic(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	staleCoordinator := NewMockBroker(t, 2)
@@ -45,6 +45,15 @@ type Config struct {
 		// https://kafka.apache.org/28/documentation.html#producerconfigs_max.in.flight.requests.per.connection
 		MaxOpenRequests int
 
+		// DedicatedCoordinatorConnections makes the Client open a separate
+		// connection to the group and transaction coordinators, like the Java
+		// client does, so that heartbeats, offset commits and transactional
+		// requests are not queued behind large fetches or produces on the
+		// connection to the same broker (defaults to false). The per-broker
+		// metrics of these connections are suffixed with "-coordinator", e.g.
+		// "request-rate-for-broker-1-coordinator".
+		DedicatedCoordinatorConnections bool
+
 		// All three of the below configurations are similar to the
 		// `socket.timeout.ms` setting in JVM kafka. All of them default
 		// to 30 seconds.
@@ -52,6 +61,22 @@ type Config struct {
 		ReadTimeout  time.Duration // How long to wait for a response.
 		WriteTimeout time.Duration // How long to wait for a transmit.
 
+		// ReconnectBackoff is how long to wait before reconnecting to a broker
+		// after a failed connection attempt (default 50ms). The wait doubles
+		// with each consecutive failure up to ReconnectBackoffMax (default 1s),
+		// and 20% of random jitter is applied to avoid connection storms.
+		// Similar to `reconnect.backoff.ms` and `reconnect.backoff.max.ms`
+		// in the JVM client.
+		ReconnectBackoff    time.Duration
+		ReconnectBackoffMax time.Duration
+
+		// ConnectionsMaxIdle closes connections to brokers which have not sent
+		// or received anything for that long, and await no response (defaults
+		// to 0, never). They are reopened when the next request is sent. Similar to
+		// `connections.max.idle.ms` in the JVM client, where it defaults to 9
+		// minutes, which is shorter than the broker's own idle timeout.
+		ConnectionsMaxIdle time.Duration
+
 		// ResolveCanonicalBootstrapServers turns each bootstrap broker address
 		// into a set of IPs, then does a reverse lookup on each one to get its
 		// canonical hostname. This list of hostnames then replaces the
@@ -61,6 +86,21 @@ type Config struct {
 		// hostnames. Defaults to false.
 		ResolveCanonicalBootstrapServers bool
 
+		// UseAllDNSIPs makes connections try each IP address a bootstrap or
+		// broker host name resolves to, in turn, until one succeeds, giving
+		// each one the whole DialTimeout (defaults to false). Without it the
+		// dialer also tries them all but shares DialTimeout between them.
+		// Similar to `client.dns.lookup=use_all_dns_ips` in the JVM client.
+		// It is ignored when Proxy is enabled, as the proxy resolves the names.
+		UseAllDNSIPs bool
+
+		// AddressMapper rewrites the addresses the brokers advertise in
+		// metadata and coordinator responses before connecting to them, e.g.
+		// when they are only reachable through port forwarding or NAT
+		// (defaults to nil, using the advertised addresses). See
+		// StaticAddressMapper, RegexpAddressMapper and AddressMapperFunc.
+		AddressMapper AddressMapper
+
 		TLS struct {
 			// Whether or not to use TLS when connecting to the broker
 			// (defaults to false).
@@ -104,7 +144,8 @@ type Config struct {
 			// TokenProvider is a user-defined callback for generating
 			// access tokens for SASL/OAUTHBEARER auth. See the
 			// AccessTokenProvider interface docs for proper implementation
-			// guidelines.
+			// guidelines. ClientCredentialsTokenProvider implements the
+			// OAuth 2.0 client credentials grant.
 			TokenProvider AccessTokenProvider
 
 			GSSAPI GSSAPIConfig
@@ -127,6 +168,9 @@ type Config struct {
 			Enable bool
 			// The proxy dialer to use enabled (defaults to nil).
 			Dialer proxy.Dialer
+			// HTTP configures HTTP CONNECT proxies, used when Enable is true
+			// and Dialer is nil.
+			HTTP HTTPProxyConfig
 		}
 	}
 
@@ -409,12 +453,25 @@ type Config struct {
 		// between two messages being sent may not be recognized as a timeout.
 		MaxProcessingTime time.Duration
 
+		// The amount of time without any records being returned for a partition
+		// after which a PartitionIdle event is sent on the Events channel of its
+		// PartitionConsumer. It is checked whenever a fetch response arrives, so
+		// it is only as precise as MaxWaitTime and paused partitions never become
+		// idle. Defaults to 0, which disables idle events.
+		IdleTimeout time.Duration
+
 		// Return specifies what channels will be populated. If they are set to true,
 		// you must read from them to prevent deadlock.
 		Return struct {
 			// If enabled, any errors that occurred while consuming are returned on
 			// the Errors channel (default disabled).
 			Errors bool
+
+			// If enabled, a PartitionEOF event is returned on the Events channel
+			// every time a partition consumer catches up with the end of its
+			// partition (default disabled). Similar to `enable.partition.eof` in
+			// librdkafka.
+			PartitionEOF bool
 		}
 
 		// Offsets specifies configuration for how and when to commit consumed
@@ -453,6 +510,13 @@ type Config struct {
 				// requests during OffsetManager shutdown (default 3).
 				Max int
 			}
+
+			// Store is where offsets are fetched from and committed to. If nil
+			// (the default), offsets are stored in Kafka through the group
+			// coordinator, bound to the current group generation. Set it to
+			// store offsets externally, e.g. atomically with the processed data;
+			// consumer groups still use the group protocol for assignment only.
+			Store OffsetStore
 		}
 
 		// IsolationLevel support 2 mode:
@@ -484,11 +548,27 @@ type Config struct {
 	ChannelBufferSize int
 	// ApiVersionsRequest determines whether Sarama should send an
 	// ApiVersionsRequest message to each broker as part of its initial
-	// connection. This defaults to `true` to match the official Java client
+	// connection, to negotiate the version of the requests sent to the broker
+	// (see Version). This defaults to `true` to match the official Java client
 	// and most 3rdparty ones.
 	ApiVersionsRequest bool
-	// The version of Kafka that Sarama will assume it is running against.
-	// Defaults to the oldest supported stable version. Since Kafka provides
+	// The version of Kafka that Sarama will assume it is running against,
+	// unset by default.
+	// When ApiVersionsRequest is enabled, the version of every request is
+	// negotiated with each broker: it is the highest version supported by
+	// both the broker and Sarama, and Version is an optional ceiling, for
+	// example to keep using older protocol versions. A request which needs a
+	// newer version than the broker supports, for example to carry a field
+	// which was set, fails with ErrUnsupportedVersion. Negotiating requires
+	// Kafka 0.10 or later: Version must be set to talk to older brokers.
+	// When ApiVersionsRequest is disabled, the requests are only chosen from
+	// Version, DefaultVersion if unset.
+	// Breaking change: NewConfig used to set Version to DefaultVersion. Code
+	// reading Version now sees the zero KafkaVersion unless it was set, and
+	// Validate only checks the features which require a newer Kafka version,
+	// such as the idempotent producer or ZSTD compression, against Version
+	// when set: otherwise they fail with ErrUnsupportedVersion when sent to
+	// a broker which does not support them. Since Kafka provides
 	// backwards-compatibility, setting it to a version older than you have
 	// will not break anything, although it may prevent you from using the
 	// latest features. Setting it to a version greater than you are actually
@@ -514,6 +594,8 @@ func NewConfig() *Config {
 	c.Net.DialTimeout = 30 * time.Second
 	c.Net.ReadTimeout = 30 * time.Second
 	c.Net.WriteTimeout = 30 * time.Second
+	c.Net.ReconnectBackoff = 50 * time.Millisecond
+	c.Net.ReconnectBackoffMax = 1 * time.Second
 	c.Net.SASL.Handshake = true
 	c.Net.SASL.Version = SASLHandshakeV1
 
@@ -558,7 +640,6 @@ func NewConfig() *Config {
 	c.ClientID = defaultClientID
 	c.ChannelBufferSize = 256
 	c.ApiVersionsRequest = true
-	c.Version = DefaultVersion
 	c.MetricRegistry = metrics.NewRegistry()
 
 	return c
@@ -628,6 +709,12 @@ func (c *Config) Validate() error {
 		return ConfigurationError("Net.ReadTimeout must be > 0")
 	case c.Net.WriteTimeout <= 0:
 		return ConfigurationError("Net.WriteTimeout must be > 0")
+	case c.Net.ReconnectBackoff < 0:
+		return ConfigurationError("Net.ReconnectBackoff must be >= 0")
+	case c.Net.ReconnectBackoffMax < c.Net.ReconnectBackoff:
+		return ConfigurationError("Net.ReconnectBackoffMax must be >= Net.ReconnectBackoff")
+	case c.Net.ConnectionsMaxIdle < 0:
+		return ConfigurationError("Net.ConnectionsMaxIdle must be >= 0")
 	case c.Net.SASL.Enable:
 		if c.Net.SASL.Mechanism == "" {
 			c.Net.SASL.Mechanism = SASLTypePlaintext
@@ -696,6 +783,12 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.Net.Proxy.Enable && c.Net.Proxy.Dialer == nil {
+		if err := c.Net.Proxy.HTTP.validate(); err != nil {
+			return err
+		}
+	}
+
 	// validate the Admin values
 	switch {
 	case c.Admin.Timeout <= 0:
@@ -738,7 +831,7 @@ func (c *Config) Validate() error {
 		return ConfigurationError("Producer.Retry.Backoff must be >= 0")
 	}
 
-	if c.Producer.Compression == CompressionLZ4 && !c.Version.IsAtLeast(V0_10_0_0) {
+	if c.Producer.Compression == CompressionLZ4 && !c.versionCeiling().IsAtLeast(V0_10_0_0) {
 		return ConfigurationError("lz4 compression requires Version >= V0_10_0_0")
 	}
 
@@ -750,12 +843,12 @@ func (c *Config) Validate() error {
 		}
 	}
 
-	if c.Producer.Compression == CompressionZSTD && !c.Version.IsAtLeast(V2_1_0_0) {
+	if c.Producer.Compression == CompressionZSTD && !c.versionCeiling().IsAtLeast(V2_1_0_0) {
 		return ConfigurationError("zstd compression requires Version >= V2_1_0_0")
 	}
 
 	if c.Producer.Idempotent {
-		if !c.Version.IsAtLeast(V0_11_0_0) {
+		if !c.versionCeiling().IsAtLeast(V0_11_0_0) {
 			return ConfigurationError("Idempotent producer requires Version >= V0_11_0_0")
 		}
 		if c.Producer.Retry.Max == 0 {
@@ -787,6 +880,8 @@ func (c *Config) Validate() error {
 		return ConfigurationError("Consumer.MaxProcessingTime must be > 0")
 	case c.Consumer.Retry.Backoff < 0:
 		return ConfigurationError("Consumer.Retry.Backoff must be >= 0")
+	case c.Consumer.IdleTimeout < 0:
+		return ConfigurationError("Consumer.IdleTimeout must be >= 0")
 	case c.Consumer.Offsets.AutoCommit.Interval <= 0:
 		return ConfigurationError("Consumer.Offsets.AutoCommit.Interval must be > 0")
 	case c.Consumer.Offsets.Initial != OffsetOldest && c.Consumer.Offsets.Initial != OffsetNewest:
@@ -807,7 +902,7 @@ func (c *Config) Validate() error {
 	}
 
 	// validate IsolationLevel
-	if c.Consumer.IsolationLevel == ReadCommitted && !c.Version.IsAtLeast(V0_11_0_0) {
+	if c.Consumer.IsolationLevel == ReadCommitted && !c.versionCeiling().IsAtLeast(V0_11_0_0) {
 		return ConfigurationError("ReadCommitted requires Version >= V0_11_0_0")
 	}
 
@@ -836,7 +931,7 @@ func (c *Config) Validate() error {
 	}
 
 	if c.Consumer.Group.InstanceId != "" {
-		if !c.Version.IsAtLeast(V2_3_0_0) {
+		if !c.versionCeiling().IsAtLeast(V2_3_0_0) {
 			return ConfigurationError("Consumer.Group.InstanceId need Version >= 2.3")
 		}
 		if err := validateGroupInstanceId(c.Consumer.Group.InstanceId); err != nil {
@@ -851,23 +946,57 @@ func (c *Config) Validate() error {
 	}
 
 	// only validate clientID locally for Kafka versions before KIP-190 was implemented
-	if !c.Version.IsAtLeast(V1_0_0_0) && !validClientID.MatchString(c.ClientID) {
+	if !c.versionCeiling().IsAtLeast(V1_0_0_0) && !validClientID.MatchString(c.ClientID) {
 		return ConfigurationError(fmt.Sprintf("ClientID value %q is not valid for Kafka versions before 1.0.0", c.ClientID))
 	}
 
 	return nil
 }
 
+// versionCeiling returns the Kafka version the versions of the requests are
+// chosen from, before they are negotiated with each broker: Version if set,
+// otherwise MaxVersion, or DefaultVersion when they are not negotiated.
+func (c *Config) versionCeiling() KafkaVersion {
+	switch {
+	case c.Version != KafkaVersion{}:
+		return c.Version
+	case c.ApiVersionsRequest:
+		return MaxVersion
+	default:
+		return DefaultVersion
+	}
+}
+
+// negotiatesVersions returns whether the versions of the requests are
+// negotiated with each broker, which requires ApiVersionsRequest (Kafka 0.10).
+func (c *Config) negotiatesVersions() bool {
+	return c.ApiVersionsRequest && c.versionCeiling().IsAtLeast(V0_10_0_0)
+}
+
 func (c *Config) getDialer() proxy.Dialer {
 	if c.Net.Proxy.Enable {
 		Logger.Println("using proxy")
+		if c.Net.Proxy.Dialer == nil {
+			return &httpProxyDialer{
+				config: c.Net.Proxy.HTTP,
+				forward: &net.Dialer{
+					Timeout:   c.Net.DialTimeout,
+					KeepAlive: c.Net.KeepAlive,
+					LocalAddr: c.Net.LocalAddr,
+				},
+			}
+		}
 		return c.Net.Proxy.Dialer
 	} else {
-		return &net.Dialer{
+		dialer := &net.Dialer{
 			Timeout:   c.Net.DialTimeout,
 			KeepAlive: c.Net.KeepAlive,
 			LocalAddr: c.Net.LocalAddr,
 		}
+		if c.Net.UseAllDNSIPs {
+			return &allDNSIPsDialer{forward: dialer, timeout: c.Net.DialTimeout, lookupHost: net.DefaultResolver.LookupHost}
+		}
+		return dialer
 	}
 }
 
@@ -0,0 +1,116 @@
+package sarama
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// DescribeConfigsResult is the configuration of a single resource described by
+// ClusterAdmin.DescribeConfigs.
+type DescribeConfigsResult struct {
+	Type ConfigResourceType
+	Name string
+	// Configs contains the config entries of the resource, including their
+	// source and, if requested, their synonyms and documentation.
+	Configs []*ConfigEntry
+	// Err is the error of the resource, if it could not be described.
+	Err error
+}
+
+// configResourceKey identifies a resource in a DescribeConfigs response.
+type configResourceKey struct {
+	resourceType ConfigResourceType
+	name         string
+}
+
+func (ca *clusterAdmin) DescribeConfigs(resources []ConfigResource, includeSynonyms, includeDocumentation bool) ([]*DescribeConfigsResult, error) {
+	if includeSynonyms && !ca.conf.versionCeiling().IsAtLeast(V1_1_0_0) {
+		return nil, ConfigurationError("describing config synonyms requires Version >= V1_1_0_0")
+	}
+	if includeDocumentation && !ca.conf.versionCeiling().IsAtLeast(V2_6_0_0) {
+		return nil, ConfigurationError("describing config documentation requires Version >= V2_6_0_0")
+	}
+
+	// Broker and broker logger resources must be sent to the broker in
+	// question, all other resources are batched into a single request.
+	perBroker := make(map[int32][]*ConfigResource)
+	var anyBroker []*ConfigResource
+	for i := range resources {
+		resource := &resources[i]
+		if !dependsOnSpecificNode(*resource) {
+			anyBroker = append(anyBroker, resource)
+			continue
+		}
+		id, err := strconv.ParseInt(resource.Name, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid broker id %q: %w", resource.Name, err)
+		}
+		perBroker[int32(id)] = append(perBroker[int32(id)], resource)
+	}
+
+	described := make(map[configResourceKey]*DescribeConfigsResult, len(resources))
+	describe := func(b *Broker, err error, batch []*ConfigResource) {
+		if err == nil {
+			_ = b.Open(ca.client.Config())
+			request := newDescribeConfigsRequest(ca.conf.versionCeiling())
+			request.Resources = batch
+			request.IncludeSynonyms = includeSynonyms
+			request.IncludeDocumentation = includeDocumentation
+
+			var rsp *DescribeConfigsResponse
+			if rsp, err = b.DescribeConfigs(request); err == nil {
+				for _, rspResource := range rsp.Resources {
+					described[configResourceKey{rspResource.Type, rspResource.Name}] = newDescribeConfigsResult(rspResource)
+				}
+			}
+		}
+		if err != nil {
+			for _, resource := range batch {
+				described[configResourceKey{resource.Type, resource.Name}] = &DescribeConfigsResult{
+					Type: resource.Type,
+					Name: resource.Name,
+					Err:  err,
+				}
+			}
+		}
+	}
+
+	for id, batch := range perBroker {
+		b, err := ca.findBroker(id)
+		describe(b, err, batch)
+	}
+	if len(anyBroker) > 0 {
+		b, err := ca.findAnyBroker()
+		describe(b, err, anyBroker)
+	}
+
+	results := make([]*DescribeConfigsResult, len(resources))
+	for i, resource := range resources {
+		result, ok := described[configResourceKey{resource.Type, resource.Name}]
+		if !ok {
+			result = &DescribeConfigsResult{Type: resource.Type, Name: resource.Name, Err: ErrIncompleteResponse}
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+func newDescribeConfigsResult(rspResource *ResourceResponse) *DescribeConfigsResult {
+	result := &DescribeConfigsResult{
+		Type:    rspResource.Type,
+		Name:    rspResource.Name,
+		Configs: rspResource.Configs,
+	}
+	if rspResource.ErrorCode != 0 {
+		kerr := KError(rspResource.ErrorCode)
+		if rspResource.ErrorMsg != "" {
+			result.Err = fmt.Errorf("%w: %s", kerr, rspResource.ErrorMsg)
+		} else {
+			result.Err = kerr
+		}
+	} else if rspResource.ErrorMsg != "" {
+		result.Err = errors.New(rspResource.ErrorMsg)
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClusterAdminDescribeConfigs(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	secondBroker := NewMockBroker(t, 2)
+	defer secondBroker.Close()
+
+	metadata := NewMockMetadataResponse(t).
+		SetController(seedBroker.BrokerID()).
+		SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+		SetBroker(secondBroker.Addr(), secondBroker.BrokerID())
+	handlers := map[string]MockResponse{
+		"ApiVersionsRequest":     NewMockApiVersionsResponse(t),
+		"MetadataRequest":        metadata,
+		"DescribeConfigsRequest": NewMockDescribeConfigsResponse(t),
+	}
+	seedBroker.SetHandlerByMap(handlers)
+	secondBroker.SetHandlerByMap(handlers)
+
+	config := NewTestConfig()
+	config.Version = V2_8_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	results, err := admin.DescribeConfigs([]ConfigResource{
+		{Type: TopicResource, Name: "foo"},
+		{Type: BrokerResource, Name: "2"},
+		{Type: TopicResource, Name: "bar"},
+	}, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	for i, expected := range []string{"foo", "2", "bar"} {
+		if results[i].Name != expected || results[i].Err != nil {
+			t.Errorf("Expected result %d for %s without error, got %+v", i, expected, results[i])
+		}
+	}
+
+	retention := results[0].Configs[1]
+	if retention.Name != "retention.ms" || retention.Type != ConfigTypeLong ||
+		retention.Documentation != "Documentation of retention.ms" || len(retention.Synonyms) != 1 {
+		t.Errorf("Unexpected config entry %+v", retention)
+	}
+	if results[1].Configs[0].Type != ConfigTypeInt {
+		t.Errorf("Expected the broker config to be an int, got %v", results[1].Configs[0].Type)
+	}
+
+	var brokerRequest *DescribeConfigsRequest
+	for _, rr := range secondBroker.History() {
+		if request, ok := rr.Request.(*DescribeConfigsRequest); ok && request.Resources[0].Type == BrokerResource {
+			brokerRequest = request
+		}
+	}
+	if brokerRequest == nil {
+		t.Fatal("Expected the broker resource to be described by broker 2")
+	}
+	if brokerRequest.Version != 4 || !brokerRequest.IncludeDocumentation || len(brokerRequest.Resources) != 1 {
+		t.Errorf("Unexpected request to broker 2 %+v", brokerRequest)
+	}
+}
+
+func TestClusterAdminDescribeConfigsUnsupportedVersion(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_0_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	resources := []ConfigResource{{Type: TopicResource, Name: "foo"}}
+	var configErr ConfigurationError
+	if _, err := admin.DescribeConfigs(resources, true, true); !errors.As(err, &configErr) {
+		t.Errorf("Expected a ConfigurationError for documentation, got %v", err)
+	}
+}
@@ -0,0 +1,797 @@
+package sarama
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kcore-io/sarama/keystore"
+)
+
+// LoadProperties reads a Java .properties file, such as the client.properties
+// file of the Kafka command line tools.
+func LoadProperties(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	properties, err := parseProperties(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load properties %s: %w", path, err)
+	}
+	return properties, nil
+}
+
+// ConfigFromPropertiesFile loads a Java .properties file with LoadProperties
+// and translates it with ConfigFromProperties.
+func ConfigFromPropertiesFile(path string) (*Config, []string, error) {
+	properties, err := LoadProperties(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ConfigFromProperties(properties)
+}
+
+// ConfigFromProperties translates the settings of a Java client, as found in
+// a client.properties file, to a Config, and returns it along with the
+// bootstrap addresses (bootstrap.servers). Settings that are not given keep
+// the defaults of NewConfig, and settings without an equivalent are logged to
+// Logger and ignored. Java clients negotiate the protocol versions, so
+// Config.Version should still be set before the Config is used, which
+// validates it. group.id has no equivalent in Config, pass it to
+// NewConsumerGroup along with the returned addresses.
+//
+// The SASL mechanism (sasl.mechanism, GSSAPI by default like in Java) is
+// configured from the login module of sasl.jaas.config:
+//
+//   - PlainLoginModule and ScramLoginModule use the username and password
+//     options.
+//   - OAuthBearerLoginModule uses a ClientCredentialsTokenProvider requesting
+//     tokens from sasl.oauthbearer.token.endpoint.url with the clientId,
+//     clientSecret, scope and extension_* options. Close the provider set to
+//     Net.SASL.TokenProvider once the configuration is no longer used.
+//   - Krb5LoginModule uses the keyTab and principal options, or the ticket
+//     cache with useTicketCache=true, along with the Kerberos configuration of
+//     the KRB5_CONFIG environment variable or /etc/krb5.conf.
+//
+// TLS is configured from the PKCS#12, JKS or PEM keystores and truststores of
+// the ssl.* settings, see the keystore package.
+func ConfigFromProperties(properties map[string]string) (*Config, []string, error) {
+	conf, addrs, unsupported, err := configFromProperties(properties)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, key := range unsupported {
+		Logger.Printf("config/properties ignoring unsupported property %s\n", key)
+	}
+	return conf, addrs, nil
+}
+
+// configFromProperties implements ConfigFromProperties, returning the sorted
+// keys of the unsupported settings instead of logging them.
+func configFromProperties(properties map[string]string) (*Config, []string, []string, error) {
+	p := &javaProperties{values: properties, used: make(map[string]bool)}
+	conf := NewConfig()
+
+	var addrs []string
+	if servers, ok := p.get("bootstrap.servers"); ok {
+		for _, addr := range strings.Split(servers, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, nil, nil, ConfigurationError("bootstrap.servers must not be empty")
+	}
+
+	// settings of Java clients without an equivalent that do not change the
+	// behaviour of the client
+	for _, key := range []string{
+		"group.id", "key.serializer", "value.serializer", "key.deserializer", "value.deserializer",
+		"ssl.protocol", "sasl.login.callback.handler.class",
+	} {
+		p.get(key)
+	}
+
+	if err := p.translate(conf); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := p.security(conf); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var unsupported []string
+	for key := range properties {
+		if !p.used[key] {
+			unsupported = append(unsupported, key)
+		}
+	}
+	sort.Strings(unsupported)
+	return conf, addrs, unsupported, nil
+}
+
+// javaProperties reads the settings of a Java client, keeping track of the
+// settings that have been read.
+type javaProperties struct {
+	values map[string]string
+	used   map[string]bool
+	err    error
+}
+
+func (p *javaProperties) get(key string) (string, bool) {
+	value, ok := p.values[key]
+	if ok {
+		p.used[key] = true
+	}
+	return strings.TrimSpace(value), ok
+}
+
+func (p *javaProperties) string(key string, dst *string) {
+	if value, ok := p.get(key); ok {
+		*dst = value
+	}
+}
+
+func (p *javaProperties) invalid(key, value string) {
+	if p.err == nil {
+		p.err = ConfigurationError(fmt.Sprintf("invalid value %q for property %s", value, key))
+	}
+}
+
+func (p *javaProperties) int64(key string) (int64, bool) {
+	value, ok := p.get(key)
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		p.invalid(key, value)
+		return 0, false
+	}
+	return i, true
+}
+
+func (p *javaProperties) int(key string, dst *int) {
+	if i, ok := p.int64(key); ok {
+		*dst = int(i)
+	}
+}
+
+func (p *javaProperties) int32(key string, dst *int32) {
+	if i, ok := p.int64(key); ok {
+		*dst = int32(i)
+	}
+}
+
+func (p *javaProperties) millis(key string, dst ...*time.Duration) {
+	if i, ok := p.int64(key); ok {
+		for _, d := range dst {
+			*d = time.Duration(i) * time.Millisecond
+		}
+	}
+}
+
+func (p *javaProperties) bool(key string, dst *bool) {
+	value, ok := p.get(key)
+	if !ok {
+		return
+	}
+	switch strings.ToLower(value) {
+	case "true":
+		*dst = true
+	case "false":
+		*dst = false
+	default:
+		p.invalid(key, value)
+	}
+}
+
+func (p *javaProperties) float(key string, dst *float64) {
+	value, ok := p.get(key)
+	if !ok {
+		return
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		p.invalid(key, value)
+		return
+	}
+	*dst = f
+}
+
+// translate translates the settings of the network, producer and consumer.
+func (p *javaProperties) translate(conf *Config) error {
+	p.string("client.id", &conf.ClientID)
+	p.string("client.rack", &conf.RackID)
+	p.millis("socket.connection.setup.timeout.ms", &conf.Net.DialTimeout)
+	p.millis("request.timeout.ms", &conf.Net.ReadTimeout)
+	p.millis("reconnect.backoff.ms", &conf.Net.ReconnectBackoff)
+	p.millis("reconnect.backoff.max.ms", &conf.Net.ReconnectBackoffMax)
+	p.millis("connections.max.idle.ms", &conf.Net.ConnectionsMaxIdle)
+	if lookup, ok := p.get("client.dns.lookup"); ok {
+		switch strings.ToLower(lookup) {
+		case "use_all_dns_ips":
+			conf.Net.UseAllDNSIPs = true
+		case "resolve_canonical_bootstrap_servers_only":
+			// which also uses all the IPs of the brokers since Kafka 2.6
+			conf.Net.ResolveCanonicalBootstrapServers = true
+			conf.Net.UseAllDNSIPs = true
+		default:
+			p.invalid("client.dns.lookup", lookup)
+		}
+	}
+	p.millis("metadata.max.age.ms", &conf.Metadata.RefreshFrequency)
+	p.bool("allow.auto.create.topics", &conf.Metadata.AllowAutoTopicCreation)
+	p.millis("retry.backoff.ms", &conf.Metadata.Retry.Backoff, &conf.Producer.Retry.Backoff, &conf.Admin.Retry.Backoff)
+	p.millis("default.api.timeout.ms", &conf.Admin.Timeout)
+
+	// the idempotent producer requires a single in-flight request
+	inFlight := -1
+	p.int("max.in.flight.requests.per.connection", &inFlight)
+	if inFlight != -1 {
+		conf.Net.MaxOpenRequests = inFlight
+	}
+
+	if acks, ok := p.get("acks"); ok {
+		switch strings.ToLower(acks) {
+		case "all", "-1":
+			conf.Producer.RequiredAcks = WaitForAll
+		case "1":
+			conf.Producer.RequiredAcks = WaitForLocal
+		case "0":
+			conf.Producer.RequiredAcks = NoResponse
+		default:
+			p.invalid("acks", acks)
+		}
+	}
+	if compression, ok := p.get("compression.type"); ok {
+		if err := conf.Producer.Compression.UnmarshalText([]byte(strings.ToLower(compression))); err != nil {
+			p.invalid("compression.type", compression)
+		}
+	}
+	p.int("max.request.size", &conf.Producer.MaxMessageBytes)
+	p.int("retries", &conf.Producer.Retry.Max)
+	// batch.size only bounds the batches of Java producers, while
+	// Flush.Bytes holds messages back until it is reached, so it is only
+	// applied along with linger.ms
+	batchSize := 0
+	p.int("batch.size", &batchSize)
+	p.millis("linger.ms", &conf.Producer.Flush.Frequency)
+	if conf.Producer.Flush.Frequency > 0 {
+		conf.Producer.Flush.Bytes = batchSize
+	}
+
+	transactionalID, _ := p.get("transactional.id")
+	conf.Producer.Transaction.ID = transactionalID
+	p.millis("transaction.timeout.ms", &conf.Producer.Transaction.Timeout)
+	// transactional producers are idempotent unless disabled explicitly,
+	// like in Java
+	conf.Producer.Idempotent = transactionalID != ""
+	p.bool("enable.idempotence", &conf.Producer.Idempotent)
+	if conf.Producer.Idempotent {
+		if _, ok := p.values["acks"]; !ok {
+			conf.Producer.RequiredAcks = WaitForAll
+		}
+		// Java idempotent producers allow up to 5 requests in flight, which
+		// Sarama does not support
+		if inFlight > 1 {
+			Logger.Printf("config/properties max.in.flight.requests.per.connection %d is not supported by idempotent producers, using 1\n", inFlight)
+		}
+		conf.Net.MaxOpenRequests = 1
+	}
+
+	if reset, ok := p.get("auto.offset.reset"); ok {
+		switch strings.ToLower(reset) {
+		case "earliest":
+			conf.Consumer.Offsets.Initial = OffsetOldest
+		case "latest":
+			conf.Consumer.Offsets.Initial = OffsetNewest
+		case "none":
+			conf.Consumer.Group.ResetInvalidOffsets = false
+		default:
+			p.invalid("auto.offset.reset", reset)
+		}
+	}
+	p.bool("enable.auto.commit", &conf.Consumer.Offsets.AutoCommit.Enable)
+	p.millis("auto.commit.interval.ms", &conf.Consumer.Offsets.AutoCommit.Interval)
+	p.string("group.instance.id", &conf.Consumer.Group.InstanceId)
+	p.millis("session.timeout.ms", &conf.Consumer.Group.Session.Timeout)
+	p.millis("heartbeat.interval.ms", &conf.Consumer.Group.Heartbeat.Interval)
+	p.millis("max.poll.interval.ms", &conf.Consumer.Group.Rebalance.Timeout)
+	p.int32("fetch.min.bytes", &conf.Consumer.Fetch.Min)
+	p.int32("fetch.max.bytes", &conf.Consumer.Fetch.Max)
+	p.int32("max.partition.fetch.bytes", &conf.Consumer.Fetch.Default)
+	p.millis("fetch.max.wait.ms", &conf.Consumer.MaxWaitTime)
+	if level, ok := p.get("isolation.level"); ok {
+		switch strings.ToLower(level) {
+		case "read_uncommitted":
+			conf.Consumer.IsolationLevel = ReadUncommitted
+		case "read_committed":
+			conf.Consumer.IsolationLevel = ReadCommitted
+		default:
+			p.invalid("isolation.level", level)
+		}
+	}
+	if assignors, ok := p.get("partition.assignment.strategy"); ok {
+		conf.Consumer.Group.Rebalance.GroupStrategies = nil
+		for _, assignor := range strings.Split(assignors, ",") {
+			assignor = strings.TrimSpace(assignor)
+			switch assignor[strings.LastIndex(assignor, ".")+1:] {
+			case "RangeAssignor":
+				conf.Consumer.Group.Rebalance.GroupStrategies = append(conf.Consumer.Group.Rebalance.GroupStrategies, NewBalanceStrategyRange())
+			case "RoundRobinAssignor":
+				conf.Consumer.Group.Rebalance.GroupStrategies = append(conf.Consumer.Group.Rebalance.GroupStrategies, NewBalanceStrategyRoundRobin())
+			case "StickyAssignor":
+				conf.Consumer.Group.Rebalance.GroupStrategies = append(conf.Consumer.Group.Rebalance.GroupStrategies, NewBalanceStrategySticky())
+			case "":
+			default:
+				Logger.Printf("config/properties ignoring unsupported partition assignment strategy %s\n", assignor)
+			}
+		}
+		if len(conf.Consumer.Group.Rebalance.GroupStrategies) == 0 {
+			p.invalid("partition.assignment.strategy", assignors)
+		}
+	}
+
+	return p.err
+}
+
+// security translates the security.protocol, ssl.* and sasl.* settings.
+func (p *javaProperties) security(conf *Config) error {
+	protocol := "PLAINTEXT"
+	p.string("security.protocol", &protocol)
+	switch strings.ToUpper(protocol) {
+	case "PLAINTEXT":
+	case "SSL":
+		conf.Net.TLS.Enable = true
+	case "SASL_PLAINTEXT":
+		conf.Net.SASL.Enable = true
+	case "SASL_SSL":
+		conf.Net.TLS.Enable = true
+		conf.Net.SASL.Enable = true
+	default:
+		return ConfigurationError(fmt.Sprintf("invalid value %q for property security.protocol", protocol))
+	}
+
+	// all the settings are read to not report them as unsupported when they
+	// are not used by the security protocol
+	tlsConfig, err := p.tlsConfig(conf.Net.TLS.Enable)
+	if err != nil {
+		return err
+	}
+	if conf.Net.TLS.Enable {
+		conf.Net.TLS.Config = tlsConfig
+	}
+	return p.sasl(conf)
+}
+
+func (p *javaProperties) tlsConfig(enabled bool) (*tls.Config, error) {
+	var ks keystore.Config
+	p.string("ssl.keystore.location", &ks.KeystoreLocation)
+	p.string("ssl.keystore.type", &ks.KeystoreType)
+	p.string("ssl.keystore.password", &ks.KeystorePassword)
+	p.string("ssl.key.password", &ks.KeyPassword)
+	p.string("ssl.truststore.location", &ks.TruststoreLocation)
+	p.string("ssl.truststore.type", &ks.TruststoreType)
+	p.string("ssl.truststore.password", &ks.TruststorePassword)
+	keyPEM, _ := p.get("ssl.keystore.key")
+	chainPEM, _ := p.get("ssl.keystore.certificate.chain")
+	trustedPEM, _ := p.get("ssl.truststore.certificates")
+	identification, verifyHostname := p.get("ssl.endpoint.identification.algorithm")
+	protocols, _ := p.get("ssl.enabled.protocols")
+	if !enabled {
+		return nil, nil
+	}
+
+	// PEM keystores are decoded here, and the JKS and PKCS12 types are
+	// detected from the content as Java also reads PKCS#12 keystores of type
+	// JKS
+	pemKeystore := keyPEM != "" || strings.EqualFold(ks.KeystoreType, "PEM") && ks.KeystoreLocation != ""
+	pemTruststore := trustedPEM != "" || strings.EqualFold(ks.TruststoreType, "PEM") && ks.TruststoreLocation != ""
+	keystoreConfig := ks
+	for _, t := range []*string{&keystoreConfig.KeystoreType, &keystoreConfig.TruststoreType} {
+		if strings.EqualFold(*t, keystore.TypeJKS) || strings.EqualFold(*t, keystore.TypePKCS12) {
+			*t = ""
+		}
+	}
+	if pemKeystore {
+		keystoreConfig.KeystoreLocation = ""
+	}
+	if pemTruststore {
+		keystoreConfig.TruststoreLocation = ""
+	}
+	tlsConfig, err := keystoreConfig.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if pemKeystore {
+		if keyPEM == "" {
+			data, err := os.ReadFile(ks.KeystoreLocation)
+			if err != nil {
+				return nil, err
+			}
+			keyPEM, chainPEM = string(data), string(data)
+		}
+		cert, err := tls.X509KeyPair([]byte(chainPEM), []byte(keyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the PEM keystore: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if pemTruststore {
+		if trustedPEM == "" {
+			data, err := os.ReadFile(ks.TruststoreLocation)
+			if err != nil {
+				return nil, err
+			}
+			trustedPEM = string(data)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM([]byte(trustedPEM)) {
+			return nil, ConfigurationError("the PEM truststore contains no certificates")
+		}
+	}
+
+	if verifyHostname && identification == "" {
+		// like in Java, the certificate chain is still verified
+		Logger.Println("config/properties ssl.endpoint.identification.algorithm is empty, the host names of the brokers are not verified")
+		roots := tlsConfig.RootCAs
+		tlsConfig.InsecureSkipVerify = true //nolint:gosec
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("tls: broker did not provide a certificate")
+			}
+			opts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+			for _, cert := range state.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := state.PeerCertificates[0].Verify(opts)
+			return err
+		}
+	}
+
+	if protocols != "" {
+		tlsConfig.MinVersion, tlsConfig.MaxVersion = 0, 0
+		for _, protocol := range strings.Split(protocols, ",") {
+			var version uint16
+			switch strings.TrimSpace(protocol) {
+			case "TLSv1":
+				version = tls.VersionTLS10
+			case "TLSv1.1":
+				version = tls.VersionTLS11
+			case "TLSv1.2":
+				version = tls.VersionTLS12
+			case "TLSv1.3":
+				version = tls.VersionTLS13
+			default:
+				return nil, ConfigurationError(fmt.Sprintf("invalid value %q for property ssl.enabled.protocols", protocols))
+			}
+			if tlsConfig.MinVersion == 0 || version < tlsConfig.MinVersion {
+				tlsConfig.MinVersion = version
+			}
+			if version > tlsConfig.MaxVersion {
+				tlsConfig.MaxVersion = version
+			}
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+func (p *javaProperties) sasl(conf *Config) error {
+	mechanism := SASLTypeGSSAPI
+	p.string("sasl.mechanism", &mechanism)
+	jaasConfig, hasJAASConfig := p.get("sasl.jaas.config")
+	p.string("sasl.kerberos.service.name", &conf.Net.SASL.GSSAPI.ServiceName)
+
+	oauth := ClientCredentialsConfig{}
+	p.string("sasl.oauthbearer.token.endpoint.url", &oauth.TokenURL)
+	p.float("sasl.login.refresh.window.factor", &oauth.RefreshWindowFactor)
+	p.millis("sasl.login.retry.backoff.ms", &oauth.RetryBackoff)
+	p.millis("sasl.login.retry.backoff.max.ms", &oauth.RetryBackoffMax)
+	p.millis("sasl.login.read.timeout.ms", &oauth.Timeout)
+	if p.err != nil || !conf.Net.SASL.Enable {
+		return p.err
+	}
+
+	if !hasJAASConfig {
+		return ConfigurationError("sasl.jaas.config must be set when SASL is enabled")
+	}
+	jaas, err := parseJAASConfig(jaasConfig)
+	if err != nil {
+		return err
+	}
+	module := jaas.loginModule[strings.LastIndex(jaas.loginModule, ".")+1:]
+	conf.Net.SASL.Mechanism = SASLMechanism(strings.ToUpper(mechanism))
+
+	switch conf.Net.SASL.Mechanism {
+	case SASLTypePlaintext, SASLTypeSCRAMSHA256, SASLTypeSCRAMSHA512:
+		if module != "PlainLoginModule" && module != "ScramLoginModule" ||
+			(module == "PlainLoginModule") != (conf.Net.SASL.Mechanism == SASLTypePlaintext) {
+			break
+		}
+		if jaas.options["tokenauth"] == "true" {
+			return ConfigurationError("sasl.jaas.config: delegation tokens are not supported")
+		}
+		conf.Net.SASL.User = jaas.options["username"]
+		conf.Net.SASL.Password = jaas.options["password"]
+		switch conf.Net.SASL.Mechanism {
+		case SASLTypeSCRAMSHA256:
+			conf.Net.SASL.SCRAMClientGeneratorFunc = newSCRAMClientGeneratorFunc(SCRAM_MECHANISM_SHA_256)
+		case SASLTypeSCRAMSHA512:
+			conf.Net.SASL.SCRAMClientGeneratorFunc = newSCRAMClientGeneratorFunc(SCRAM_MECHANISM_SHA_512)
+		}
+		return nil
+	case SASLTypeOAuth:
+		if module != "OAuthBearerLoginModule" {
+			break
+		}
+		if oauth.TokenURL == "" {
+			return ConfigurationError("sasl.oauthbearer.token.endpoint.url must be set, unsecured OAUTHBEARER tokens are not supported")
+		}
+		oauth.ClientID = jaas.options["clientId"]
+		oauth.ClientSecret = jaas.options["clientSecret"]
+		oauth.Scopes = strings.Fields(jaas.options["scope"])
+		for key, value := range jaas.options {
+			if name := strings.TrimPrefix(key, "extension_"); name != key {
+				if oauth.Extensions == nil {
+					oauth.Extensions = make(map[string]string)
+				}
+				oauth.Extensions[name] = value
+			}
+		}
+		provider, err := NewClientCredentialsTokenProvider(oauth)
+		if err != nil {
+			return err
+		}
+		conf.Net.SASL.TokenProvider = provider
+		return nil
+	case SASLTypeGSSAPI:
+		if module != "Krb5LoginModule" {
+			break
+		}
+		return jaas.gssapi(&conf.Net.SASL.GSSAPI)
+	default:
+		return ConfigurationError(fmt.Sprintf("invalid value %q for property sasl.mechanism", mechanism))
+	}
+	return ConfigurationError(fmt.Sprintf("sasl.jaas.config: login module %s does not support the SASL mechanism %s", jaas.loginModule, mechanism))
+}
+
+func (c *jaasConfig) gssapi(conf *GSSAPIConfig) error {
+	if principal := c.options["principal"]; principal != "" {
+		conf.Username = principal
+		if i := strings.LastIndex(principal, "@"); i >= 0 {
+			conf.Username, conf.Realm = principal[:i], principal[i+1:]
+		}
+	}
+
+	switch {
+	case c.options["useKeyTab"] == "true":
+		conf.AuthType = KRB5_KEYTAB_AUTH
+		conf.KeyTabPath = c.options["keyTab"]
+	case c.options["useTicketCache"] == "true":
+		conf.AuthType = KRB5_CCACHE_AUTH
+		conf.CCachePath = c.options["ticketCache"]
+		if conf.CCachePath == "" {
+			conf.CCachePath = strings.TrimPrefix(os.Getenv("KRB5CCNAME"), "FILE:")
+		}
+		if conf.CCachePath == "" {
+			conf.CCachePath = fmt.Sprintf("/tmp/krb5cc_%d", os.Getuid())
+		}
+	default:
+		return ConfigurationError("sasl.jaas.config: Krb5LoginModule requires useKeyTab=true or useTicketCache=true")
+	}
+
+	conf.KerberosConfigPath = os.Getenv("KRB5_CONFIG")
+	if conf.KerberosConfigPath == "" {
+		conf.KerberosConfigPath = "/etc/krb5.conf"
+	}
+	return nil
+}
+
+// jaasConfig is the login module of a sasl.jaas.config setting, in the JAAS
+// configuration format:
+//
+//	<LoginModuleClass> <ControlFlag> *(<OptionName>=<OptionValue>);
+type jaasConfig struct {
+	loginModule string
+	controlFlag string
+	options     map[string]string
+}
+
+func parseJAASConfig(s string) (*jaasConfig, error) {
+	invalid := func(reason string) error {
+		return ConfigurationError("sasl.jaas.config: " + reason)
+	}
+
+	var tokens []jaasToken
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f':
+			i++
+		case c == '=' || c == ';':
+			tokens = append(tokens, jaasToken{text: string(c), symbol: true})
+			i++
+		case c == '"':
+			var b strings.Builder
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			if i == len(s) {
+				return nil, invalid("unterminated quoted value")
+			}
+			tokens = append(tokens, jaasToken{text: b.String()})
+			i++
+		default:
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t\r\n\f=;\"", rune(s[i])) {
+				i++
+			}
+			tokens = append(tokens, jaasToken{text: s[start:i]})
+		}
+	}
+
+	if len(tokens) < 3 || tokens[0].symbol || tokens[1].symbol {
+		return nil, invalid("expected a login module and a control flag")
+	}
+	c := &jaasConfig{
+		loginModule: tokens[0].text,
+		controlFlag: strings.ToLower(tokens[1].text),
+		options:     make(map[string]string),
+	}
+	switch c.controlFlag {
+	case "required", "requisite", "sufficient", "optional":
+	default:
+		return nil, invalid(fmt.Sprintf("invalid control flag %s", tokens[1].text))
+	}
+
+	rest := tokens[2:]
+	for len(rest) >= 3 && !rest[0].symbol && rest[1].symbol && rest[1].text == "=" && !rest[2].symbol {
+		c.options[rest[0].text] = rest[2].text
+		rest = rest[3:]
+	}
+	switch {
+	case len(rest) == 0 || !rest[0].symbol || rest[0].text != ";":
+		return nil, invalid("expected options of the form name=value terminated by ;")
+	case len(rest) > 1:
+		return nil, invalid("exactly one login module must be configured")
+	}
+	return c, nil
+}
+
+type jaasToken struct {
+	text   string
+	symbol bool
+}
+
+// parseProperties parses the Java .properties format: key=value, key:value or
+// key value pairs, # and ! comments, lines continued with a backslash and
+// backslash escapes.
+func parseProperties(r io.Reader) (map[string]string, error) {
+	properties := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var line strings.Builder
+	continued := false
+	flush := func() error {
+		key, value, err := parsePropertiesLine(line.String())
+		if err != nil {
+			return err
+		}
+		properties[key] = value
+		line.Reset()
+		return nil
+	}
+
+	for scanner.Scan() {
+		text := strings.TrimLeft(scanner.Text(), " \t\f")
+		if !continued && (text == "" || text[0] == '#' || text[0] == '!') {
+			continue
+		}
+
+		backslashes := len(text) - len(strings.TrimRight(text, "\\"))
+		continued = backslashes%2 == 1
+		if continued {
+			line.WriteString(text[:len(text)-1])
+			continue
+		}
+		line.WriteString(text)
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if continued {
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+	return properties, nil
+}
+
+func parsePropertiesLine(line string) (string, string, error) {
+	end := 0
+	for end < len(line) && !strings.ContainsRune("=: \t\f", rune(line[end])) {
+		if line[end] == '\\' {
+			end++
+		}
+		end++
+	}
+	if end > len(line) {
+		end = len(line)
+	}
+	key := line[:end]
+
+	value := strings.TrimLeft(line[end:], " \t\f")
+	if value != "" && (value[0] == '=' || value[0] == ':') {
+		value = strings.TrimLeft(value[1:], " \t\f")
+	}
+
+	key, err := unescapeProperty(key)
+	if err != nil {
+		return "", "", err
+	}
+	value, err = unescapeProperty(value)
+	if err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			break
+		}
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("malformed \\uxxxx encoding in %q", s)
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\uxxxx encoding in %q", s)
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
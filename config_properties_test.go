@@ -0,0 +1,400 @@
+package sarama
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseProperties(t *testing.T) {
+	properties, err := parseProperties(strings.NewReader(`
+# comment
+! another comment
+bootstrap.servers=kafka-1:9092,\
+    kafka-2:9092
+client.id : my-client
+  acks all
+empty=
+key\ with\ spaces=value\twith\ttabs
+unicode=café
+escaped=a\\b\=c
+sasl.jaas.config=org.apache.kafka.common.security.plain.PlainLoginModule required \
+    username="alice" \
+    password="alice-secret";
+trailing=continued\
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"bootstrap.servers": "kafka-1:9092,kafka-2:9092",
+		"client.id":         "my-client",
+		"acks":              "all",
+		"empty":             "",
+		"key with spaces":   "value\twith\ttabs",
+		"unicode":           "café",
+		"escaped":           `a\b=c`,
+		"sasl.jaas.config":  `org.apache.kafka.common.security.plain.PlainLoginModule required username="alice" password="alice-secret";`,
+		"trailing":          "continued",
+	}
+	if !reflect.DeepEqual(properties, expected) {
+		t.Errorf("Expected %v, got %v", expected, properties)
+	}
+
+	if _, err := parseProperties(strings.NewReader(`invalid=\u12`)); err == nil {
+		t.Error("Expected an error for a malformed unicode escape")
+	}
+}
+
+func TestParseJAASConfig(t *testing.T) {
+	jaas, err := parseJAASConfig(`org.apache.kafka.common.security.scram.ScramLoginModule Required
+		username=alice password="p\"a;ss=word" extension_traceId = "abc" ;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jaas.loginModule != "org.apache.kafka.common.security.scram.ScramLoginModule" || jaas.controlFlag != "required" {
+		t.Errorf("Unexpected login module %s %s", jaas.loginModule, jaas.controlFlag)
+	}
+	expected := map[string]string{"username": "alice", "password": `p"a;ss=word`, "extension_traceId": "abc"}
+	if !reflect.DeepEqual(jaas.options, expected) {
+		t.Errorf("Expected options %v, got %v", expected, jaas.options)
+	}
+
+	for _, invalid := range []string{
+		``,
+		`PlainLoginModule required username="alice"`,
+		`PlainLoginModule mandatory username="alice";`,
+		`PlainLoginModule required username="alice;`,
+		`PlainLoginModule required username;`,
+		`PlainLoginModule required; ScramLoginModule required;`,
+	} {
+		if _, err := parseJAASConfig(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestConfigFromProperties(t *testing.T) {
+	conf, addrs, unsupported, err := configFromProperties(map[string]string{
+		"bootstrap.servers":             " kafka-1:9092, kafka-2:9092 ",
+		"client.id":                     "my-client",
+		"client.rack":                   "rack-1",
+		"retry.backoff.ms":              "500",
+		"request.timeout.ms":            "20000",
+		"reconnect.backoff.max.ms":      "10000",
+		"connections.max.idle.ms":       "540000",
+		"client.dns.lookup":             "use_all_dns_ips",
+		"compression.type":              "zstd",
+		"linger.ms":                     "5",
+		"batch.size":                    "32768",
+		"transactional.id":              "my-txn",
+		"group.id":                      "my-group",
+		"group.instance.id":             "instance-1",
+		"auto.offset.reset":             "earliest",
+		"enable.auto.commit":            "false",
+		"session.timeout.ms":            "45000",
+		"max.poll.interval.ms":          "300000",
+		"isolation.level":               "read_committed",
+		"partition.assignment.strategy": "org.apache.kafka.clients.consumer.RoundRobinAssignor",
+		"key.serializer":                "org.apache.kafka.common.serialization.StringSerializer",
+		"buffer.memory":                 "33554432",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(addrs, []string{"kafka-1:9092", "kafka-2:9092"}) {
+		t.Errorf("Unexpected addresses %v", addrs)
+	}
+	switch {
+	case conf.ClientID != "my-client" || conf.RackID != "rack-1":
+		t.Errorf("Unexpected client %s %s", conf.ClientID, conf.RackID)
+	case conf.Metadata.Retry.Backoff != 500*time.Millisecond || conf.Producer.Retry.Backoff != 500*time.Millisecond:
+		t.Errorf("Unexpected retry backoff %v %v", conf.Metadata.Retry.Backoff, conf.Producer.Retry.Backoff)
+	case conf.Net.ReadTimeout != 20*time.Second:
+		t.Errorf("Unexpected read timeout %v", conf.Net.ReadTimeout)
+	case conf.Net.ReconnectBackoffMax != 10*time.Second || conf.Net.ConnectionsMaxIdle != 9*time.Minute || !conf.Net.UseAllDNSIPs:
+		t.Errorf("Unexpected reconnect backoff %v, max idle %v and DNS lookup %v", conf.Net.ReconnectBackoffMax, conf.Net.ConnectionsMaxIdle, conf.Net.UseAllDNSIPs)
+	case conf.Producer.Compression != CompressionZSTD:
+		t.Errorf("Unexpected compression %v", conf.Producer.Compression)
+	case conf.Producer.Flush.Frequency != 5*time.Millisecond || conf.Producer.Flush.Bytes != 32768:
+		t.Errorf("Unexpected flush %v %d", conf.Producer.Flush.Frequency, conf.Producer.Flush.Bytes)
+	case conf.Producer.Transaction.ID != "my-txn" || !conf.Producer.Idempotent:
+		t.Errorf("Expected an idempotent transactional producer, got %q %v", conf.Producer.Transaction.ID, conf.Producer.Idempotent)
+	case conf.Producer.RequiredAcks != WaitForAll || conf.Net.MaxOpenRequests != 1:
+		t.Errorf("Unexpected acks %v and max open requests %d", conf.Producer.RequiredAcks, conf.Net.MaxOpenRequests)
+	case conf.Consumer.Group.InstanceId != "instance-1" || conf.Consumer.Offsets.Initial != OffsetOldest:
+		t.Errorf("Unexpected consumer %q %d", conf.Consumer.Group.InstanceId, conf.Consumer.Offsets.Initial)
+	case conf.Consumer.Offsets.AutoCommit.Enable || conf.Consumer.Group.Session.Timeout != 45*time.Second:
+		t.Errorf("Unexpected auto commit %v and session timeout %v", conf.Consumer.Offsets.AutoCommit.Enable, conf.Consumer.Group.Session.Timeout)
+	case conf.Consumer.Group.Rebalance.Timeout != 5*time.Minute || conf.Consumer.IsolationLevel != ReadCommitted:
+		t.Errorf("Unexpected rebalance timeout %v and isolation level %v", conf.Consumer.Group.Rebalance.Timeout, conf.Consumer.IsolationLevel)
+	case len(conf.Consumer.Group.Rebalance.GroupStrategies) != 1 || conf.Consumer.Group.Rebalance.GroupStrategies[0].Name() != RoundRobinBalanceStrategyName:
+		t.Errorf("Unexpected group strategies %v", conf.Consumer.Group.Rebalance.GroupStrategies)
+	case conf.Net.TLS.Enable || conf.Net.SASL.Enable:
+		t.Errorf("Expected neither TLS nor SASL, got %v %v", conf.Net.TLS.Enable, conf.Net.SASL.Enable)
+	}
+
+	if !reflect.DeepEqual(unsupported, []string{"buffer.memory"}) {
+		t.Errorf("Expected buffer.memory only to be unsupported, got %v", unsupported)
+	}
+
+	conf.Version = V2_3_0_0
+	if err := conf.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConfigFromPropertiesIdempotentInFlight(t *testing.T) {
+	// the defaults of Java producers
+	conf, _, err := ConfigFromProperties(map[string]string{
+		"bootstrap.servers":                     "kafka:9092",
+		"enable.idempotence":                    "true",
+		"max.in.flight.requests.per.connection": "5",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Net.MaxOpenRequests != 1 {
+		t.Errorf("Expected 1 max open requests, got %d", conf.Net.MaxOpenRequests)
+	}
+
+	conf.Version = V2_3_0_0
+	if err := conf.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConfigFromPropertiesErrors(t *testing.T) {
+	for _, properties := range []map[string]string{
+		{},
+		{"bootstrap.servers": "kafka:9092", "acks": "2"},
+		{"bootstrap.servers": "kafka:9092", "linger.ms": "soon"},
+		{"bootstrap.servers": "kafka:9092", "enable.auto.commit": "yes"},
+		{"bootstrap.servers": "kafka:9092", "security.protocol": "TLS"},
+		{"bootstrap.servers": "kafka:9092", "security.protocol": "SASL_PLAINTEXT"},
+		{
+			"bootstrap.servers": "kafka:9092",
+			"security.protocol": "SASL_PLAINTEXT",
+			"sasl.jaas.config":  `org.apache.kafka.common.security.plain.PlainLoginModule required username="alice" password="secret";`,
+		},
+		{
+			"bootstrap.servers": "kafka:9092",
+			"security.protocol": "SASL_PLAINTEXT",
+			"sasl.mechanism":    "OAUTHBEARER",
+			"sasl.jaas.config":  `org.apache.kafka.common.security.oauthbearer.OAuthBearerLoginModule required;`,
+		},
+	} {
+		if _, _, err := ConfigFromProperties(properties); err == nil {
+			t.Errorf("Expected an error for %v", properties)
+		} else if !errors.As(err, new(ConfigurationError)) {
+			t.Errorf("Expected a ConfigurationError for %v, got %v", properties, err)
+		}
+	}
+}
+
+func TestConfigFromPropertiesSASL(t *testing.T) {
+	t.Run("PLAIN", func(t *testing.T) {
+		conf, _, err := ConfigFromProperties(map[string]string{
+			"bootstrap.servers": "kafka:9092",
+			"security.protocol": "SASL_PLAINTEXT",
+			"sasl.mechanism":    "PLAIN",
+			"sasl.jaas.config":  `org.apache.kafka.common.security.plain.PlainLoginModule required username="alice" password="alice-secret";`,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !conf.Net.SASL.Enable || conf.Net.TLS.Enable || conf.Net.SASL.Mechanism != SASLTypePlaintext {
+			t.Errorf("Unexpected SASL %v %v %s", conf.Net.SASL.Enable, conf.Net.TLS.Enable, conf.Net.SASL.Mechanism)
+		}
+		if conf.Net.SASL.User != "alice" || conf.Net.SASL.Password != "alice-secret" {
+			t.Errorf("Unexpected credentials %s %s", conf.Net.SASL.User, conf.Net.SASL.Password)
+		}
+	})
+
+	t.Run("SCRAM", func(t *testing.T) {
+		conf, _, err := ConfigFromProperties(map[string]string{
+			"bootstrap.servers": "kafka:9092",
+			"security.protocol": "SASL_PLAINTEXT",
+			"sasl.mechanism":    "SCRAM-SHA-512",
+			"sasl.jaas.config":  `org.apache.kafka.common.security.scram.ScramLoginModule required username="alice" password="alice-secret";`,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conf.Net.SASL.Mechanism != SASLTypeSCRAMSHA512 || conf.Net.SASL.User != "alice" || conf.Net.SASL.Password != "alice-secret" {
+			t.Errorf("Unexpected SASL %s %s %s", conf.Net.SASL.Mechanism, conf.Net.SASL.User, conf.Net.SASL.Password)
+		}
+		client, ok := conf.Net.SASL.SCRAMClientGeneratorFunc().(*scramClient)
+		if !ok || client.formatter.mechanism != SCRAM_MECHANISM_SHA_512 {
+			t.Errorf("Expected a SCRAM-SHA-512 client, got %#v", client)
+		}
+	})
+
+	t.Run("OAUTHBEARER", func(t *testing.T) {
+		conf, _, err := ConfigFromProperties(map[string]string{
+			"bootstrap.servers":                   "kafka:9092",
+			"security.protocol":                   "SASL_PLAINTEXT",
+			"sasl.mechanism":                      "OAUTHBEARER",
+			"sasl.oauthbearer.token.endpoint.url": "https://auth.example.com/token",
+			"sasl.login.callback.handler.class":   "org.apache.kafka.common.security.oauthbearer.OAuthBearerLoginCallbackHandler",
+			"sasl.login.refresh.window.factor":    "0.5",
+			"sasl.jaas.config": `org.apache.kafka.common.security.oauthbearer.OAuthBearerLoginModule required
+				clientId="my-client" clientSecret="my-secret" scope="kafka offline" extension_logicalCluster="lkc-1";`,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		provider, ok := conf.Net.SASL.TokenProvider.(*ClientCredentialsTokenProvider)
+		if !ok {
+			t.Fatalf("Expected a ClientCredentialsTokenProvider, got %T", conf.Net.SASL.TokenProvider)
+		}
+		defer safeClose(t, provider)
+
+		expected := ClientCredentialsConfig{
+			TokenURL:            "https://auth.example.com/token",
+			ClientID:            "my-client",
+			ClientSecret:        "my-secret",
+			Scopes:              []string{"kafka", "offline"},
+			Extensions:          map[string]string{"logicalCluster": "lkc-1"},
+			RefreshWindowFactor: 0.5,
+		}
+		actual := provider.conf
+		actual.RetryBackoff, actual.RetryBackoffMax, actual.Timeout, actual.HTTPClient = 0, 0, 0, nil
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Expected %+v, got %+v", expected, actual)
+		}
+	})
+
+	t.Run("GSSAPI", func(t *testing.T) {
+		t.Setenv("KRB5_CONFIG", "/etc/kafka/krb5.conf")
+		conf, _, err := ConfigFromProperties(map[string]string{
+			"bootstrap.servers":          "kafka:9092",
+			"security.protocol":          "SASL_SSL",
+			"sasl.kerberos.service.name": "kafka",
+			"sasl.jaas.config": `com.sun.security.auth.module.Krb5LoginModule required
+				useKeyTab=true storeKey=true keyTab="/etc/security/keytabs/client.keytab" principal="client@EXAMPLE.COM";`,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := GSSAPIConfig{
+			AuthType:           KRB5_KEYTAB_AUTH,
+			KeyTabPath:         "/etc/security/keytabs/client.keytab",
+			KerberosConfigPath: "/etc/kafka/krb5.conf",
+			ServiceName:        "kafka",
+			Username:           "client",
+			Realm:              "EXAMPLE.COM",
+		}
+		if conf.Net.SASL.Mechanism != SASLTypeGSSAPI || !reflect.DeepEqual(conf.Net.SASL.GSSAPI, expected) {
+			t.Errorf("Expected %+v, got %s %+v", expected, conf.Net.SASL.Mechanism, conf.Net.SASL.GSSAPI)
+		}
+		if !conf.Net.TLS.Enable || conf.Net.TLS.Config == nil {
+			t.Error("Expected TLS to be enabled")
+		}
+	})
+}
+
+func TestConfigFromPropertiesTLS(t *testing.T) {
+	t.Run("PKCS12", func(t *testing.T) {
+		conf, _, err := ConfigFromProperties(map[string]string{
+			"bootstrap.servers":       "kafka:9093",
+			"security.protocol":       "SSL",
+			"ssl.keystore.location":   filepath.Join("keystore", "testdata", "client-aes.p12"),
+			"ssl.keystore.type":       "JKS",
+			"ssl.keystore.password":   "changeit",
+			"ssl.truststore.location": filepath.Join("keystore", "testdata", "truststore.p12"),
+			"ssl.truststore.password": "changeit",
+			"ssl.enabled.protocols":   "TLSv1.2,TLSv1.3",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tlsConfig := conf.Net.TLS.Config
+		if !conf.Net.TLS.Enable || tlsConfig == nil || len(tlsConfig.Certificates) != 1 || tlsConfig.RootCAs == nil {
+			t.Fatalf("Expected TLS with a client certificate and a truststore, got %v", tlsConfig)
+		}
+		if _, err := tlsConfig.Certificates[0].Leaf.Verify(x509.VerifyOptions{
+			Roots:     tlsConfig.RootCAs,
+			KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}); err != nil {
+			t.Errorf("Expected the client certificate to be trusted by the truststore: %v", err)
+		}
+		if tlsConfig.InsecureSkipVerify {
+			t.Error("Expected the host names to be verified")
+		}
+	})
+
+	t.Run("PEM", func(t *testing.T) {
+		ca := newTestCA(t, "ca")
+		clientCert, clientKey := ca.issue(t, "client", x509.ExtKeyUsageClientAuth)
+		truststore := filepath.Join(t.TempDir(), "ca.pem")
+		writeTestFile(t, truststore, ca.pem)
+		listener, clientNames := newTestTLSListener(t, ca)
+		defer listener.Close()
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				_ = conn.(*tls.Conn).Handshake()
+				_ = conn.Close()
+			}
+		}()
+
+		properties := map[string]string{
+			"bootstrap.servers":              listener.Addr().String(),
+			"security.protocol":              "SSL",
+			"ssl.keystore.type":              "PEM",
+			"ssl.keystore.key":               string(clientKey),
+			"ssl.keystore.certificate.chain": string(clientCert),
+			"ssl.truststore.type":            "PEM",
+			"ssl.truststore.location":        truststore,
+		}
+		conf, _, err := ConfigFromProperties(properties)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the certificate of the listener is issued for 127.0.0.1
+		tlsConfig := conf.Net.TLS.Config.Clone()
+		tlsConfig.ServerName = "kafka.example.com"
+		if err := dialTestTLS(t, listener.Addr().String(), tlsConfig); err == nil {
+			t.Error("Expected the host name to be verified")
+		}
+
+		properties["ssl.endpoint.identification.algorithm"] = ""
+		conf, _, err = ConfigFromProperties(properties)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tlsConfig = conf.Net.TLS.Config.Clone()
+		tlsConfig.ServerName = "kafka.example.com"
+		if err := dialTestTLS(t, listener.Addr().String(), tlsConfig); err != nil {
+			t.Errorf("Expected the host name not to be verified: %v", err)
+		}
+		if name := <-clientNames; name != "client" {
+			t.Errorf("Expected the client certificate, got %s", name)
+		}
+		if err := tlsConfig.VerifyConnection(tls.ConnectionState{}); err == nil {
+			t.Error("Expected an error without a broker certificate")
+		}
+
+		// the certificate chain is still verified
+		properties["ssl.truststore.location"] = filepath.Join(t.TempDir(), "other.pem")
+		writeTestFile(t, properties["ssl.truststore.location"], newTestCA(t, "other").pem)
+		conf, _, err = ConfigFromProperties(properties)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := dialTestTLS(t, listener.Addr().String(), conf.Net.TLS.Config); err == nil {
+			t.Error("Expected the certificate chain to be verified")
+		}
+	})
+}
@@ -48,6 +48,40 @@ func (ce ConsumerErrors) Error() string {
 	return fmt.Sprintf("kafka: %d errors while consuming", len(ce))
 }
 
+// PartitionEvent is a notification about the state of a consumed partition,
+// returned on the Events channel of a PartitionConsumer. It is either a
+// *PartitionEOF or a *PartitionIdle.
+type PartitionEvent interface {
+	partitionEvent()
+}
+
+// PartitionEOF is sent when a PartitionConsumer has caught up with the end of
+// its partition, i.e. the high water mark (or the last stable offset when
+// reading committed messages). It is sent again every time the consumer catches
+// up after new records have been returned.
+type PartitionEOF struct {
+	Topic     string
+	Partition int32
+	// Offset is the offset of the next record to be consumed.
+	Offset int64
+}
+
+func (*PartitionEOF) partitionEvent() {}
+
+// PartitionIdle is sent when no records were returned for a partition for
+// Consumer.IdleTimeout. It is sent again after records have been returned and
+// the partition became idle once more.
+type PartitionIdle struct {
+	Topic     string
+	Partition int32
+	// Offset is the offset of the next record to be consumed.
+	Offset int64
+	// Since is when the last record was returned, or when consuming started.
+	Since time.Time
+}
+
+func (*PartitionIdle) partitionEvent() {}
+
 // Consumer manages PartitionConsumers which process Kafka messages from brokers. You MUST call Close()
 // on a consumer to avoid leaks, it will not be garbage-collected automatically when it passes out of
 // scope.
@@ -127,6 +161,12 @@ func NewConsumerFromClient(client Client) (Consumer, error) {
 }
 
 func newConsumer(client Client) (Consumer, error) {
+	return newConsumerWithConfig(client, client.Config())
+}
+
+// newConsumerWithConfig creates a consumer using conf instead of the Config of
+// the client, e.g. to consume with another isolation level.
+func newConsumerWithConfig(client Client, conf *Config) (Consumer, error) {
 	// Check that we are not dealing with a closed Client before processing any other arguments
 	if client.Closed() {
 		return nil, ErrClosedClient
@@ -134,10 +174,10 @@ func newConsumer(client Client) (Consumer, error) {
 
 	c := &consumer{
 		client:          client,
-		conf:            client.Config(),
+		conf:            conf,
 		children:        make(map[string]map[int32]*partitionConsumer),
 		brokerConsumers: make(map[*Broker]*brokerConsumer),
-		metricRegistry:  newCleanupRegistry(client.Config().MetricRegistry),
+		metricRegistry:  newCleanupRegistry(conf.MetricRegistry),
 	}
 
 	return c, nil
@@ -164,12 +204,14 @@ func (c *consumer) ConsumePartition(topic string, partition int32, offset int64)
 		partition:            partition,
 		messages:             make(chan *ConsumerMessage, c.conf.ChannelBufferSize),
 		errors:               make(chan *ConsumerError, c.conf.ChannelBufferSize),
+		events:               make(chan PartitionEvent, c.conf.ChannelBufferSize),
 		feeder:               make(chan *FetchResponse, 1),
 		leaderEpoch:          invalidLeaderEpoch,
 		preferredReadReplica: invalidPreferredReplicaID,
 		trigger:              make(chan none, 1),
 		dying:                make(chan none),
 		fetchSize:            c.conf.Consumer.Fetch.Default,
+		lastRecordTime:       time.Now(),
 	}
 
 	if err := child.chooseStartingOffset(offset); err != nil {
@@ -371,6 +413,12 @@ type PartitionConsumer interface {
 	// Consumer.Return.Errors setting to true, and read from this channel.
 	Errors() <-chan *ConsumerError
 
+	// Events returns a read channel of PartitionEOF and PartitionIdle events, if
+	// enabled through the config's Consumer.Return.PartitionEOF and
+	// Consumer.IdleTimeout settings. When enabled, you must read from this channel
+	// to prevent deadlock.
+	Events() <-chan PartitionEvent
+
 	// HighWaterMarkOffset returns the high water mark offset of the partition,
 	// i.e. the offset that will be used for the next message that will be produced.
 	// You can use this to determine how far behind the processing is.
@@ -399,6 +447,7 @@ type partitionConsumer struct {
 	broker   *brokerConsumer
 	messages chan *ConsumerMessage
 	errors   chan *ConsumerError
+	events   chan PartitionEvent
 	feeder   chan *FetchResponse
 
 	leaderEpoch          int32
@@ -413,6 +462,11 @@ type partitionConsumer struct {
 	offset         int64
 	retries        int32
 
+	// only accessed by responseFeeder
+	reachedEOF     bool
+	idle           bool
+	lastRecordTime time.Time
+
 	paused int32
 }
 
@@ -537,6 +591,10 @@ func (child *partitionConsumer) Errors() <-chan *ConsumerError {
 	return child.errors
 }
 
+func (child *partitionConsumer) Events() <-chan PartitionEvent {
+	return child.events
+}
+
 func (child *partitionConsumer) AsyncClose() {
 	// this triggers whatever broker owns this child to abandon it and close its trigger channel, which causes
 	// the dispatcher to exit its loop, which removes it from the consumer then closes its 'messages' and
@@ -611,12 +669,64 @@ feederLoop:
 			}
 		}
 
+		// the broker consumer resets the result once acknowledged
+		result := child.responseResult
 		child.broker.acks.Done()
+
+		if result == nil {
+			child.sendPartitionEvents(response, len(msgs))
+		}
 	}
 
 	expiryTicker.Stop()
 	close(child.messages)
 	close(child.errors)
+	close(child.events)
+}
+
+// sendPartitionEvents sends the PartitionEOF and PartitionIdle events that are
+// due once the messages of a fetch response have been delivered.
+func (child *partitionConsumer) sendPartitionEvents(response *FetchResponse, delivered int) {
+	now := time.Now()
+	if delivered > 0 {
+		child.reachedEOF = false
+		child.idle = false
+		child.lastRecordTime = now
+	}
+
+	if child.conf.Consumer.Return.PartitionEOF && !child.reachedEOF {
+		if block := response.GetBlock(child.topic, child.partition); block != nil {
+			end := block.HighWaterMarkOffset
+			if child.conf.Consumer.IsolationLevel == ReadCommitted && response.Version >= 4 {
+				end = block.LastStableOffset
+			}
+			if child.offset >= end {
+				child.reachedEOF = true
+				child.sendEvent(&PartitionEOF{
+					Topic:     child.topic,
+					Partition: child.partition,
+					Offset:    child.offset,
+				})
+			}
+		}
+	}
+
+	if child.conf.Consumer.IdleTimeout > 0 && !child.idle && now.Sub(child.lastRecordTime) >= child.conf.Consumer.IdleTimeout {
+		child.idle = true
+		child.sendEvent(&PartitionIdle{
+			Topic:     child.topic,
+			Partition: child.partition,
+			Offset:    child.offset,
+			Since:     child.lastRecordTime,
+		})
+	}
+}
+
+func (child *partitionConsumer) sendEvent(event PartitionEvent) {
+	select {
+	case child.events <- event:
+	case <-child.dying:
+	}
 }
 
 func (child *partitionConsumer) parseMessages(msgSet *MessageSet) ([]*ConsumerMessage, error) {
@@ -1069,18 +1179,18 @@ func (bc *brokerConsumer) fetchNewMessages() (*FetchResponse, error) {
 		MaxWaitTime: int32(bc.consumer.conf.Consumer.MaxWaitTime / time.Millisecond),
 	}
 	// Version 1 is the same as version 0.
-	if bc.consumer.conf.Version.IsAtLeast(V0_9_0_0) {
+	if bc.consumer.conf.versionCeiling().IsAtLeast(V0_9_0_0) {
 		request.Version = 1
 	}
 	// Starting in Version 2, the requestor must be able to handle Kafka Log
 	// Message format version 1.
-	if bc.consumer.conf.Version.IsAtLeast(V0_10_0_0) {
+	if bc.consumer.conf.versionCeiling().IsAtLeast(V0_10_0_0) {
 		request.Version = 2
 	}
 	// Version 3 adds MaxBytes.  Starting in version 3, the partition ordering in
 	// the request is now relevant.  Partitions will be processed in the order
 	// they appear in the request.
-	if bc.consumer.conf.Version.IsAtLeast(V0_10_1_0) {
+	if bc.consumer.conf.versionCeiling().IsAtLeast(V0_10_1_0) {
 		request.Version = 3
 		request.MaxBytes = MaxResponseSize
 	}
@@ -1088,16 +1198,16 @@ func (bc *brokerConsumer) fetchNewMessages() (*FetchResponse, error) {
 	// able to handle Kafka log message format version 2.
 	// Version 5 adds LogStartOffset to indicate the earliest available offset of
 	// partition data that can be consumed.
-	if bc.consumer.conf.Version.IsAtLeast(V0_11_0_0) {
+	if bc.consumer.conf.versionCeiling().IsAtLeast(V0_11_0_0) {
 		request.Version = 5
 		request.Isolation = bc.consumer.conf.Consumer.IsolationLevel
 	}
 	// Version 6 is the same as version 5.
-	if bc.consumer.conf.Version.IsAtLeast(V1_0_0_0) {
+	if bc.consumer.conf.versionCeiling().IsAtLeast(V1_0_0_0) {
 		request.Version = 6
 	}
 	// Version 7 adds incremental fetch request support.
-	if bc.consumer.conf.Version.IsAtLeast(V1_1_0_0) {
+	if bc.consumer.conf.versionCeiling().IsAtLeast(V1_1_0_0) {
 		request.Version = 7
 		// We do not currently implement KIP-227 FetchSessions. Setting the id to 0
 		// and the epoch to -1 tells the broker not to generate as session ID we're going
@@ -1106,17 +1216,17 @@ func (bc *brokerConsumer) fetchNewMessages() (*FetchResponse, error) {
 		request.SessionEpoch = -1
 	}
 	// Version 8 is the same as version 7.
-	if bc.consumer.conf.Version.IsAtLeast(V2_0_0_0) {
+	if bc.consumer.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 		request.Version = 8
 	}
 	// Version 9 adds CurrentLeaderEpoch, as described in KIP-320.
 	// Version 10 indicates that we can use the ZStd compression algorithm, as
 	// described in KIP-110.
-	if bc.consumer.conf.Version.IsAtLeast(V2_1_0_0) {
+	if bc.consumer.conf.versionCeiling().IsAtLeast(V2_1_0_0) {
 		request.Version = 10
 	}
 	// Version 11 adds RackID for KIP-392 fetch from closest replica
-	if bc.consumer.conf.Version.IsAtLeast(V2_3_0_0) {
+	if bc.consumer.conf.versionCeiling().IsAtLeast(V2_3_0_0) {
 		request.Version = 11
 		request.RackID = bc.consumer.conf.RackID
 	}
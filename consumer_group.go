@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"sort"
 	"sync"
 	"time"
@@ -45,6 +46,18 @@ type ConsumerGroup interface {
 	// recreated to get the new claims.
 	Consume(ctx context.Context, topics []string, handler ConsumerGroupHandler) error
 
+	// ConsumePattern behaves like Consume, but subscribes to every topic known to
+	// the cluster whose name matches the given regular expression, e.g.
+	// `^events\.tenant-.*`.
+	//
+	// The pattern is evaluated against Client.Topics() when a session starts and
+	// re-evaluated every Config.Metadata.RefreshFrequency. When the set of matching
+	// topics changes, the current session is ended in the same way as when the
+	// number of partitions of a subscribed topic changes, so that the next call
+	// rejoins the group with the updated subscription. Setting
+	// Config.Metadata.RefreshFrequency to 0 disables the re-evaluation.
+	ConsumePattern(ctx context.Context, pattern *regexp.Regexp, handler ConsumerGroupHandler) error
+
 	// Errors returns a read channel of errors that occurred during the consumer life-cycle.
 	// By default, errors are logged and not returned over this channel.
 	// If you want to implement any custom error handling, set your config's
@@ -86,6 +99,10 @@ type consumerGroup struct {
 	memberID        string
 	errors          chan error
 
+	// pattern is the topic subscription pattern of the running session, if any.
+	// It is only accessed while holding lock.
+	pattern *regexp.Regexp
+
 	lock       sync.Mutex
 	errorsLock sync.RWMutex
 	closed     chan none
@@ -125,7 +142,7 @@ func NewConsumerGroupFromClient(groupID string, client Client) (ConsumerGroup, e
 
 func newConsumerGroup(groupID string, client Client) (ConsumerGroup, error) {
 	config := client.Config()
-	if !config.Version.IsAtLeast(V0_10_2_0) {
+	if !config.versionCeiling().IsAtLeast(V0_10_2_0) {
 		return nil, ConfigurationError("consumer groups require Version to be >= V0_10_2_0")
 	}
 
@@ -144,7 +161,7 @@ func newConsumerGroup(groupID string, client Client) (ConsumerGroup, error) {
 		userData:       config.Consumer.Group.Member.UserData,
 		metricRegistry: newCleanupRegistry(config.MetricRegistry),
 	}
-	if config.Consumer.Group.InstanceId != "" && config.Version.IsAtLeast(V2_3_0_0) {
+	if config.Consumer.Group.InstanceId != "" && config.versionCeiling().IsAtLeast(V2_3_0_0) {
 		cg.groupInstanceId = &config.Consumer.Group.InstanceId
 	}
 	return cg, nil
@@ -205,6 +222,47 @@ func (c *consumerGroup) Consume(ctx context.Context, topics []string, handler Co
 		return err
 	}
 
+	c.pattern = nil
+	return c.consume(ctx, topics, handler)
+}
+
+// ConsumePattern implements ConsumerGroup.
+func (c *consumerGroup) ConsumePattern(ctx context.Context, pattern *regexp.Regexp, handler ConsumerGroupHandler) error {
+	// Ensure group is not closed
+	select {
+	case <-c.closed:
+		return ErrClosedConsumerGroup
+	default:
+	}
+
+	if pattern == nil {
+		return ConfigurationError("pattern must not be nil")
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// Refresh metadata for all topics so that the pattern sees newly created ones
+	if err := c.client.RefreshMetadata(); err != nil {
+		return err
+	}
+
+	topics, err := c.matchingTopics(pattern)
+	if err != nil {
+		return err
+	}
+
+	// Quick exit when no topics match
+	if len(topics) == 0 {
+		return fmt.Errorf("no topics match pattern %q", pattern.String())
+	}
+
+	c.pattern = pattern
+	return c.consume(ctx, topics, handler)
+}
+
+// c.lock must be held by caller
+func (c *consumerGroup) consume(ctx context.Context, topics []string, handler ConsumerGroupHandler) error {
 	// Init session
 	sess, err := c.newSession(ctx, topics, handler, c.config.Consumer.Group.Rebalance.Retry.Max)
 	if errors.Is(err, ErrClosedClient) {
@@ -431,9 +489,10 @@ func (c *consumerGroup) newSession(ctx context.Context, topics []string, handler
 		return nil, err
 	}
 
-	// only the leader needs to check whether there are newly-added partitions in order to trigger a rebalance
-	if join.LeaderId == join.MemberId {
-		go c.loopCheckPartitionNumbers(allSubscribedTopicPartitions, allSubscribedTopics, session)
+	// only the leader needs to check whether there are newly-added partitions in order to trigger a rebalance,
+	// but with a pattern subscription every member has to check whether its own set of matching topics changed
+	if join.LeaderId == join.MemberId || c.pattern != nil {
+		go c.loopCheckPartitionNumbers(allSubscribedTopicPartitions, allSubscribedTopics, c.pattern, topics, session)
 	}
 
 	return session, err
@@ -444,29 +503,29 @@ func (c *consumerGroup) joinGroupRequest(coordinator *Broker, topics []string) (
 		GroupId:        c.groupID,
 		MemberId:       c.memberID,
 		SessionTimeout: int32(c.config.Consumer.Group.Session.Timeout / time.Millisecond),
-		ProtocolType:   "consumer",
+		ProtocolType:   ConsumerGroupProtocolType,
 	}
-	if c.config.Version.IsAtLeast(V0_10_1_0) {
+	if c.config.versionCeiling().IsAtLeast(V0_10_1_0) {
 		req.Version = 1
 		req.RebalanceTimeout = int32(c.config.Consumer.Group.Rebalance.Timeout / time.Millisecond)
 	}
-	if c.config.Version.IsAtLeast(V0_11_0_0) {
+	if c.config.versionCeiling().IsAtLeast(V0_11_0_0) {
 		req.Version = 2
 	}
-	if c.config.Version.IsAtLeast(V0_11_0_0) {
+	if c.config.versionCeiling().IsAtLeast(V0_11_0_0) {
 		req.Version = 2
 	}
-	if c.config.Version.IsAtLeast(V2_0_0_0) {
+	if c.config.versionCeiling().IsAtLeast(V2_0_0_0) {
 		req.Version = 3
 	}
 	// from JoinGroupRequest v4 onwards (due to KIP-394) the client will actually
 	// send two JoinGroupRequests, once with the empty member id, and then again
 	// with the assigned id from the first response. This is handled via the
 	// ErrMemberIdRequired case.
-	if c.config.Version.IsAtLeast(V2_2_0_0) {
+	if c.config.versionCeiling().IsAtLeast(V2_2_0_0) {
 		req.Version = 4
 	}
-	if c.config.Version.IsAtLeast(V2_3_0_0) {
+	if c.config.versionCeiling().IsAtLeast(V2_3_0_0) {
 		req.Version = 5
 		req.GroupInstanceId = c.groupInstanceId
 	}
@@ -516,14 +575,14 @@ func (c *consumerGroup) syncGroupRequest(
 	}
 
 	// Versions 1 and 2 are the same as version 0.
-	if c.config.Version.IsAtLeast(V0_11_0_0) {
+	if c.config.versionCeiling().IsAtLeast(V0_11_0_0) {
 		req.Version = 1
 	}
-	if c.config.Version.IsAtLeast(V2_0_0_0) {
+	if c.config.versionCeiling().IsAtLeast(V2_0_0_0) {
 		req.Version = 2
 	}
 	// Starting from version 3, we add a new field called groupInstanceId to indicate member identity across restarts.
-	if c.config.Version.IsAtLeast(V2_3_0_0) {
+	if c.config.versionCeiling().IsAtLeast(V2_3_0_0) {
 		req.Version = 3
 		req.GroupInstanceId = c.groupInstanceId
 	}
@@ -558,14 +617,14 @@ func (c *consumerGroup) heartbeatRequest(coordinator *Broker, memberID string, g
 	}
 
 	// Version 1 and version 2 are the same as version 0.
-	if c.config.Version.IsAtLeast(V0_11_0_0) {
+	if c.config.versionCeiling().IsAtLeast(V0_11_0_0) {
 		req.Version = 1
 	}
-	if c.config.Version.IsAtLeast(V2_0_0_0) {
+	if c.config.versionCeiling().IsAtLeast(V2_0_0_0) {
 		req.Version = 2
 	}
 	// Starting from version 3, we add a new field called groupInstanceId to indicate member identity across restarts.
-	if c.config.Version.IsAtLeast(V2_3_0_0) {
+	if c.config.versionCeiling().IsAtLeast(V2_3_0_0) {
 		req.Version = 3
 		req.GroupInstanceId = c.groupInstanceId
 	}
@@ -627,13 +686,13 @@ func (c *consumerGroup) leave() error {
 		GroupId:  c.groupID,
 		MemberId: c.memberID,
 	}
-	if c.config.Version.IsAtLeast(V0_11_0_0) {
+	if c.config.versionCeiling().IsAtLeast(V0_11_0_0) {
 		req.Version = 1
 	}
-	if c.config.Version.IsAtLeast(V2_0_0_0) {
+	if c.config.versionCeiling().IsAtLeast(V2_0_0_0) {
 		req.Version = 2
 	}
-	if c.config.Version.IsAtLeast(V2_4_0_0) {
+	if c.config.versionCeiling().IsAtLeast(V2_4_0_0) {
 		req.Version = 3
 		req.Members = append(req.Members, MemberIdentity{
 			MemberId: c.memberID,
@@ -688,7 +747,7 @@ func (c *consumerGroup) handleError(err error, topic string, partition int32) {
 	}
 }
 
-func (c *consumerGroup) loopCheckPartitionNumbers(allSubscribedTopicPartitions map[string][]int32, topics []string, session *consumerGroupSession) {
+func (c *consumerGroup) loopCheckPartitionNumbers(allSubscribedTopicPartitions map[string][]int32, topics []string, pattern *regexp.Regexp, subscribedTopics []string, session *consumerGroupSession) {
 	if c.config.Metadata.RefreshFrequency == time.Duration(0) {
 		return
 	}
@@ -715,8 +774,26 @@ func (c *consumerGroup) loopCheckPartitionNumbers(allSubscribedTopicPartitions m
 				}
 			}
 		}
+		if pattern != nil {
+			if matchingTopics, err := c.matchingTopics(pattern); err != nil {
+				return
+			} else if !equalTopics(matchingTopics, subscribedTopics) {
+				Logger.Printf(
+					"consumergroup/%s loop check partition number goroutine find topics matching %q changed from %s to %s\n",
+					c.groupID, pattern.String(), subscribedTopics, matchingTopics)
+				return // trigger the end of the session on exit
+			}
+		}
 		select {
 		case <-pause.C:
+			if pattern != nil && !c.config.Metadata.Full {
+				// the background refresh only covers already known topics
+				if err := c.client.RefreshMetadata(); err != nil {
+					Logger.Printf(
+						"consumergroup/%s refresh metadata for topic pattern %q failed due to '%v'\n",
+						c.groupID, pattern.String(), err)
+				}
+			}
 		case <-session.ctx.Done():
 			Logger.Printf(
 				"consumergroup/%s loop check partition number goroutine will exit, topics %s\n",
@@ -729,6 +806,48 @@ func (c *consumerGroup) loopCheckPartitionNumbers(allSubscribedTopicPartitions m
 	}
 }
 
+// matchingTopics returns the sorted names of the topics known to the client
+// that match pattern.
+func (c *consumerGroup) matchingTopics(pattern *regexp.Regexp) ([]string, error) {
+	topics, err := c.client.Topics()
+	if err != nil {
+		Logger.Printf(
+			"consumergroup/%s get topics for pattern %q failed due to '%v'\n",
+			c.groupID, pattern.String(), err)
+		return nil, err
+	}
+	return filterTopics(topics, pattern), nil
+}
+
+func filterTopics(topics []string, pattern *regexp.Regexp) []string {
+	matched := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		if pattern.MatchString(topic) {
+			matched = append(matched, topic)
+		}
+	}
+	sort.Strings(matched)
+	return matched
+}
+
+// equalTopics reports whether a and b contain the same topics, regardless of order.
+func equalTopics(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]int, len(a))
+	for _, topic := range a {
+		seen[topic]++
+	}
+	for _, topic := range b {
+		if seen[topic] == 0 {
+			return false
+		}
+		seen[topic]--
+	}
+	return true
+}
+
 func (c *consumerGroup) topicToPartitionNumbers(topics []string) (map[string]int, error) {
 	topicToPartitionNum := make(map[string]int, len(topics))
 	for _, topic := range topics {
@@ -777,6 +896,17 @@ type ConsumerGroupSession interface {
 	// Note: calling Commit performs a blocking synchronous operation.
 	Commit()
 
+	// CommitSync commits the marked offsets and blocks until the coordinator has
+	// responded or ctx is done. It returns nil once every marked offset has been
+	// committed and OffsetCommitErrors if some partitions failed, so external
+	// state can be checkpointed only after Kafka confirmed the commit.
+	CommitSync(ctx context.Context) error
+
+	// CommitAsync commits the marked offsets in the background and invokes
+	// callback, if not nil, with the per-partition results, including the
+	// committed offsets, once the coordinator has responded.
+	CommitAsync(callback OffsetCommitCallback)
+
 	// ResetOffset resets to the provided offset, alongside a metadata string that
 	// represents the state of the partition consumer at that point in time. Reset
 	// acts as a counterpart to MarkOffset, the difference being that it allows to
@@ -892,6 +1022,14 @@ func (s *consumerGroupSession) Commit() {
 	s.offsets.Commit()
 }
 
+func (s *consumerGroupSession) CommitSync(ctx context.Context) error {
+	return s.offsets.CommitSync(ctx)
+}
+
+func (s *consumerGroupSession) CommitAsync(callback OffsetCommitCallback) {
+	s.offsets.CommitAsync(callback)
+}
+
 func (s *consumerGroupSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {
 	if pom := s.offsets.findPOM(topic, partition); pom != nil {
 		pom.ResetOffset(offset, metadata)
@@ -1105,6 +1243,12 @@ type ConsumerGroupClaim interface {
 	// Config.Consumer.Group.Session.Timeout before the topic/partition is eventually
 	// re-assigned to another group member.
 	Messages() <-chan *ConsumerMessage
+
+	// Events returns the read channel for PartitionEOF and PartitionIdle events,
+	// if enabled through the config's Consumer.Return.PartitionEOF and
+	// Consumer.IdleTimeout settings. The channel is closed together with the
+	// Messages channel.
+	Events() <-chan PartitionEvent
 }
 
 type consumerGroupClaim struct {
@@ -0,0 +1,291 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ConsumerGroupLagOptions configures ClusterAdmin.DescribeConsumerGroupLag.
+type ConsumerGroupLagOptions struct {
+	// IsolationLevel selects the end offset the lag is computed against: the
+	// high water mark for ReadUncommitted and the last stable offset for
+	// ReadCommitted.
+	IsolationLevel IsolationLevel
+	// TimeLag enables estimating the time lag of each lagging partition from
+	// the timestamp of its first unconsumed record. This consumes one record per
+	// lagging partition and is considerably more expensive than the offset lag.
+	TimeLag bool
+}
+
+// ConsumerGroupLag is the lag of a single consumer group.
+type ConsumerGroupLag struct {
+	Group string
+	State string
+	// Err is set if the lag of the group could not be described at all.
+	Err error
+	// Partitions are sorted by topic and partition. They contain all partitions
+	// the group has committed offsets for or that are assigned to a member.
+	Partitions []*PartitionLag
+}
+
+// TotalLag returns the sum of the lag of all partitions with a known lag.
+func (l *ConsumerGroupLag) TotalLag() int64 {
+	var total int64
+	for _, partition := range l.Partitions {
+		if partition.Lag > 0 {
+			total += partition.Lag
+		}
+	}
+	return total
+}
+
+// PartitionLag is the lag of a consumer group on a single partition.
+type PartitionLag struct {
+	Topic     string
+	Partition int32
+	// CommittedOffset is the committed offset of the group, or -1 if it has none.
+	CommittedOffset int64
+	// EndOffset is the high water mark or last stable offset, or -1 if unknown.
+	EndOffset int64
+	// Lag is EndOffset - CommittedOffset, or -1 if either of them is unknown.
+	Lag int64
+	// TimeLag is the time since the first unconsumed record was produced. It is
+	// only estimated if enabled through ConsumerGroupLagOptions.TimeLag and zero
+	// if the partition has no lag or the record could not be read in time.
+	TimeLag time.Duration
+	// MemberID, ClientID and ClientHost identify the member the partition is
+	// assigned to. They are empty if the partition is not assigned.
+	MemberID   string
+	ClientID   string
+	ClientHost string
+	// Err is set if the committed or end offset could not be fetched.
+	Err error
+}
+
+func (ca *clusterAdmin) DescribeConsumerGroupLag(groups []string, options *ConsumerGroupLagOptions) ([]*ConsumerGroupLag, error) {
+	if options == nil {
+		options = &ConsumerGroupLagOptions{}
+	}
+
+	descriptions, err := ca.DescribeConsumerGroups(groups)
+	if err != nil {
+		return nil, err
+	}
+	byGroup := make(map[string]*GroupDescription, len(descriptions))
+	for _, description := range descriptions {
+		byGroup[description.GroupId] = description
+	}
+
+	lags := make([]*ConsumerGroupLag, 0, len(groups))
+	endOffsetSpecs := make(map[string]map[int32]OffsetSpec)
+	for _, group := range groups {
+		lag := &ConsumerGroupLag{Group: group}
+		lags = append(lags, lag)
+
+		description := byGroup[group]
+		if description == nil {
+			lag.Err = ErrIncompleteResponse
+			continue
+		}
+		if !errors.Is(description.Err, ErrNoError) {
+			lag.Err = description.Err
+			continue
+		}
+		lag.State = description.State
+
+		if err := ca.describeGroupPartitions(lag, description); err != nil {
+			lag.Err = err
+			continue
+		}
+		for _, partition := range lag.Partitions {
+			if endOffsetSpecs[partition.Topic] == nil {
+				endOffsetSpecs[partition.Topic] = make(map[int32]OffsetSpec)
+			}
+			endOffsetSpecs[partition.Topic][partition.Partition] = OffsetSpecLatest
+		}
+	}
+
+	// the end offsets of all groups are listed at once, so partitions consumed
+	// by several groups are only listed once
+	endOffsets, err := ca.ListOffsets(endOffsetSpecs, options.IsolationLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	var lagging []*PartitionLag
+	for _, lag := range lags {
+		for _, partition := range lag.Partitions {
+			if partition.Err != nil {
+				continue
+			}
+			endOffset := endOffsets[partition.Topic][partition.Partition]
+			if endOffset == nil {
+				partition.Err = ErrIncompleteResponse
+				continue
+			}
+			if endOffset.Err != nil {
+				partition.Err = endOffset.Err
+				continue
+			}
+			partition.EndOffset = endOffset.Offset
+			if partition.CommittedOffset >= 0 && partition.EndOffset >= 0 {
+				partition.Lag = partition.EndOffset - partition.CommittedOffset
+				if partition.Lag < 0 {
+					// the end offset was listed after the offset was committed
+					partition.Lag = 0
+				}
+				if partition.Lag > 0 {
+					lagging = append(lagging, partition)
+				}
+			}
+		}
+	}
+
+	if options.TimeLag && len(lagging) > 0 {
+		if err := ca.estimateTimeLag(lagging, options.IsolationLevel); err != nil {
+			return nil, err
+		}
+	}
+
+	return lags, nil
+}
+
+// describeGroupPartitions fills lag.Partitions with the committed offsets and
+// the owning members of the partitions of the given group.
+func (ca *clusterAdmin) describeGroupPartitions(lag *ConsumerGroupLag, description *GroupDescription) error {
+	partitions := make(map[string]map[int32]*PartitionLag)
+	partitionLag := func(topic string, partition int32) *PartitionLag {
+		if partitions[topic] == nil {
+			partitions[topic] = make(map[int32]*PartitionLag)
+		}
+		if partitions[topic][partition] == nil {
+			partitions[topic][partition] = &PartitionLag{
+				Topic:           topic,
+				Partition:       partition,
+				CommittedOffset: -1,
+				EndOffset:       -1,
+				Lag:             -1,
+			}
+		}
+		return partitions[topic][partition]
+	}
+
+	for _, member := range description.Members {
+		if member.Assignment == nil {
+			// not a consumer group, or a member without an assignment yet
+			continue
+		}
+		for topic, assigned := range member.Assignment.Topics {
+			for _, partition := range assigned {
+				owned := partitionLag(topic, partition)
+				owned.MemberID = member.MemberId
+				owned.ClientID = member.ClientId
+				owned.ClientHost = member.ClientHost
+			}
+		}
+	}
+
+	committed, err := ca.ListConsumerGroupOffsets(lag.Group, nil)
+	if err != nil {
+		return err
+	}
+	if !errors.Is(committed.Err, ErrNoError) {
+		return committed.Err
+	}
+	for topic, blocks := range committed.Blocks {
+		for partition, block := range blocks {
+			if !errors.Is(block.Err, ErrNoError) {
+				partitionLag(topic, partition).Err = block.Err
+				continue
+			}
+			if block.Offset >= 0 {
+				partitionLag(topic, partition).CommittedOffset = block.Offset
+			}
+		}
+	}
+
+	for _, byPartition := range partitions {
+		for _, partition := range byPartition {
+			lag.Partitions = append(lag.Partitions, partition)
+		}
+	}
+	sort.Slice(lag.Partitions, func(i, j int) bool {
+		a, b := lag.Partitions[i], lag.Partitions[j]
+		if a.Topic != b.Topic {
+			return a.Topic < b.Topic
+		}
+		return a.Partition < b.Partition
+	})
+	return nil
+}
+
+// estimateTimeLag sets the TimeLag of the given partitions from the timestamp
+// of the record at their committed offset. All partitions are consumed by a
+// single consumer, so the fetches are batched per broker. The consumer reads
+// with the isolation level the end offsets were listed with, so that it does
+// not wait for records past the last stable offset, and it gives up after
+// Admin.Timeout.
+func (ca *clusterAdmin) estimateTimeLag(partitions []*PartitionLag, isolationLevel IsolationLevel) error {
+	conf := *ca.client.Config()
+	conf.Consumer.IsolationLevel = isolationLevel
+	if conf.Consumer.MaxWaitTime > ca.conf.Admin.Timeout {
+		conf.Consumer.MaxWaitTime = ca.conf.Admin.Timeout
+	}
+	consumer, err := newConsumerWithConfig(&nopCloserClient{ca.client}, &conf)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := consumer.Close(); err != nil {
+			Logger.Println("admin/lag failed to close consumer:", err)
+		}
+	}()
+
+	// the same partition may be lagging in several groups
+	type topicPartition struct {
+		topic     string
+		partition int32
+		offset    int64
+	}
+	byOffset := make(map[topicPartition][]*PartitionLag)
+	for _, partition := range partitions {
+		key := topicPartition{partition.Topic, partition.Partition, partition.CommittedOffset}
+		byOffset[key] = append(byOffset[key], partition)
+	}
+
+	now := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), ca.conf.Admin.Timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for key, lagging := range byOffset {
+		pc, err := consumer.ConsumePartition(key.topic, key.partition, key.offset)
+		if err != nil {
+			// for example an offset that is no longer available
+			Logger.Printf("admin/lag failed to estimate time lag of %s/%d: %v\n", key.topic, key.partition, err)
+			continue
+		}
+
+		lagging := lagging
+		wg.Add(1)
+		go withRecover(func() {
+			defer wg.Done()
+			defer func() { _ = pc.Close() }()
+
+			select {
+			case msg, ok := <-pc.Messages():
+				if ok && !msg.Timestamp.IsZero() {
+					for _, partition := range lagging {
+						partition.TimeLag = now.Sub(msg.Timestamp)
+					}
+				}
+			case <-ctx.Done():
+			}
+		})
+	}
+	wg.Wait()
+	return nil
+}
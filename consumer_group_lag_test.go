@@ -0,0 +1,154 @@
+package sarama
+
+import "testing"
+
+func TestClusterAdminDescribeConsumerGroupLag(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	group := "my-group"
+	topic := "my-topic"
+
+	assignment, err := Encode(&ConsumerGroupMemberAssignment{
+		Topics: map[string][]int32{topic: {0, 1}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"DescribeGroupsRequest": NewMockDescribeGroupsResponse(t).AddGroupDescription(group, &GroupDescription{
+			GroupId:      group,
+			State:        "Stable",
+			ProtocolType: "consumer",
+			Members: map[string]*GroupMemberDescription{
+				"member-1": {
+					MemberId:         "member-1",
+					ClientId:         "client-1",
+					ClientHost:       "/10.0.0.1",
+					MemberAssignment: assignment,
+				},
+			},
+		}),
+		"OffsetFetchRequest": NewMockOffsetFetchResponse(t).
+			SetOffset(group, topic, 0, 5, "", ErrNoError).
+			SetOffset(group, topic, 1, 10, "", ErrNoError).
+			SetError(ErrNoError),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset(topic, 0, OffsetNewest, 8).
+			SetOffset(topic, 1, OffsetNewest, 10),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader(topic, 0, seedBroker.BrokerID()).
+			SetLeader(topic, 1, seedBroker.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).SetCoordinator(CoordinatorGroup, group, seedBroker),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	lags, err := admin.DescribeConsumerGroupLag([]string{group}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lags) != 1 {
+		t.Fatalf("Expected 1 group, got %d", len(lags))
+	}
+	lag := lags[0]
+	if lag.Err != nil {
+		t.Fatal(lag.Err)
+	}
+	if lag.State != "Stable" {
+		t.Errorf("Expected state Stable, got %s", lag.State)
+	}
+	if len(lag.Partitions) != 2 {
+		t.Fatalf("Expected 2 partitions, got %d", len(lag.Partitions))
+	}
+	for i, expected := range []int64{3, 0} {
+		partition := lag.Partitions[i]
+		if partition.Partition != int32(i) {
+			t.Errorf("Expected partitions to be sorted, got %d at %d", partition.Partition, i)
+		}
+		if partition.Err != nil {
+			t.Errorf("Unexpected error for partition %d: %v", i, partition.Err)
+		}
+		if partition.Lag != expected {
+			t.Errorf("Expected lag %d for partition %d, got %d", expected, i, partition.Lag)
+		}
+		if partition.MemberID != "member-1" || partition.ClientID != "client-1" || partition.ClientHost != "/10.0.0.1" {
+			t.Errorf("Unexpected owner of partition %d: %+v", i, partition)
+		}
+	}
+	if total := lag.TotalLag(); total != 3 {
+		t.Errorf("Expected total lag 3, got %d", total)
+	}
+}
+
+func TestClusterAdminDescribeConsumerGroupTimeLagIsolationLevel(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	group := "my-group"
+	topic := "my-topic"
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"DescribeGroupsRequest": NewMockDescribeGroupsResponse(t).AddGroupDescription(group, &GroupDescription{
+			GroupId:      group,
+			State:        "Empty",
+			ProtocolType: "consumer",
+		}),
+		"OffsetFetchRequest": NewMockOffsetFetchResponse(t).
+			SetOffset(group, topic, 0, 5, "", ErrNoError).
+			SetError(ErrNoError),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset(topic, 0, OffsetOldest, 0).
+			SetOffset(topic, 0, OffsetNewest, 8),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader(topic, 0, seedBroker.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).SetCoordinator(CoordinatorGroup, group, seedBroker),
+		"FetchRequest":           NewMockFetchResponse(t, 1).SetMessage(topic, 0, 5, StringEncoder("foo")),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+	config.Consumer.IsolationLevel = ReadCommitted
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	lags, err := admin.DescribeConsumerGroupLag([]string{group}, &ConsumerGroupLagOptions{
+		IsolationLevel: ReadUncommitted,
+		TimeLag:        true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lags) != 1 || len(lags[0].Partitions) != 1 || lags[0].Partitions[0].Lag != 3 {
+		t.Fatalf("Expected a lag of 3 on a single partition, got %+v", lags)
+	}
+
+	fetches := 0
+	for _, rr := range seedBroker.History() {
+		if req, ok := rr.Request.(*FetchRequest); ok {
+			fetches++
+			if req.Isolation != ReadUncommitted {
+				t.Errorf("Expected the records to be fetched with the isolation level of the options, got %v", req.Isolation)
+			}
+		}
+	}
+	if fetches == 0 {
+		t.Error("Expected the first unconsumed record to be fetched")
+	}
+}
@@ -0,0 +1,105 @@
+package sarama
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ListConsumerGroupsOptions filters the groups listed by
+// ClusterAdmin.ListConsumerGroupsWithOptions. Empty filters match all groups.
+type ListConsumerGroupsOptions struct {
+	// States only lists groups in one of the given states, for example "Empty"
+	// or "Dead" (KIP-518). It requires Kafka 2.6 or later.
+	States []string
+	// Types only lists groups of one of the given types, "classic" or
+	// "consumer" (KIP-848). It requires Kafka 3.8 or later.
+	Types []string
+}
+
+// ConsumerGroupListing is a single group listed by
+// ClusterAdmin.ListConsumerGroupsWithOptions.
+type ConsumerGroupListing struct {
+	GroupID      string
+	ProtocolType string
+	// State is empty before Kafka 2.6.
+	State string
+	// Type is empty before Kafka 3.8.
+	Type string
+}
+
+// ListConsumerGroupsResult is the result of
+// ClusterAdmin.ListConsumerGroupsWithOptions.
+type ListConsumerGroupsResult struct {
+	// Groups are sorted by group ID.
+	Groups []*ConsumerGroupListing
+	// Errors contains the error of each broker that failed to list its groups,
+	// whose groups are missing from Groups.
+	Errors map[int32]error
+}
+
+func (ca *clusterAdmin) ListConsumerGroupsWithOptions(options *ListConsumerGroupsOptions) (*ListConsumerGroupsResult, error) {
+	if options == nil {
+		options = &ListConsumerGroupsOptions{}
+	}
+	if len(options.States) > 0 && !ca.conf.versionCeiling().IsAtLeast(V2_6_0_0) {
+		return nil, ConfigurationError("filtering consumer groups by state requires Version >= V2_6_0_0")
+	}
+	if len(options.Types) > 0 && !ca.conf.versionCeiling().IsAtLeast(V3_8_0_0) {
+		return nil, ConfigurationError("filtering consumer groups by type requires Version >= V3_8_0_0")
+	}
+
+	brokers := ca.client.Brokers()
+	if len(brokers) == 0 {
+		return nil, ErrOutOfBrokers
+	}
+
+	var lock sync.Mutex
+	result := &ListConsumerGroupsResult{Errors: make(map[int32]error)}
+	listed := make(map[string]*ConsumerGroupListing)
+
+	// Query brokers in parallel, since we have to query *all* brokers
+	var wg sync.WaitGroup
+	for _, b := range brokers {
+		wg.Add(1)
+		go func(b *Broker) {
+			defer wg.Done()
+			_ = b.Open(ca.conf) // Ensure that broker is opened
+
+			request := newListGroupsRequest(ca.conf.versionCeiling())
+			request.StatesFilter = options.States
+			request.TypesFilter = options.Types
+
+			response, err := b.ListGroups(request)
+			if err == nil && !errors.Is(response.Err, ErrNoError) {
+				err = response.Err
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				result.Errors[b.ID()] = err
+				return
+			}
+			for group, protocolType := range response.Groups {
+				data := response.GroupsData[group]
+				listed[group] = &ConsumerGroupListing{
+					GroupID:      group,
+					ProtocolType: protocolType,
+					State:        data.GroupState,
+					Type:         data.GroupType,
+				}
+			}
+		}(b)
+	}
+	wg.Wait()
+
+	result.Groups = make([]*ConsumerGroupListing, 0, len(listed))
+	for _, group := range listed {
+		result.Groups = append(result.Groups, group)
+	}
+	sort.Slice(result.Groups, func(i, j int) bool {
+		return result.Groups[i].GroupID < result.Groups[j].GroupID
+	})
+	return result, nil
+}
@@ -0,0 +1,89 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestListConsumerGroupsWithOptions(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	secondBroker := NewMockBroker(t, 2)
+	defer secondBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetBroker(secondBroker.Addr(), secondBroker.BrokerID()),
+		"ListGroupsRequest": NewMockListGroupsResponse(t).
+			AddGroupWithData("empty-group", "consumer", GroupData{GroupState: "Empty"}).
+			AddGroupWithData("dead-group", "consumer", GroupData{GroupState: "Dead"}).
+			AddGroupWithData("stable-group", "consumer", GroupData{GroupState: "Stable"}),
+	})
+	secondBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"ListGroupsRequest":  NewMockWrapper(&ListGroupsResponse{Version: 4, Err: ErrOffsetsLoadInProgress}),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_6_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	result, err := admin.ListConsumerGroupsWithOptions(&ListConsumerGroupsOptions{
+		States: []string{"Empty", "Dead"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(result.Groups))
+	}
+	if result.Groups[0].GroupID != "dead-group" || result.Groups[0].State != "Dead" {
+		t.Errorf("Unexpected first group %+v", result.Groups[0])
+	}
+	if result.Groups[1].GroupID != "empty-group" || result.Groups[1].State != "Empty" {
+		t.Errorf("Unexpected second group %+v", result.Groups[1])
+	}
+
+	if len(result.Errors) != 1 {
+		t.Fatalf("Expected 1 broker error, got %d", len(result.Errors))
+	}
+	if !errors.Is(result.Errors[secondBroker.BrokerID()], ErrOffsetsLoadInProgress) {
+		t.Errorf("Expected ErrOffsetsLoadInProgress for the second broker, got %v", result.Errors[secondBroker.BrokerID()])
+	}
+}
+
+func TestListConsumerGroupsWithOptionsUnsupportedFilter(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_0_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	_, err = admin.ListConsumerGroupsWithOptions(&ListConsumerGroupsOptions{Types: []string{"consumer"}})
+	var configErr ConfigurationError
+	if !errors.As(err, &configErr) {
+		t.Errorf("Expected a ConfigurationError, got %v", err)
+	}
+}
@@ -2,6 +2,11 @@ package sarama
 
 import "errors"
 
+// ConsumerGroupProtocolType is the protocol type of groups joined by consumers,
+// whose member metadata and assignments are ConsumerGroupMemberMetadata and
+// ConsumerGroupMemberAssignment.
+const ConsumerGroupProtocolType = "consumer"
+
 // ConsumerGroupMemberMetadata holds the metadata for consumer group
 // https://github.com/apache/kafka/blob/trunk/clients/src/main/resources/common/message/ConsumerProtocolSubscription.json
 type ConsumerGroupMemberMetadata struct {
@@ -3,6 +3,7 @@ package sarama
 import (
 	"context"
 	"errors"
+	"regexp"
 	"sync"
 	"testing"
 	"time"
@@ -244,3 +245,129 @@ func TestConsumerShouldNotRetrySessionIfContextCancelled(t *testing.T) {
 	_, err = c.retryNewSession(ctx, nil, nil, 1024, true)
 	assert.Equal(t, context.Canceled, err)
 }
+
+func TestConsumerGroupConsumePatternRequiresPattern(t *testing.T) {
+	c := &consumerGroup{
+		config: NewTestConfig(),
+		closed: make(chan none),
+	}
+	err := c.ConsumePattern(context.Background(), nil, nil)
+	assert.Error(t, err)
+}
+
+func TestConsumerGroupFilterTopics(t *testing.T) {
+	pattern := regexp.MustCompile(`^events\.tenant-.*`)
+	topics := []string{"events.tenant-b", "events.other", "events.tenant-a", "audit"}
+
+	assert.Equal(t, []string{"events.tenant-a", "events.tenant-b"}, filterTopics(topics, pattern))
+	assert.Empty(t, filterTopics([]string{"audit"}, pattern))
+}
+
+func TestConsumerGroupEqualTopics(t *testing.T) {
+	assert.True(t, equalTopics(nil, []string{}))
+	assert.True(t, equalTopics([]string{"a", "b"}, []string{"b", "a"}))
+	assert.False(t, equalTopics([]string{"a", "b"}, []string{"a"}))
+	assert.False(t, equalTopics([]string{"a", "a"}, []string{"a", "b"}))
+}
+
+type claimsHandler struct {
+	claims chan map[string][]int32
+}
+
+func (h *claimsHandler) Setup(s ConsumerGroupSession) error {
+	h.claims <- s.Claims()
+	return nil
+}
+func (h *claimsHandler) Cleanup(s ConsumerGroupSession) error { return nil }
+func (h *claimsHandler) ConsumeClaim(sess ConsumerGroupSession, claim ConsumerGroupClaim) error {
+	<-sess.Context().Done()
+	return nil
+}
+
+func TestConsumerGroupConsumePatternRejoinsOnNewTopic(t *testing.T) {
+	config := NewTestConfig()
+	config.ClientID = t.Name()
+	config.Version = V2_0_0_0
+	config.Metadata.RefreshFrequency = 50 * time.Millisecond
+	config.Consumer.Group.Rebalance.Retry.Backoff = 0
+	config.Consumer.Offsets.AutoCommit.Enable = false
+
+	broker0 := NewMockBroker(t, 0)
+	defer broker0.Close()
+
+	handlers := func(topics ...string) map[string]MockResponse {
+		metadata := NewMockMetadataResponse(t).SetBroker(broker0.Addr(), broker0.BrokerID())
+		offsets := NewMockOffsetResponse(t)
+		offsetFetch := NewMockOffsetFetchResponse(t).SetError(ErrNoError)
+		assignment := &ConsumerGroupMemberAssignment{Topics: map[string][]int32{}}
+		for _, topic := range topics {
+			metadata.SetLeader(topic, 0, broker0.BrokerID())
+			offsets.SetOffset(topic, 0, OffsetOldest, 0).SetOffset(topic, 0, OffsetNewest, 0)
+			offsetFetch.SetOffset("my-group", topic, 0, 0, "", ErrNoError)
+			assignment.Topics[topic] = []int32{0}
+		}
+		return map[string]MockResponse{
+			"MetadataRequest": metadata.SetLeader("audit", 0, broker0.BrokerID()),
+			"OffsetRequest":   offsets,
+			"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+				SetCoordinator(CoordinatorGroup, "my-group", broker0),
+			"HeartbeatRequest":   NewMockHeartbeatResponse(t),
+			"JoinGroupRequest":   NewMockJoinGroupResponse(t).SetGroupProtocol(RangeBalanceStrategyName),
+			"SyncGroupRequest":   NewMockSyncGroupResponse(t).SetMemberAssignment(assignment),
+			"LeaveGroupRequest":  NewMockLeaveGroupResponse(t),
+			"OffsetFetchRequest": offsetFetch,
+			"FetchRequest":       NewMockFetchResponse(t, 1),
+		}
+	}
+	broker0.SetHandlerByMap(handlers("events.a"))
+
+	group, err := NewConsumerGroup([]string{broker0.Addr()}, "my-group", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = group.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := &claimsHandler{claims: make(chan map[string][]int32, 2)}
+	done := make(chan none)
+	go func() {
+		defer close(done)
+		for ctx.Err() == nil {
+			if err := group.ConsumePattern(ctx, regexp.MustCompile(`^events\.`), h); err != nil && ctx.Err() == nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	nextClaims := func() map[string][]int32 {
+		select {
+		case claims := <-h.claims:
+			return claims
+		case <-time.After(10 * time.Second):
+			t.Fatal("Expected a new session")
+			return nil
+		}
+	}
+	assert.Equal(t, map[string][]int32{"events.a": {0}}, nextClaims())
+
+	// a new matching topic ends the session, and the group is rejoined with it
+	broker0.SetHandlerByMap(handlers("events.a", "events.b"))
+	assert.Equal(t, map[string][]int32{"events.a": {0}, "events.b": {0}}, nextClaims())
+
+	var subscriptions [][]string
+	for _, rr := range broker0.History() {
+		if req, ok := rr.Request.(*JoinGroupRequest); ok {
+			var meta ConsumerGroupMemberMetadata
+			if err := Decode(req.OrderedGroupProtocols[0].Metadata, &meta, nil); err != nil {
+				t.Fatal(err)
+			}
+			subscriptions = append(subscriptions, meta.Topics)
+		}
+	}
+	assert.Equal(t, [][]string{{"events.a"}, {"events.a", "events.b"}}, subscriptions)
+
+	cancel()
+	<-done
+}
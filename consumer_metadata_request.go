@@ -31,6 +31,10 @@ func (r *ConsumerMetadataRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *ConsumerMetadataRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ConsumerMetadataRequest) HeaderVersion() int16 {
 	return 1
 }
@@ -74,6 +74,10 @@ func (r *ConsumerMetadataResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *ConsumerMetadataResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ConsumerMetadataResponse) HeaderVersion() int16 {
 	return 0
 }
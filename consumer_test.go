@@ -829,7 +829,16 @@ func TestConsumeMessageWithSessionIDs(t *testing.T) {
 	safeClose(t, master)
 	broker0.Close()
 
-	fetchReq := broker0.History()[3].Request.(*FetchRequest)
+	var fetchReq *FetchRequest
+	for _, rr := range broker0.History() {
+		if req, ok := rr.Request.(*FetchRequest); ok {
+			fetchReq = req
+			break
+		}
+	}
+	if fetchReq == nil {
+		t.Fatal("Expected a fetch request")
+	}
 	if fetchReq.SessionID != 0 || fetchReq.SessionEpoch != -1 {
 		t.Error("Expected session ID to be zero & Epoch to be -1")
 	}
@@ -2143,3 +2152,62 @@ func TestConsumerError(t *testing.T) {
 		t.Error("unexpected errors.Is")
 	}
 }
+
+func TestPartitionConsumerSendPartitionEvents(t *testing.T) {
+	t.Parallel()
+	conf := NewTestConfig()
+	conf.Consumer.Return.PartitionEOF = true
+	conf.Consumer.IdleTimeout = time.Millisecond
+	child := &partitionConsumer{
+		conf:           conf,
+		topic:          "my_topic",
+		partition:      0,
+		events:         make(chan PartitionEvent, 10),
+		dying:          make(chan none),
+		offset:         10,
+		lastRecordTime: time.Now(),
+	}
+
+	response := new(FetchResponse)
+	response.AddError("my_topic", 0, ErrNoError)
+	response.GetBlock("my_topic", 0).HighWaterMarkOffset = 10
+
+	// records were delivered and the partition caught up
+	child.sendPartitionEvents(response, 1)
+	if len(child.events) != 1 {
+		t.Fatalf("expected a single event, got %d", len(child.events))
+	}
+	eof, ok := (<-child.events).(*PartitionEOF)
+	if !ok || eof.Topic != "my_topic" || eof.Partition != 0 || eof.Offset != 10 {
+		t.Errorf("unexpected event %+v", eof)
+	}
+
+	// further empty responses neither repeat the EOF nor count as idle yet
+	child.sendPartitionEvents(response, 0)
+	if len(child.events) != 0 {
+		t.Fatalf("expected no event, got %d", len(child.events))
+	}
+
+	time.Sleep(2 * conf.Consumer.IdleTimeout)
+	child.sendPartitionEvents(response, 0)
+	if idle, ok := (<-child.events).(*PartitionIdle); !ok || idle.Offset != 10 {
+		t.Errorf("unexpected event %+v", idle)
+	}
+	child.sendPartitionEvents(response, 0)
+	if len(child.events) != 0 {
+		t.Fatalf("expected idle event to be sent only once, got %d", len(child.events))
+	}
+
+	// new records reset both events
+	child.offset = 12
+	response.GetBlock("my_topic", 0).HighWaterMarkOffset = 15
+	child.sendPartitionEvents(response, 2)
+	if len(child.events) != 0 {
+		t.Fatalf("expected no event before reaching the high water mark, got %d", len(child.events))
+	}
+	child.offset = 15
+	child.sendPartitionEvents(response, 3)
+	if eof, ok := (<-child.events).(*PartitionEOF); !ok || eof.Offset != 15 {
+		t.Errorf("unexpected event %+v", eof)
+	}
+}
@@ -68,6 +68,10 @@ func (r *CreatePartitionsRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *CreatePartitionsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *CreatePartitionsRequest) HeaderVersion() int16 {
 	return 1
 }
@@ -1,6 +1,7 @@
 package sarama
 
 import (
+	"errors"
 	"time"
 )
 
@@ -17,14 +18,20 @@ type CreateTopicsRequest struct {
 }
 
 func (c *CreateTopicsRequest) Encode(pe packetEncoder) error {
-	if err := pe.putArrayLength(len(c.TopicDetails)); err != nil {
+	if c.Version >= 5 {
+		pe.putCompactArrayLength(len(c.TopicDetails))
+	} else if err := pe.putArrayLength(len(c.TopicDetails)); err != nil {
 		return err
 	}
 	for topic, detail := range c.TopicDetails {
-		if err := pe.putString(topic); err != nil {
+		if c.Version >= 5 {
+			if err := pe.putCompactString(topic); err != nil {
+				return err
+			}
+		} else if err := pe.putString(topic); err != nil {
 			return err
 		}
-		if err := detail.Encode(pe); err != nil {
+		if err := detail.encode(pe, c.Version); err != nil {
 			return err
 		}
 	}
@@ -35,11 +42,20 @@ func (c *CreateTopicsRequest) Encode(pe packetEncoder) error {
 		pe.putBool(c.ValidateOnly)
 	}
 
+	if c.Version >= 5 {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
 func (c *CreateTopicsRequest) Decode(pd packetDecoder, version int16) (err error) {
-	n, err := pd.getArrayLength()
+	var n int
+	if version >= 5 {
+		n, err = pd.getCompactArrayLength()
+	} else {
+		n, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
@@ -47,7 +63,12 @@ func (c *CreateTopicsRequest) Decode(pd packetDecoder, version int16) (err error
 	c.TopicDetails = make(map[string]*TopicDetail, n)
 
 	for i := 0; i < n; i++ {
-		topic, err := pd.getString()
+		var topic string
+		if version >= 5 {
+			topic, err = pd.getCompactString()
+		} else {
+			topic, err = pd.getString()
+		}
 		if err != nil {
 			return err
 		}
@@ -72,6 +93,12 @@ func (c *CreateTopicsRequest) Decode(pd packetDecoder, version int16) (err error
 		c.Version = version
 	}
 
+	if version >= 5 {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -83,16 +110,32 @@ func (c *CreateTopicsRequest) APIVersion() int16 {
 	return c.Version
 }
 
+func (c *CreateTopicsRequest) setVersion(v int16) {
+	c.Version = v
+}
+
+func (c *CreateTopicsRequest) checkVersion(v int16) error {
+	if c.ValidateOnly && v < 1 {
+		return errors.New("validating only requires version 1")
+	}
+	return nil
+}
+
 func (r *CreateTopicsRequest) HeaderVersion() int16 {
+	if r.Version >= 5 {
+		return 2
+	}
 	return 1
 }
 
 func (c *CreateTopicsRequest) IsValidVersion() bool {
-	return c.Version >= 0 && c.Version <= 3
+	return c.Version >= 0 && c.Version <= 5
 }
 
 func (c *CreateTopicsRequest) RequiredVersion() KafkaVersion {
 	switch c.Version {
+	case 5, 4:
+		return V2_4_0_0
 	case 3:
 		return V2_0_0_0
 	case 2:
@@ -123,23 +166,46 @@ type TopicDetail struct {
 }
 
 func (t *TopicDetail) Encode(pe packetEncoder) error {
+	return t.encode(pe, 0)
+}
+
+func (t *TopicDetail) encode(pe packetEncoder, version int16) error {
 	pe.putInt32(t.NumPartitions)
 	pe.putInt16(t.ReplicationFactor)
 
-	if err := pe.putArrayLength(len(t.ReplicaAssignment)); err != nil {
+	if version >= 5 {
+		pe.putCompactArrayLength(len(t.ReplicaAssignment))
+	} else if err := pe.putArrayLength(len(t.ReplicaAssignment)); err != nil {
 		return err
 	}
 	for partition, assignment := range t.ReplicaAssignment {
 		pe.putInt32(partition)
-		if err := pe.putInt32Array(assignment); err != nil {
+		if version >= 5 {
+			if err := pe.putCompactInt32Array(assignment); err != nil {
+				return err
+			}
+			pe.putEmptyTaggedFieldArray()
+		} else if err := pe.putInt32Array(assignment); err != nil {
 			return err
 		}
 	}
 
-	if err := pe.putArrayLength(len(t.ConfigEntries)); err != nil {
+	if version >= 5 {
+		pe.putCompactArrayLength(len(t.ConfigEntries))
+	} else if err := pe.putArrayLength(len(t.ConfigEntries)); err != nil {
 		return err
 	}
 	for configKey, configValue := range t.ConfigEntries {
+		if version >= 5 {
+			if err := pe.putCompactString(configKey); err != nil {
+				return err
+			}
+			if err := pe.putNullableCompactString(configValue); err != nil {
+				return err
+			}
+			pe.putEmptyTaggedFieldArray()
+			continue
+		}
 		if err := pe.putString(configKey); err != nil {
 			return err
 		}
@@ -148,6 +214,10 @@ func (t *TopicDetail) Encode(pe packetEncoder) error {
 		}
 	}
 
+	if version >= 5 {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
@@ -159,7 +229,12 @@ func (t *TopicDetail) Decode(pd packetDecoder, version int16) (err error) {
 		return err
 	}
 
-	n, err := pd.getArrayLength()
+	var n int
+	if version >= 5 {
+		n, err = pd.getCompactArrayLength()
+	} else {
+		n, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
@@ -171,13 +246,26 @@ func (t *TopicDetail) Decode(pd packetDecoder, version int16) (err error) {
 			if err != nil {
 				return err
 			}
+			if version >= 5 {
+				if t.ReplicaAssignment[replica], err = pd.getCompactInt32Array(); err != nil {
+					return err
+				}
+				if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+					return err
+				}
+				continue
+			}
 			if t.ReplicaAssignment[replica], err = pd.getInt32Array(); err != nil {
 				return err
 			}
 		}
 	}
 
-	n, err = pd.getArrayLength()
+	if version >= 5 {
+		n, err = pd.getCompactArrayLength()
+	} else {
+		n, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
@@ -185,6 +273,19 @@ func (t *TopicDetail) Decode(pd packetDecoder, version int16) (err error) {
 	if n > 0 {
 		t.ConfigEntries = make(map[string]*string, n)
 		for i := 0; i < n; i++ {
+			if version >= 5 {
+				configKey, err := pd.getCompactString()
+				if err != nil {
+					return err
+				}
+				if t.ConfigEntries[configKey], err = pd.getCompactNullableString(); err != nil {
+					return err
+				}
+				if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+					return err
+				}
+				continue
+			}
 			configKey, err := pd.getString()
 			if err != nil {
 				return err
@@ -195,5 +296,11 @@ func (t *TopicDetail) Decode(pd packetDecoder, version int16) (err error) {
 		}
 	}
 
+	if version >= 5 {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
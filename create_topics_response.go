@@ -13,6 +13,32 @@ type CreateTopicsResponse struct {
 	ThrottleTime time.Duration
 	// TopicErrors contains a map of any errors for the topics we tried to create.
 	TopicErrors map[string]*TopicError
+	// CreatedTopics contains the partition count, replication factor and
+	// configuration the broker applied to each topic (version 5 or later).
+	CreatedTopics map[string]*CreatedTopicDetail
+}
+
+// CreatedTopicDetail is the partition count, replication factor and
+// configuration the broker applied to a created topic.
+type CreatedTopicDetail struct {
+	// NumPartitions contains the number of partitions of the topic, or -1 if
+	// the topic was not created or the details are not available.
+	NumPartitions int32
+	// ReplicationFactor contains the replication factor of the topic, or -1 if
+	// the topic was not created or the details are not available.
+	ReplicationFactor int16
+	// Configs contains the configuration of the topic, or nil if the topic was
+	// not created or the details are not available.
+	Configs []*CreatedTopicConfig
+}
+
+// CreatedTopicConfig is a single configuration entry of a created topic.
+type CreatedTopicConfig struct {
+	Name      string
+	Value     *string
+	ReadOnly  bool
+	Source    ConfigSource
+	Sensitive bool
 }
 
 func (c *CreateTopicsResponse) Encode(pe packetEncoder) error {
@@ -20,16 +46,36 @@ func (c *CreateTopicsResponse) Encode(pe packetEncoder) error {
 		pe.putInt32(int32(c.ThrottleTime / time.Millisecond))
 	}
 
-	if err := pe.putArrayLength(len(c.TopicErrors)); err != nil {
+	if c.Version >= 5 {
+		pe.putCompactArrayLength(len(c.TopicErrors))
+	} else if err := pe.putArrayLength(len(c.TopicErrors)); err != nil {
 		return err
 	}
 	for topic, topicError := range c.TopicErrors {
-		if err := pe.putString(topic); err != nil {
+		if c.Version >= 5 {
+			if err := pe.putCompactString(topic); err != nil {
+				return err
+			}
+		} else if err := pe.putString(topic); err != nil {
 			return err
 		}
 		if err := topicError.encode(pe, c.Version); err != nil {
 			return err
 		}
+		if c.Version >= 5 {
+			detail := c.CreatedTopics[topic]
+			if detail == nil {
+				detail = &CreatedTopicDetail{NumPartitions: -1, ReplicationFactor: -1}
+			}
+			if err := detail.encode(pe); err != nil {
+				return err
+			}
+			pe.putEmptyTaggedFieldArray()
+		}
+	}
+
+	if c.Version >= 5 {
+		pe.putEmptyTaggedFieldArray()
 	}
 
 	return nil
@@ -46,14 +92,27 @@ func (c *CreateTopicsResponse) Decode(pd packetDecoder, version int16) (err erro
 		c.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
 	}
 
-	n, err := pd.getArrayLength()
+	var n int
+	if version >= 5 {
+		n, err = pd.getCompactArrayLength()
+	} else {
+		n, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
 
 	c.TopicErrors = make(map[string]*TopicError, n)
+	if version >= 5 {
+		c.CreatedTopics = make(map[string]*CreatedTopicDetail, n)
+	}
 	for i := 0; i < n; i++ {
-		topic, err := pd.getString()
+		var topic string
+		if version >= 5 {
+			topic, err = pd.getCompactString()
+		} else {
+			topic, err = pd.getString()
+		}
 		if err != nil {
 			return err
 		}
@@ -61,6 +120,21 @@ func (c *CreateTopicsResponse) Decode(pd packetDecoder, version int16) (err erro
 		if err := c.TopicErrors[topic].Decode(pd, version); err != nil {
 			return err
 		}
+		if version >= 5 {
+			c.CreatedTopics[topic] = new(CreatedTopicDetail)
+			if err := c.CreatedTopics[topic].decode(pd); err != nil {
+				return err
+			}
+			if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if version >= 5 {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -74,16 +148,25 @@ func (c *CreateTopicsResponse) APIVersion() int16 {
 	return c.Version
 }
 
+func (c *CreateTopicsResponse) setVersion(v int16) {
+	c.Version = v
+}
+
 func (c *CreateTopicsResponse) HeaderVersion() int16 {
+	if c.Version >= 5 {
+		return 1
+	}
 	return 0
 }
 
 func (c *CreateTopicsResponse) IsValidVersion() bool {
-	return c.Version >= 0 && c.Version <= 3
+	return c.Version >= 0 && c.Version <= 5
 }
 
 func (c *CreateTopicsResponse) RequiredVersion() KafkaVersion {
 	switch c.Version {
+	case 5, 4:
+		return V2_4_0_0
 	case 3:
 		return V2_0_0_0
 	case 2:
@@ -121,7 +204,11 @@ func (t *TopicError) Unwrap() error {
 func (t *TopicError) encode(pe packetEncoder, version int16) error {
 	pe.putInt16(int16(t.Err))
 
-	if version >= 1 {
+	if version >= 5 {
+		if err := pe.putNullableCompactString(t.ErrMsg); err != nil {
+			return err
+		}
+	} else if version >= 1 {
 		if err := pe.putNullableString(t.ErrMsg); err != nil {
 			return err
 		}
@@ -137,7 +224,11 @@ func (t *TopicError) Decode(pd packetDecoder, version int16) (err error) {
 	}
 	t.Err = KError(kErr)
 
-	if version >= 1 {
+	if version >= 5 {
+		if t.ErrMsg, err = pd.getCompactNullableString(); err != nil {
+			return err
+		}
+	} else if version >= 1 {
 		if t.ErrMsg, err = pd.getNullableString(); err != nil {
 			return err
 		}
@@ -145,3 +236,70 @@ func (t *TopicError) Decode(pd packetDecoder, version int16) (err error) {
 
 	return nil
 }
+
+func (d *CreatedTopicDetail) encode(pe packetEncoder) error {
+	pe.putInt32(d.NumPartitions)
+	pe.putInt16(d.ReplicationFactor)
+
+	if d.Configs == nil {
+		pe.putCompactArrayLength(-1)
+		return nil
+	}
+	pe.putCompactArrayLength(len(d.Configs))
+	for _, config := range d.Configs {
+		if err := pe.putCompactString(config.Name); err != nil {
+			return err
+		}
+		if err := pe.putNullableCompactString(config.Value); err != nil {
+			return err
+		}
+		pe.putBool(config.ReadOnly)
+		pe.putInt8(int8(config.Source))
+		pe.putBool(config.Sensitive)
+		pe.putEmptyTaggedFieldArray()
+	}
+	return nil
+}
+
+func (d *CreatedTopicDetail) decode(pd packetDecoder) (err error) {
+	if d.NumPartitions, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if d.ReplicationFactor, err = pd.getInt16(); err != nil {
+		return err
+	}
+
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n <= 0 {
+		return nil
+	}
+	d.Configs = make([]*CreatedTopicConfig, n)
+	for i := 0; i < n; i++ {
+		config := &CreatedTopicConfig{}
+		if config.Name, err = pd.getCompactString(); err != nil {
+			return err
+		}
+		if config.Value, err = pd.getCompactNullableString(); err != nil {
+			return err
+		}
+		if config.ReadOnly, err = pd.getBool(); err != nil {
+			return err
+		}
+		source, err := pd.getInt8()
+		if err != nil {
+			return err
+		}
+		config.Source = ConfigSource(source)
+		if config.Sensitive, err = pd.getBool(); err != nil {
+			return err
+		}
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+		d.Configs[i] = config
+	}
+	return nil
+}
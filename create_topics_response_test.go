@@ -27,6 +27,25 @@ var (
 		0, 42,
 		0, 3, 'm', 's', 'g',
 	}
+
+	createTopicsResponseV5 = []byte{
+		0, 0, 0, 100,
+		2,
+		6, 't', 'o', 'p', 'i', 'c',
+		0, 0,
+		0, // null error message
+		0, 0, 0, 3,
+		0, 2,
+		2, // 1 config
+		13, 'r', 'e', 't', 'e', 'n', 't', 'i', 'o', 'n', '.', 'm', 's',
+		3, '-', '1',
+		0, // read only
+		1, // source topic
+		0, // sensitive
+		0, // empty config tagged fields
+		0, // empty topic tagged fields
+		0, // empty tagged fields
+	}
 )
 
 func TestCreateTopicsResponse(t *testing.T) {
@@ -52,6 +71,28 @@ func TestCreateTopicsResponse(t *testing.T) {
 	testResponse(t, "version 2", resp, createTopicsResponseV2)
 }
 
+func TestCreateTopicsResponseV5(t *testing.T) {
+	retention := "-1"
+	resp := &CreateTopicsResponse{
+		Version:      5,
+		ThrottleTime: 100 * time.Millisecond,
+		TopicErrors: map[string]*TopicError{
+			"topic": {Err: ErrNoError},
+		},
+		CreatedTopics: map[string]*CreatedTopicDetail{
+			"topic": {
+				NumPartitions:     3,
+				ReplicationFactor: 2,
+				Configs: []*CreatedTopicConfig{
+					{Name: "retention.ms", Value: &retention, Source: SourceTopic},
+				},
+			},
+		},
+	}
+
+	testResponse(t, "version 5", resp, createTopicsResponseV5)
+}
+
 func TestTopicError(t *testing.T) {
 	// Assert that TopicError satisfies error interface
 	var err error = &TopicError{
@@ -22,6 +22,10 @@ func (r *DeleteGroupsRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *DeleteGroupsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *DeleteGroupsRequest) HeaderVersion() int16 {
 	return 1
 }
@@ -78,6 +78,10 @@ func (d *DeleteRecordsRequest) APIVersion() int16 {
 	return d.Version
 }
 
+func (d *DeleteRecordsRequest) setVersion(v int16) {
+	d.Version = v
+}
+
 func (d *DeleteRecordsRequest) HeaderVersion() int16 {
 	return 1
 }
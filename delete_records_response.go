@@ -80,6 +80,10 @@ func (d *DeleteRecordsResponse) APIVersion() int16 {
 	return d.Version
 }
 
+func (d *DeleteRecordsResponse) setVersion(v int16) {
+	d.Version = v
+}
+
 func (d *DeleteRecordsResponse) HeaderVersion() int16 {
 	return 0
 }
@@ -68,6 +68,10 @@ func (d *DeleteTopicsResponse) APIVersion() int16 {
 	return d.Version
 }
 
+func (d *DeleteTopicsResponse) setVersion(v int16) {
+	d.Version = v
+}
+
 func (d *DeleteTopicsResponse) HeaderVersion() int16 {
 	return 0
 }
@@ -133,6 +133,10 @@ func (d *DescribeClientQuotasRequest) APIVersion() int16 {
 	return d.Version
 }
 
+func (d *DescribeClientQuotasRequest) setVersion(v int16) {
+	d.Version = v
+}
+
 func (d *DescribeClientQuotasRequest) HeaderVersion() int16 {
 	return 1
 }
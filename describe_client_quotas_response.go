@@ -227,6 +227,10 @@ func (d *DescribeClientQuotasResponse) APIVersion() int16 {
 	return d.Version
 }
 
+func (d *DescribeClientQuotasResponse) setVersion(v int16) {
+	d.Version = v
+}
+
 func (d *DescribeClientQuotasResponse) HeaderVersion() int16 {
 	return 0
 }
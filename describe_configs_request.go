@@ -1,9 +1,12 @@
 package sarama
 
+import "errors"
+
 type DescribeConfigsRequest struct {
-	Version         int16
-	Resources       []*ConfigResource
-	IncludeSynonyms bool
+	Version              int16
+	Resources            []*ConfigResource
+	IncludeSynonyms      bool
+	IncludeDocumentation bool // version 3 or later
 }
 
 type ConfigResource struct {
@@ -12,13 +15,54 @@ type ConfigResource struct {
 	ConfigNames []string
 }
 
+// newDescribeConfigsRequest returns a DescribeConfigsRequest with the highest
+// version supported by the given Kafka version.
+func newDescribeConfigsRequest(version KafkaVersion) *DescribeConfigsRequest {
+	request := &DescribeConfigsRequest{}
+	if version.IsAtLeast(V2_8_0_0) {
+		// Version 4 is the first flexible version.
+		request.Version = 4
+	} else if version.IsAtLeast(V2_6_0_0) {
+		// Version 3 adds IncludeDocumentation and returns the config types (KIP-569).
+		request.Version = 3
+	} else if version.IsAtLeast(V2_0_0_0) {
+		// Version 2 is the same as version 1.
+		request.Version = 2
+	} else if version.IsAtLeast(V1_1_0_0) {
+		// Version 1 adds IncludeSynonyms and returns the config sources (KIP-226).
+		request.Version = 1
+	}
+	return request
+}
+
 func (r *DescribeConfigsRequest) Encode(pe packetEncoder) error {
-	if err := pe.putArrayLength(len(r.Resources)); err != nil {
+	if r.Version >= 4 {
+		pe.putCompactArrayLength(len(r.Resources))
+	} else if err := pe.putArrayLength(len(r.Resources)); err != nil {
 		return err
 	}
 
 	for _, c := range r.Resources {
 		pe.putInt8(int8(c.Type))
+
+		if r.Version >= 4 {
+			if err := pe.putCompactString(c.Name); err != nil {
+				return err
+			}
+			if len(c.ConfigNames) == 0 {
+				pe.putCompactArrayLength(-1)
+			} else {
+				pe.putCompactArrayLength(len(c.ConfigNames))
+				for _, name := range c.ConfigNames {
+					if err := pe.putCompactString(name); err != nil {
+						return err
+					}
+				}
+			}
+			pe.putEmptyTaggedFieldArray()
+			continue
+		}
+
 		if err := pe.putString(c.Name); err != nil {
 			return err
 		}
@@ -36,11 +80,24 @@ func (r *DescribeConfigsRequest) Encode(pe packetEncoder) error {
 		pe.putBool(r.IncludeSynonyms)
 	}
 
+	if r.Version >= 3 {
+		pe.putBool(r.IncludeDocumentation)
+	}
+
+	if r.Version >= 4 {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
 func (r *DescribeConfigsRequest) Decode(pd packetDecoder, version int16) (err error) {
-	n, err := pd.getArrayLength()
+	var n int
+	if version >= 4 {
+		n, err = pd.getCompactArrayLength()
+	} else {
+		n, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
@@ -54,6 +111,29 @@ func (r *DescribeConfigsRequest) Decode(pd packetDecoder, version int16) (err er
 			return err
 		}
 		r.Resources[i].Type = ConfigResourceType(t)
+
+		if version >= 4 {
+			if r.Resources[i].Name, err = pd.getCompactString(); err != nil {
+				return err
+			}
+			confLength, err := pd.getCompactArrayLength()
+			if err != nil {
+				return err
+			}
+			if confLength > 0 {
+				r.Resources[i].ConfigNames = make([]string, confLength)
+				for j := 0; j < confLength; j++ {
+					if r.Resources[i].ConfigNames[j], err = pd.getCompactString(); err != nil {
+						return err
+					}
+				}
+			}
+			if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+			continue
+		}
+
 		name, err := pd.getString()
 		if err != nil {
 			return err
@@ -88,6 +168,18 @@ func (r *DescribeConfigsRequest) Decode(pd packetDecoder, version int16) (err er
 		r.IncludeSynonyms = b
 	}
 
+	if r.Version >= 3 {
+		if r.IncludeDocumentation, err = pd.getBool(); err != nil {
+			return err
+		}
+	}
+
+	if r.Version >= 4 {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -99,16 +191,37 @@ func (r *DescribeConfigsRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *DescribeConfigsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *DescribeConfigsRequest) checkVersion(v int16) error {
+	switch {
+	case r.IncludeSynonyms && v < 1:
+		return errors.New("the synonyms require version 1")
+	case r.IncludeDocumentation && v < 3:
+		return errors.New("the documentation requires version 3")
+	}
+	return nil
+}
+
 func (r *DescribeConfigsRequest) HeaderVersion() int16 {
+	if r.Version >= 4 {
+		return 2
+	}
 	return 1
 }
 
 func (r *DescribeConfigsRequest) IsValidVersion() bool {
-	return r.Version >= 0 && r.Version <= 2
+	return r.Version >= 0 && r.Version <= 4
 }
 
 func (r *DescribeConfigsRequest) RequiredVersion() KafkaVersion {
 	switch r.Version {
+	case 4:
+		return V2_8_0_0
+	case 3:
+		return V2_6_0_0
 	case 2:
 		return V2_0_0_0
 	case 1:
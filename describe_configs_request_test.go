@@ -115,3 +115,54 @@ func TestDescribeConfigsRequestv1(t *testing.T) {
 
 	testRequest(t, "one topic, all configs", request, singleDescribeConfigsRequestAllConfigsv1)
 }
+
+func TestDescribeConfigsRequestv3(t *testing.T) {
+	request := &DescribeConfigsRequest{
+		Version: 3,
+		Resources: []*ConfigResource{
+			{
+				Type: TopicResource,
+				Name: "foo",
+			},
+		},
+		IncludeSynonyms:      true,
+		IncludeDocumentation: true,
+	}
+
+	testRequest(t, "one topic, all configs", request, append(singleDescribeConfigsRequestAllConfigsv1, 1))
+}
+
+func TestDescribeConfigsRequestv4(t *testing.T) {
+	request := &DescribeConfigsRequest{
+		Version: 4,
+		Resources: []*ConfigResource{
+			{
+				Type: TopicResource,
+				Name: "foo",
+			},
+			{
+				Type:        BrokerResource,
+				Name:        "1",
+				ConfigNames: []string{"segment.ms"},
+			},
+		},
+		IncludeSynonyms:      true,
+		IncludeDocumentation: true,
+	}
+
+	testRequest(t, "two resources", request, []byte{
+		3,                // 2 resources
+		2,                // a topic
+		4, 'f', 'o', 'o', // topic name: foo
+		0, // all configs
+		0, // empty tagged fields
+		4, // a broker
+		2, '1',
+		2, // 1 config name
+		11, 's', 'e', 'g', 'm', 'e', 'n', 't', '.', 'm', 's',
+		0, // empty tagged fields
+		1, // synonyms
+		1, // documentation
+		0, // empty tagged fields
+	})
+}
@@ -34,6 +34,49 @@ const (
 	SourceDefault
 )
 
+// ConfigType is the type of a config value, as returned by DescribeConfigs
+// version 3 or later.
+type ConfigType int8
+
+const (
+	ConfigTypeUnknown ConfigType = iota
+	ConfigTypeBoolean
+	ConfigTypeString
+	ConfigTypeInt
+	ConfigTypeShort
+	ConfigTypeLong
+	ConfigTypeDouble
+	ConfigTypeList
+	ConfigTypeClass
+	ConfigTypePassword
+)
+
+func (t ConfigType) String() string {
+	switch t {
+	case ConfigTypeUnknown:
+		return "Unknown"
+	case ConfigTypeBoolean:
+		return "Boolean"
+	case ConfigTypeString:
+		return "String"
+	case ConfigTypeInt:
+		return "Int"
+	case ConfigTypeShort:
+		return "Short"
+	case ConfigTypeLong:
+		return "Long"
+	case ConfigTypeDouble:
+		return "Double"
+	case ConfigTypeList:
+		return "List"
+	case ConfigTypeClass:
+		return "Class"
+	case ConfigTypePassword:
+		return "Password"
+	}
+	return fmt.Sprintf("ConfigType Invalid: %d", int(t))
+}
+
 type DescribeConfigsResponse struct {
 	Version      int16
 	ThrottleTime time.Duration
@@ -56,6 +99,10 @@ type ConfigEntry struct {
 	Source    ConfigSource
 	Sensitive bool
 	Synonyms  []*ConfigSynonym
+	// Type is the type of the value (version 3 or later).
+	Type ConfigType
+	// Documentation describes the config, if it was requested (version 3 or later).
+	Documentation string
 }
 
 type ConfigSynonym struct {
@@ -66,7 +113,9 @@ type ConfigSynonym struct {
 
 func (r *DescribeConfigsResponse) Encode(pe packetEncoder) (err error) {
 	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
-	if err = pe.putArrayLength(len(r.Resources)); err != nil {
+	if r.Version >= 4 {
+		pe.putCompactArrayLength(len(r.Resources))
+	} else if err = pe.putArrayLength(len(r.Resources)); err != nil {
 		return err
 	}
 
@@ -76,6 +125,10 @@ func (r *DescribeConfigsResponse) Encode(pe packetEncoder) (err error) {
 		}
 	}
 
+	if r.Version >= 4 {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
@@ -87,7 +140,12 @@ func (r *DescribeConfigsResponse) Decode(pd packetDecoder, version int16) (err e
 	}
 	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
 
-	n, err := pd.getArrayLength()
+	var n int
+	if version >= 4 {
+		n, err = pd.getCompactArrayLength()
+	} else {
+		n, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
@@ -101,6 +159,12 @@ func (r *DescribeConfigsResponse) Decode(pd packetDecoder, version int16) (err e
 		r.Resources[i] = rr
 	}
 
+	if version >= 4 {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -112,16 +176,27 @@ func (r *DescribeConfigsResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *DescribeConfigsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *DescribeConfigsResponse) HeaderVersion() int16 {
+	if r.Version >= 4 {
+		return 1
+	}
 	return 0
 }
 
 func (r *DescribeConfigsResponse) IsValidVersion() bool {
-	return r.Version >= 0 && r.Version <= 2
+	return r.Version >= 0 && r.Version <= 4
 }
 
 func (r *DescribeConfigsResponse) RequiredVersion() KafkaVersion {
 	switch r.Version {
+	case 4:
+		return V2_8_0_0
+	case 3:
+		return V2_6_0_0
 	case 2:
 		return V2_0_0_0
 	case 1:
@@ -140,18 +215,28 @@ func (r *DescribeConfigsResponse) throttleTime() time.Duration {
 func (r *ResourceResponse) encode(pe packetEncoder, version int16) (err error) {
 	pe.putInt16(r.ErrorCode)
 
-	if err = pe.putString(r.ErrorMsg); err != nil {
+	if version >= 4 {
+		if err = putNullableCompactStringIfEmpty(pe, r.ErrorMsg); err != nil {
+			return err
+		}
+	} else if err = pe.putString(r.ErrorMsg); err != nil {
 		return err
 	}
 
 	pe.putInt8(int8(r.Type))
 
-	if err = pe.putString(r.Name); err != nil {
-		return err
-	}
-
-	if err = pe.putArrayLength(len(r.Configs)); err != nil {
-		return err
+	if version >= 4 {
+		if err = pe.putCompactString(r.Name); err != nil {
+			return err
+		}
+		pe.putCompactArrayLength(len(r.Configs))
+	} else {
+		if err = pe.putString(r.Name); err != nil {
+			return err
+		}
+		if err = pe.putArrayLength(len(r.Configs)); err != nil {
+			return err
+		}
 	}
 
 	for _, c := range r.Configs {
@@ -159,6 +244,10 @@ func (r *ResourceResponse) encode(pe packetEncoder, version int16) (err error) {
 			return err
 		}
 	}
+
+	if version >= 4 {
+		pe.putEmptyTaggedFieldArray()
+	}
 	return nil
 }
 
@@ -169,11 +258,17 @@ func (r *ResourceResponse) Decode(pd packetDecoder, version int16) (err error) {
 	}
 	r.ErrorCode = ec
 
-	em, err := pd.getString()
-	if err != nil {
-		return err
+	if version >= 4 {
+		if r.ErrorMsg, err = getCompactNullableStringOrEmpty(pd); err != nil {
+			return err
+		}
+	} else {
+		em, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		r.ErrorMsg = em
 	}
-	r.ErrorMsg = em
 
 	t, err := pd.getInt8()
 	if err != nil {
@@ -181,13 +276,18 @@ func (r *ResourceResponse) Decode(pd packetDecoder, version int16) (err error) {
 	}
 	r.Type = ConfigResourceType(t)
 
-	name, err := pd.getString()
-	if err != nil {
-		return err
+	var n int
+	if version >= 4 {
+		if r.Name, err = pd.getCompactString(); err != nil {
+			return err
+		}
+		n, err = pd.getCompactArrayLength()
+	} else {
+		if r.Name, err = pd.getString(); err != nil {
+			return err
+		}
+		n, err = pd.getArrayLength()
 	}
-	r.Name = name
-
-	n, err := pd.getArrayLength()
 	if err != nil {
 		return err
 	}
@@ -200,16 +300,30 @@ func (r *ResourceResponse) Decode(pd packetDecoder, version int16) (err error) {
 		}
 		r.Configs[i] = c
 	}
+
+	if version >= 4 {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func (r *ConfigEntry) encode(pe packetEncoder, version int16) (err error) {
-	if err = pe.putString(r.Name); err != nil {
-		return err
-	}
-
-	if err = pe.putString(r.Value); err != nil {
-		return err
+	if version >= 4 {
+		if err = pe.putCompactString(r.Name); err != nil {
+			return err
+		}
+		if err = putNullableCompactStringIfEmpty(pe, r.Value); err != nil {
+			return err
+		}
+	} else {
+		if err = pe.putString(r.Name); err != nil {
+			return err
+		}
+		if err = pe.putString(r.Value); err != nil {
+			return err
+		}
 	}
 
 	pe.putBool(r.ReadOnly)
@@ -217,18 +331,39 @@ func (r *ConfigEntry) encode(pe packetEncoder, version int16) (err error) {
 	if version <= 0 {
 		pe.putBool(r.Default)
 		pe.putBool(r.Sensitive)
-	} else {
-		pe.putInt8(int8(r.Source))
-		pe.putBool(r.Sensitive)
+		return nil
+	}
+
+	pe.putInt8(int8(r.Source))
+	pe.putBool(r.Sensitive)
 
-		if err := pe.putArrayLength(len(r.Synonyms)); err != nil {
+	if version >= 4 {
+		pe.putCompactArrayLength(len(r.Synonyms))
+	} else if err := pe.putArrayLength(len(r.Synonyms)); err != nil {
+		return err
+	}
+	for _, c := range r.Synonyms {
+		if err = c.encode(pe, version); err != nil {
 			return err
 		}
-		for _, c := range r.Synonyms {
-			if err = c.encode(pe, version); err != nil {
-				return err
-			}
+	}
+
+	if version >= 3 {
+		pe.putInt8(int8(r.Type))
+		if version >= 4 {
+			err = putNullableCompactStringIfEmpty(pe, r.Documentation)
+		} else if r.Documentation == "" {
+			err = pe.putNullableString(nil)
+		} else {
+			err = pe.putString(r.Documentation)
 		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if version >= 4 {
+		pe.putEmptyTaggedFieldArray()
 	}
 
 	return nil
@@ -239,17 +374,22 @@ func (r *ConfigEntry) Decode(pd packetDecoder, version int16) (err error) {
 	if version == 0 {
 		r.Source = SourceUnknown
 	}
-	name, err := pd.getString()
-	if err != nil {
-		return err
-	}
-	r.Name = name
 
-	value, err := pd.getString()
-	if err != nil {
-		return err
+	if version >= 4 {
+		if r.Name, err = pd.getCompactString(); err != nil {
+			return err
+		}
+		if r.Value, err = getCompactNullableStringOrEmpty(pd); err != nil {
+			return err
+		}
+	} else {
+		if r.Name, err = pd.getString(); err != nil {
+			return err
+		}
+		if r.Value, err = pd.getString(); err != nil {
+			return err
+		}
 	}
-	r.Value = value
 
 	read, err := pd.getBool()
 	if err != nil {
@@ -282,7 +422,12 @@ func (r *ConfigEntry) Decode(pd packetDecoder, version int16) (err error) {
 	r.Sensitive = sensitive
 
 	if version > 0 {
-		n, err := pd.getArrayLength()
+		var n int
+		if version >= 4 {
+			n, err = pd.getCompactArrayLength()
+		} else {
+			n, err = pd.getArrayLength()
+		}
 		if err != nil {
 			return err
 		}
@@ -296,42 +441,103 @@ func (r *ConfigEntry) Decode(pd packetDecoder, version int16) (err error) {
 			r.Synonyms[i] = s
 		}
 	}
+
+	if version >= 3 {
+		configType, err := pd.getInt8()
+		if err != nil {
+			return err
+		}
+		r.Type = ConfigType(configType)
+
+		if version >= 4 {
+			r.Documentation, err = getCompactNullableStringOrEmpty(pd)
+		} else {
+			r.Documentation, err = pd.getString()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if version >= 4 {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func (c *ConfigSynonym) encode(pe packetEncoder, version int16) (err error) {
-	err = pe.putString(c.ConfigName)
-	if err != nil {
-		return err
-	}
-
-	err = pe.putString(c.ConfigValue)
-	if err != nil {
-		return err
+	if version >= 4 {
+		if err = pe.putCompactString(c.ConfigName); err != nil {
+			return err
+		}
+		if err = putNullableCompactStringIfEmpty(pe, c.ConfigValue); err != nil {
+			return err
+		}
+	} else {
+		if err = pe.putString(c.ConfigName); err != nil {
+			return err
+		}
+		if err = pe.putString(c.ConfigValue); err != nil {
+			return err
+		}
 	}
 
 	pe.putInt8(int8(c.Source))
 
+	if version >= 4 {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
-func (c *ConfigSynonym) Decode(pd packetDecoder, version int16) error {
-	name, err := pd.getString()
-	if err != nil {
-		return err
-	}
-	c.ConfigName = name
-
-	value, err := pd.getString()
-	if err != nil {
-		return err
+func (c *ConfigSynonym) Decode(pd packetDecoder, version int16) (err error) {
+	if version >= 4 {
+		if c.ConfigName, err = pd.getCompactString(); err != nil {
+			return err
+		}
+		if c.ConfigValue, err = getCompactNullableStringOrEmpty(pd); err != nil {
+			return err
+		}
+	} else {
+		if c.ConfigName, err = pd.getString(); err != nil {
+			return err
+		}
+		if c.ConfigValue, err = pd.getString(); err != nil {
+			return err
+		}
 	}
-	c.ConfigValue = value
 
 	source, err := pd.getInt8()
 	if err != nil {
 		return err
 	}
 	c.Source = ConfigSource(source)
+
+	if version >= 4 {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// putNullableCompactStringIfEmpty encodes an empty string as null, which is how
+// brokers return nullable fields such as the values of sensitive configs.
+func putNullableCompactStringIfEmpty(pe packetEncoder, in string) error {
+	if in == "" {
+		return pe.putNullableCompactString(nil)
+	}
+	return pe.putCompactString(in)
+}
+
+// getCompactNullableStringOrEmpty decodes a null string as the empty string.
+func getCompactNullableStringOrEmpty(pd packetDecoder) (string, error) {
+	s, err := pd.getCompactNullableString()
+	if err != nil || s == nil {
+		return "", err
+	}
+	return *s, nil
+}
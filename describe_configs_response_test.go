@@ -75,6 +75,36 @@ var (
 		4, // Source
 	}
 
+	describeConfigsResponseWithDocumentationv3 = append(describeConfigsResponseWithSynonymv1,
+		5, // Type: Long
+		0, 4, 'd', 'o', 'c', 's',
+	)
+
+	describeConfigsResponseFlexiblev4 = []byte{
+		0, 0, 0, 0, // throttle
+		2,    // response
+		0, 0, // errorcode
+		0, // null error message
+		2, // topic
+		4, 'f', 'o', 'o',
+		2, // configs
+		11, 's', 'e', 'g', 'm', 'e', 'n', 't', '.', 'm', 's',
+		5, '1', '0', '0', '0',
+		0, // ReadOnly
+		4, // Source
+		0, // Sensitive
+		2, // 1 Synonym
+		15, 'l', 'o', 'g', '.', 's', 'e', 'g', 'm', 'e', 'n', 't', '.', 'm', 's',
+		5, '1', '0', '0', '0',
+		4, // Source
+		0, // empty synonym tagged fields
+		5, // Type: Long
+		0, // null documentation
+		0, // empty config tagged fields
+		0, // empty resource tagged fields
+		0, // empty tagged fields
+	}
+
 	describeConfigsResponseWithDefaultv1 = []byte{
 		0, 0, 0, 0, // throttle
 		0, 0, 0, 1, // response
@@ -273,3 +303,36 @@ func TestDescribeConfigsResponseWithDefaultv1(t *testing.T) {
 	}
 	testResponse(t, "response with error", response, describeConfigsResponseWithDefaultv1)
 }
+
+func TestDescribeConfigsResponseWithDocumentation(t *testing.T) {
+	response := &DescribeConfigsResponse{
+		Version: 3,
+		Resources: []*ResourceResponse{
+			{
+				Type: TopicResource,
+				Name: "foo",
+				Configs: []*ConfigEntry{
+					{
+						Name:   "segment.ms",
+						Value:  "1000",
+						Source: SourceStaticBroker,
+						Synonyms: []*ConfigSynonym{
+							{
+								ConfigName:  "log.segment.ms",
+								ConfigValue: "1000",
+								Source:      SourceStaticBroker,
+							},
+						},
+						Type:          ConfigTypeLong,
+						Documentation: "docs",
+					},
+				},
+			},
+		},
+	}
+	testResponse(t, "version 3", response, describeConfigsResponseWithDocumentationv3)
+
+	response.Version = 4
+	response.Resources[0].Configs[0].Documentation = ""
+	testResponse(t, "version 4", response, describeConfigsResponseFlexiblev4)
+}
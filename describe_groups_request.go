@@ -1,5 +1,7 @@
 package sarama
 
+import "errors"
+
 type DescribeGroupsRequest struct {
 	Version                     int16
 	Groups                      []string
@@ -38,6 +40,17 @@ func (r *DescribeGroupsRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *DescribeGroupsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *DescribeGroupsRequest) checkVersion(v int16) error {
+	if r.IncludeAuthorizedOperations && v < 3 {
+		return errors.New("the authorized operations require version 3")
+	}
+	return nil
+}
+
 func (r *DescribeGroupsRequest) HeaderVersion() int16 {
 	return 1
 }
@@ -61,6 +61,10 @@ func (r *DescribeGroupsResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *DescribeGroupsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *DescribeGroupsResponse) HeaderVersion() int16 {
 	return 0
 }
@@ -104,7 +108,8 @@ type GroupDescription struct {
 	State string
 	// ProtocolType contains the group protocol type, or the empty string.
 	ProtocolType string
-	// Protocol contains the group protocol data, or the empty string.
+	// Protocol contains the protocol chosen for the group, which is the name of
+	// the BalanceStrategy for the consumer protocol type, or the empty string.
 	Protocol string
 	// Members contains the group members.
 	Members map[string]*GroupMemberDescription
@@ -113,6 +118,47 @@ type GroupDescription struct {
 	AuthorizedOperations int32
 }
 
+// authorizedOperationsOmitted is the AuthorizedOperations value of groups
+// described without requesting authorized operations (or before version 3).
+const authorizedOperationsOmitted int32 = -2147483648
+
+// AuthorizedAclOperations returns the operations the client is authorized to
+// perform on the group, decoded from AuthorizedOperations. It returns nil if
+// they were not requested or not supported by the broker.
+func (gd *GroupDescription) AuthorizedAclOperations() []AclOperation {
+	if gd.Version < 3 || gd.AuthorizedOperations == authorizedOperationsOmitted {
+		return nil
+	}
+	operations := []AclOperation{}
+	for op := AclOperationUnknown; op <= AclOperationIdempotentWrite; op++ {
+		if gd.AuthorizedOperations&(1<<uint(op)) != 0 {
+			operations = append(operations, op)
+		}
+	}
+	return operations
+}
+
+// decodeConsumerMembers sets the Subscription and Assignment of the members of
+// a group with the consumer protocol type.
+func (gd *GroupDescription) decodeConsumerMembers() {
+	if gd.ProtocolType != ConsumerGroupProtocolType {
+		return
+	}
+	for _, member := range gd.Members {
+		subscription, err := member.GetMemberMetadata()
+		if err != nil {
+			Logger.Printf("failed to decode the metadata of member %s of group %s: %v\n", member.MemberId, gd.GroupId, err)
+		}
+		member.Subscription = subscription
+
+		assignment, err := member.GetMemberAssignment()
+		if err != nil {
+			Logger.Printf("failed to decode the assignment of member %s of group %s: %v\n", member.MemberId, gd.GroupId, err)
+		}
+		member.Assignment = assignment
+	}
+}
+
 func (gd *GroupDescription) encode(pe packetEncoder, version int16) (err error) {
 	gd.Version = version
 	pe.putInt16(gd.ErrorCode)
@@ -210,6 +256,15 @@ type GroupMemberDescription struct {
 	// MemberAssignment contains the current assignment provided by the group
 	// leader.
 	MemberAssignment []byte
+	// Subscription contains the decoded MemberMetadata of members of groups
+	// with the consumer protocol type, as described by
+	// ClusterAdmin.DescribeConsumerGroups. It includes the subscribed topics,
+	// user data, owned partitions and rack of the member.
+	Subscription *ConsumerGroupMemberMetadata
+	// Assignment contains the decoded MemberAssignment of members of groups
+	// with the consumer protocol type, as described by
+	// ClusterAdmin.DescribeConsumerGroups.
+	Assignment *ConsumerGroupMemberAssignment
 }
 
 func (gmd *GroupMemberDescription) encode(pe packetEncoder, version int16) (err error) {
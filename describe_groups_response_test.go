@@ -266,3 +266,65 @@ func TestDescribeGroupsResponseV1plus(t *testing.T) {
 		})
 	}
 }
+
+func TestGroupDescriptionAuthorizedAclOperations(t *testing.T) {
+	group := &GroupDescription{Version: 3, AuthorizedOperations: 1<<AclOperationRead | 1<<AclOperationDescribe}
+	assert.Equal(t, []AclOperation{AclOperationRead, AclOperationDescribe}, group.AuthorizedAclOperations())
+
+	group = &GroupDescription{Version: 3, AuthorizedOperations: authorizedOperationsOmitted}
+	assert.Nil(t, group.AuthorizedAclOperations())
+
+	group = &GroupDescription{Version: 2}
+	assert.Nil(t, group.AuthorizedAclOperations())
+}
+
+func TestGroupDescriptionDecodeConsumerMembers(t *testing.T) {
+	rack := "rack-1"
+	metadata, err := Encode(&ConsumerGroupMemberMetadata{
+		Version:         3,
+		Topics:          []string{"my-topic"},
+		UserData:        []byte{1, 2},
+		OwnedPartitions: []*OwnedPartition{{Topic: "my-topic", Partitions: []int32{0}}},
+		GenerationID:    4,
+		RackID:          &rack,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignment, err := Encode(&ConsumerGroupMemberAssignment{
+		Topics: map[string][]int32{"my-topic": {0, 1}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	group := &GroupDescription{
+		ProtocolType: ConsumerGroupProtocolType,
+		Members: map[string]*GroupMemberDescription{
+			"id": {MemberId: "id", MemberMetadata: metadata, MemberAssignment: assignment},
+		},
+	}
+	group.decodeConsumerMembers()
+
+	member := group.Members["id"]
+	if assert.NotNil(t, member.Subscription) {
+		assert.Equal(t, []string{"my-topic"}, member.Subscription.Topics)
+		assert.Equal(t, []byte{1, 2}, member.Subscription.UserData)
+		assert.Equal(t, []int32{0}, member.Subscription.OwnedPartitions[0].Partitions)
+		assert.Equal(t, &rack, member.Subscription.RackID)
+	}
+	if assert.NotNil(t, member.Assignment) {
+		assert.Equal(t, []int32{0, 1}, member.Assignment.Topics["my-topic"])
+	}
+
+	// other protocol types, e.g. connect, are left undecoded
+	other := &GroupDescription{
+		ProtocolType: "connect",
+		Members: map[string]*GroupMemberDescription{
+			"id": {MemberId: "id", MemberMetadata: metadata, MemberAssignment: assignment},
+		},
+	}
+	other.decodeConsumerMembers()
+	assert.Nil(t, other.Members["id"].Subscription)
+	assert.Nil(t, other.Members["id"].Assignment)
+}
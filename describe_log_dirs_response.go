@@ -61,6 +61,10 @@ func (r *DescribeLogDirsResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *DescribeLogDirsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *DescribeLogDirsResponse) HeaderVersion() int16 {
 	return 0
 }
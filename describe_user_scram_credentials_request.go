@@ -61,6 +61,10 @@ func (r *DescribeUserScramCredentialsRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *DescribeUserScramCredentialsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *DescribeUserScramCredentialsRequest) HeaderVersion() int16 {
 	return 2
 }
@@ -46,6 +46,10 @@ func (a *EndTxnRequest) APIVersion() int16 {
 	return a.Version
 }
 
+func (a *EndTxnRequest) setVersion(v int16) {
+	a.Version = v
+}
+
 func (r *EndTxnRequest) HeaderVersion() int16 {
 	return 1
 }
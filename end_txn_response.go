@@ -40,6 +40,10 @@ func (e *EndTxnResponse) APIVersion() int16 {
 	return e.Version
 }
 
+func (e *EndTxnResponse) setVersion(v int16) {
+	e.Version = v
+}
+
 func (r *EndTxnResponse) HeaderVersion() int16 {
 	return 0
 }
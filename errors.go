@@ -37,6 +37,14 @@ var ErrNotConnected = errors.New("kafka: broker not connected")
 // of the message set.
 var ErrInsufficientData = errors.New("kafka: insufficient data to decode packet, more bytes expected")
 
+// ErrInvalidAccessToken is the error returned when an OAuth access token received from a token endpoint is invalid,
+// e.g. because it has already expired.
+var ErrInvalidAccessToken = errors.New("kafka: invalid OAuth access token")
+
+// ErrClosedTokenProvider is the error returned when a token is requested from an AccessTokenProvider that has been
+// closed.
+var ErrClosedTokenProvider = errors.New("kafka: tried to use a token provider that was closed")
+
 // ErrShuttingDown is returned when a producer receives a message during shutdown.
 var ErrShuttingDown = errors.New("kafka: message received by producer in process of shutting down")
 
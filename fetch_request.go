@@ -1,6 +1,9 @@
 package sarama
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 type fetchRequestBlock struct {
 	Version int16
@@ -276,6 +279,20 @@ func (r *FetchRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *FetchRequest) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *FetchRequest) checkVersion(v int16) error {
+	if r.Isolation == ReadCommitted && v < 4 {
+		return errors.New("the read committed isolation level requires version 4")
+	}
+	if r.RackID != "" && v < 11 {
+		return errors.New("the rack ID requires version 11")
+	}
+	return nil
+}
+
 func (r *FetchRequest) HeaderVersion() int16 {
 	return 1
 }
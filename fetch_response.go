@@ -382,6 +382,10 @@ func (r *FetchResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *FetchResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *FetchResponse) HeaderVersion() int16 {
 	return 0
 }
@@ -1,5 +1,7 @@
 package sarama
 
+import "errors"
+
 type CoordinatorType int8
 
 const (
@@ -51,6 +53,17 @@ func (f *FindCoordinatorRequest) APIVersion() int16 {
 	return f.Version
 }
 
+func (f *FindCoordinatorRequest) setVersion(v int16) {
+	f.Version = v
+}
+
+func (f *FindCoordinatorRequest) checkVersion(v int16) error {
+	if f.CoordinatorType != CoordinatorGroup && v < 1 {
+		return errors.New("transaction coordinators require version 1")
+	}
+	return nil
+}
+
 func (r *FindCoordinatorRequest) HeaderVersion() int16 {
 	return 1
 }
@@ -82,6 +82,10 @@ func (f *FindCoordinatorResponse) APIVersion() int16 {
 	return f.Version
 }
 
+func (f *FindCoordinatorResponse) setVersion(v int16) {
+	f.Version = v
+}
+
 func (r *FindCoordinatorResponse) HeaderVersion() int16 {
 	return 0
 }
@@ -1,5 +1,7 @@
 package sarama
 
+import "errors"
+
 type HeartbeatRequest struct {
 	Version         int16
 	GroupId         string
@@ -56,6 +58,17 @@ func (r *HeartbeatRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *HeartbeatRequest) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *HeartbeatRequest) checkVersion(v int16) error {
+	if r.GroupInstanceId != nil && v < 3 {
+		return errors.New("the group instance ID requires version 3")
+	}
+	return nil
+}
+
 func (r *HeartbeatRequest) HeaderVersion() int16 {
 	return 1
 }
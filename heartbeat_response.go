@@ -41,6 +41,10 @@ func (r *HeartbeatResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *HeartbeatResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *HeartbeatResponse) HeaderVersion() int16 {
 	return 0
 }
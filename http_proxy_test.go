@@ -0,0 +1,259 @@
+package sarama
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testHTTPProxy is an in-process HTTP CONNECT proxy recording the addresses
+// it tunnels to.
+type testHTTPProxy struct {
+	listener      net.Listener
+	authorization string
+
+	lock    sync.Mutex
+	tunnels []string
+}
+
+func newTestHTTPProxy(t *testing.T, tlsConfig *tls.Config, user, password string) *testHTTPProxy {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	p := &testHTTPProxy{listener: listener}
+	if user != "" {
+		p.authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go p.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { _ = listener.Close() })
+	return p
+}
+
+func (p *testHTTPProxy) serve(conn net.Conn) {
+	defer conn.Close()
+	req, err := http.ReadRequest(bufio.NewReader(conn))
+	if err != nil {
+		return
+	}
+	if req.Method != http.MethodConnect {
+		_, _ = io.WriteString(conn, "HTTP/1.1 405 Method Not Allowed\r\n\r\n")
+		return
+	}
+	if req.Header.Get("Proxy-Authorization") != p.authorization {
+		_, _ = io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+		return
+	}
+	upstream, err := net.Dial("tcp", req.Host)
+	if err != nil {
+		_, _ = io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+		return
+	}
+	defer upstream.Close()
+	p.lock.Lock()
+	p.tunnels = append(p.tunnels, req.Host)
+	p.lock.Unlock()
+
+	if _, err := io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+		return
+	}
+	go func() {
+		_, _ = io.Copy(upstream, conn)
+		_ = upstream.Close()
+	}()
+	_, _ = io.Copy(conn, upstream)
+}
+
+func (p *testHTTPProxy) Tunnels() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return append([]string(nil), p.tunnels...)
+}
+
+func (p *testHTTPProxy) Addr() string {
+	return p.listener.Addr().String()
+}
+
+func TestHTTPProxy(t *testing.T) {
+	mockBroker := NewMockBroker(t, 0)
+	defer mockBroker.Close()
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t),
+	})
+
+	proxy := newTestHTTPProxy(t, nil, "user", "secret")
+
+	conf := NewTestConfig()
+	conf.Net.Proxy.Enable = true
+	conf.Net.Proxy.HTTP.URL = "http://user:secret@" + proxy.Addr()
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	safeClose(t, broker)
+
+	if tunnels := proxy.Tunnels(); len(tunnels) != 1 || tunnels[0] != mockBroker.Addr() {
+		t.Errorf("Expected a tunnel to %s, got %v", mockBroker.Addr(), tunnels)
+	}
+
+	// wrong credentials
+	conf.Net.Proxy.HTTP.URL = "http://user:wrong@" + proxy.Addr()
+	broker = NewBroker(mockBroker.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	if connected, err := broker.Connected(); connected || err == nil {
+		t.Errorf("Expected the proxy to refuse the connection, got %v %v", connected, err)
+	}
+
+	// per-broker override
+	conf.Net.Proxy.HTTP.Brokers = map[string]string{mockBroker.Addr(): ""}
+	broker = NewBroker(mockBroker.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	if connected, err := broker.Connected(); !connected || err != nil {
+		t.Errorf("Expected a direct connection, got %v %v", connected, err)
+	}
+	safeClose(t, broker)
+	if tunnels := proxy.Tunnels(); len(tunnels) != 1 {
+		t.Errorf("Expected no other tunnel, got %v", tunnels)
+	}
+}
+
+func TestHTTPSProxyWithTLSBroker(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "localhost"},
+		SerialNumber:          big.NewInt(1),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, &x509.Certificate{Subject: pkix.Name{CommonName: "localhost"}, SerialNumber: big.NewInt(1)}, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	serverConfig := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		MinVersion:   tls.VersionTLS12,
+	}
+	clientConfig := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockBroker := NewMockBrokerListener(t, 0, listener)
+	defer mockBroker.Close()
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t),
+	})
+
+	proxy := newTestHTTPProxy(t, serverConfig, "", "")
+
+	conf := NewTestConfig()
+	conf.Net.TLS.Enable = true
+	conf.Net.TLS.Config = clientConfig
+	conf.Net.Proxy.Enable = true
+	conf.Net.Proxy.HTTP.URL = "https://" + proxy.Addr()
+	conf.Net.Proxy.HTTP.TLS = clientConfig
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	safeClose(t, broker)
+
+	if tunnels := proxy.Tunnels(); len(tunnels) != 1 || tunnels[0] != mockBroker.Addr() {
+		t.Errorf("Expected a tunnel to %s, got %v", mockBroker.Addr(), tunnels)
+	}
+}
+
+func TestHTTPProxyConfig(t *testing.T) {
+	c := &HTTPProxyConfig{
+		URL:     "http://proxy:3128",
+		Brokers: map[string]string{"kafka-1:9092": "https://other-proxy"},
+		NoProxy: []string{"internal.example.com", ".corp", "10.0.0.0/8", "192.168.1.1", "kafka-2:9093"},
+	}
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+	for addr, expected := range map[string]string{
+		"kafka-1:9092":              "other-proxy",
+		"kafka-0:9092":              "proxy:3128",
+		"internal.example.com:9092": "",
+		"a.internal.example.com:1":  "",
+		"example.com:9092":          "proxy:3128",
+		"kafka.corp:9092":           "",
+		"corp:9092":                 "proxy:3128",
+		"10.1.2.3:9092":             "",
+		"192.168.1.1:9092":          "",
+		"192.168.1.2:9092":          "proxy:3128",
+		"kafka-2:9093":              "",
+		"kafka-2:9092":              "proxy:3128",
+	} {
+		proxyURL, err := c.proxyFor(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		host := ""
+		if proxyURL != nil {
+			host = proxyURL.Host
+		}
+		if host != expected {
+			t.Errorf("Expected proxy %q for %s, got %q", expected, addr, host)
+		}
+	}
+
+	for _, invalid := range []*HTTPProxyConfig{
+		{},
+		{URL: "socks5://proxy:1080"},
+		{URL: "http://proxy", Brokers: map[string]string{"kafka-1:9092": "http://"}},
+	} {
+		if err := invalid.validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", invalid)
+		}
+	}
+}
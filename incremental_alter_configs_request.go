@@ -165,6 +165,10 @@ func (a *IncrementalAlterConfigsRequest) APIVersion() int16 {
 	return a.Version
 }
 
+func (a *IncrementalAlterConfigsRequest) setVersion(v int16) {
+	a.Version = v
+}
+
 func (a *IncrementalAlterConfigsRequest) HeaderVersion() int16 {
 	return 1
 }
@@ -1,6 +1,9 @@
 package sarama
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 type InitProducerIDRequest struct {
 	Version            int16
@@ -76,6 +79,17 @@ func (i *InitProducerIDRequest) APIVersion() int16 {
 	return i.Version
 }
 
+func (i *InitProducerIDRequest) setVersion(v int16) {
+	i.Version = v
+}
+
+func (i *InitProducerIDRequest) checkVersion(v int16) error {
+	if i.Version >= 3 && i.ProducerID != noProducerID && v < 3 {
+		return errors.New("bumping the producer epoch requires version 3")
+	}
+	return nil
+}
+
 func (i *InitProducerIDRequest) HeaderVersion() int16 {
 	if i.Version >= 2 {
 		return 2
@@ -62,6 +62,10 @@ func (i *InitProducerIDResponse) APIVersion() int16 {
 	return i.Version
 }
 
+func (i *InitProducerIDResponse) setVersion(v int16) {
+	i.Version = v
+}
+
 func (i *InitProducerIDResponse) HeaderVersion() int16 {
 	if i.Version >= 2 {
 		return 1
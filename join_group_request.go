@@ -1,5 +1,7 @@
 package sarama
 
+import "errors"
+
 type GroupProtocol struct {
 	// Name contains the protocol name.
 	Name string
@@ -164,6 +166,17 @@ func (r *JoinGroupRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *JoinGroupRequest) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *JoinGroupRequest) checkVersion(v int16) error {
+	if r.GroupInstanceId != nil && v < 5 {
+		return errors.New("the group instance ID requires version 5")
+	}
+	return nil
+}
+
 func (r *JoinGroupRequest) HeaderVersion() int16 {
 	return 1
 }
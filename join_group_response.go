@@ -156,6 +156,10 @@ func (r *JoinGroupResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *JoinGroupResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *JoinGroupResponse) HeaderVersion() int16 {
 	return 0
 }
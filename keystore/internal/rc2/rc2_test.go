@@ -0,0 +1,57 @@
+package rc2
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	// test vectors of RFC 2268
+	for _, test := range []struct {
+		key, plain, cipher string
+		t1                 int
+	}{
+		{"0000000000000000", "0000000000000000", "ebb773f993278eff", 63},
+		{"ffffffffffffffff", "ffffffffffffffff", "278b27e42e2f0d49", 64},
+		{"3000000000000000", "1000000000000001", "30649edf9be7d2c2", 64},
+		{"88", "0000000000000000", "61a8a244adacccf0", 64},
+		{"88bca90e90875a", "0000000000000000", "6ccf4308974c267f", 64},
+		{"88bca90e90875a7f0f79c384627bafb2", "0000000000000000", "1a807d272bbe5db1", 64},
+		{"88bca90e90875a7f0f79c384627bafb2", "0000000000000000", "2269552ab0f85ca6", 128},
+	} {
+		key, _ := hex.DecodeString(test.key)
+		plain, _ := hex.DecodeString(test.plain)
+		expected, _ := hex.DecodeString(test.cipher)
+
+		block, err := New(key, test.t1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encrypted := make([]byte, BlockSize)
+		block.Encrypt(encrypted, plain)
+		if !bytes.Equal(encrypted, expected) {
+			t.Errorf("Expected %x for key %s, got %x", expected, test.key, encrypted)
+		}
+		decrypted := make([]byte, BlockSize)
+		block.Decrypt(decrypted, encrypted)
+		if !bytes.Equal(decrypted, plain) {
+			t.Errorf("Expected %x after decryption with key %s, got %x", plain, test.key, decrypted)
+		}
+	}
+}
+
+func TestInvalidKeyLength(t *testing.T) {
+	for _, test := range []struct {
+		keyLen, t1 int
+	}{
+		{0, 64},
+		{129, 64},
+		{8, 0},
+		{8, 1025},
+	} {
+		if _, err := New(make([]byte, test.keyLen), test.t1); err == nil {
+			t.Errorf("Expected an error for a %d bytes key with t1 %d", test.keyLen, test.t1)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+const (
+	jksMagic   = 0xfeedfeed
+	jceksMagic = 0xcececece
+
+	jksPrivateKeyTag  = 1
+	jksTrustedCertTag = 2
+)
+
+// oidJKSKeyProtector identifies the proprietary key protection algorithm of
+// the JKS format.
+var oidJKSKeyProtector = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 17, 1, 1}
+
+// jksReader reads the big-endian encoding of java.io.DataInputStream.
+type jksReader struct {
+	data []byte
+	off  int
+	err  error
+}
+
+func (r *jksReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.data)-r.off < n {
+		r.err = fmt.Errorf("%w: truncated JKS keystore", ErrInvalidKeystore)
+		return nil
+	}
+	b := r.data[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *jksReader) uint16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *jksReader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *jksReader) int64() int64 {
+	if b := r.bytes(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+// utf reads a string written by DataOutputStream.writeUTF. Aliases and
+// certificate types are ASCII in practice, so the modified UTF-8 encoding is
+// read as UTF-8.
+func (r *jksReader) utf() string {
+	return string(r.bytes(int(r.uint16())))
+}
+
+func (r *jksReader) certificate(version uint32) *x509.Certificate {
+	if version == 2 {
+		if certType := r.utf(); r.err == nil && certType != "X.509" {
+			r.err = fmt.Errorf("%w: unsupported certificate type %s", ErrInvalidKeystore, certType)
+		}
+	}
+	der := r.bytes(int(r.uint32()))
+	if r.err != nil {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		r.err = fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	return cert
+}
+
+// DecodeJKS decodes a keystore in the JKS format of keytool. keyPassword
+// decrypts the private keys, it defaults to password if empty.
+func DecodeJKS(data []byte, password, keyPassword string) (*Keystore, error) {
+	if keyPassword == "" {
+		keyPassword = password
+	}
+
+	r := &jksReader{data: data}
+	switch magic := r.uint32(); {
+	case r.err != nil:
+		return nil, r.err
+	case magic == jceksMagic:
+		return nil, fmt.Errorf("%w: JCEKS keystores are not supported", ErrInvalidKeystore)
+	case magic != jksMagic:
+		return nil, fmt.Errorf("%w: not a JKS keystore", ErrInvalidKeystore)
+	}
+	version := r.uint32()
+	if r.err == nil && version != 1 && version != 2 {
+		return nil, fmt.Errorf("%w: unsupported JKS version %d", ErrInvalidKeystore, version)
+	}
+
+	if len(data) < sha1.Size {
+		return nil, fmt.Errorf("%w: truncated JKS keystore", ErrInvalidKeystore)
+	}
+	content, digest := data[:len(data)-sha1.Size], data[len(data)-sha1.Size:]
+	if password != "" {
+		h := sha1.New()
+		h.Write(jksPassword(password))
+		h.Write([]byte("Mighty Aphrodite"))
+		h.Write(content)
+		if subtle.ConstantTimeCompare(h.Sum(nil), digest) != 1 {
+			return nil, ErrIncorrectPassword
+		}
+	}
+	r.data = content
+
+	ks := &Keystore{}
+	count := r.uint32()
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		tag := r.uint32()
+		alias := r.utf()
+		_ = r.int64() // creation date
+
+		switch tag {
+		case jksPrivateKeyTag:
+			protectedKey := r.bytes(int(r.uint32()))
+			// the chain length comes from the file, grow the chain as the
+			// certificates are read rather than trusting it
+			var chain []*x509.Certificate
+			for j, n := uint32(0), r.uint32(); j < n && r.err == nil; j++ {
+				chain = append(chain, r.certificate(version))
+			}
+			if r.err != nil {
+				break
+			}
+			key, err := decryptJKSKey(protectedKey, keyPassword)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt private key %q: %w", alias, err)
+			}
+			ks.keys = append(ks.keys, &PrivateKeyEntry{Alias: alias, PrivateKey: key, Chain: chain})
+		case jksTrustedCertTag:
+			cert := r.certificate(version)
+			if r.err == nil {
+				ks.certs = append(ks.certs, &TrustedCertificateEntry{Alias: alias, Certificate: cert})
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported JKS entry type %d", ErrInvalidKeystore, tag)
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return ks, nil
+}
+
+// decryptJKSKey decrypts a private key protected by the proprietary
+// algorithm of the JKS format: the key is XORed with a SHA-1 based keystream
+// seeded with a random salt, and followed by a SHA-1 checksum.
+func decryptJKSKey(data []byte, password string) (interface{}, error) {
+	var epki encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(data, &epki); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	if !epki.Algorithm.Algorithm.Equal(oidJKSKeyProtector) {
+		return nil, fmt.Errorf("%w: unsupported key protection algorithm %v", ErrInvalidKeystore, epki.Algorithm.Algorithm)
+	}
+	if len(epki.EncryptedData) < 2*sha1.Size {
+		return nil, fmt.Errorf("%w: truncated protected key", ErrInvalidKeystore)
+	}
+
+	pw := jksPassword(password)
+	salt := epki.EncryptedData[:sha1.Size]
+	encrypted := epki.EncryptedData[sha1.Size : len(epki.EncryptedData)-sha1.Size]
+	check := epki.EncryptedData[len(epki.EncryptedData)-sha1.Size:]
+
+	plain := make([]byte, len(encrypted))
+	digest := salt
+	for i := 0; i < len(encrypted); i += sha1.Size {
+		h := sha1.New()
+		h.Write(pw)
+		h.Write(digest)
+		digest = h.Sum(nil)
+		for j := 0; j < sha1.Size && i+j < len(encrypted); j++ {
+			plain[i+j] = encrypted[i+j] ^ digest[j]
+		}
+	}
+
+	h := sha1.New()
+	h.Write(pw)
+	h.Write(plain)
+	if !bytes.Equal(h.Sum(nil), check) {
+		return nil, ErrIncorrectPassword
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(plain)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	return key, nil
+}
+
+// jksPassword encodes a password like the UTF-16 char array of Java.
+func jksPassword(password string) []byte {
+	chars := utf16.Encode([]rune(password))
+	out := make([]byte, 0, 2*len(chars))
+	for _, c := range chars {
+		out = append(out, byte(c>>8), byte(c))
+	}
+	return out
+}
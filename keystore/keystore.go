@@ -0,0 +1,148 @@
+/*
+Package keystore loads the Java keystores and truststores used to configure
+TLS in Java Kafka clients, in the PKCS#12 and JKS formats, and builds a
+*tls.Config from them.
+
+The Config type mirrors the ssl.keystore.* and ssl.truststore.* settings of
+Java clients, so the same settings can drive both:
+
+	tlsConfig, err := (&keystore.Config{
+		KeystoreLocation:   "/etc/kafka/client.keystore.p12",
+		KeystorePassword:   "changeit",
+		TruststoreLocation: "/etc/kafka/client.truststore.jks",
+		TruststorePassword: "changeit",
+	}).TLSConfig()
+	if err != nil {
+		return err
+	}
+	config.Net.TLS.Enable = true
+	config.Net.TLS.Config = tlsConfig
+
+PKCS#12 keystores encrypted with PBES2 (AES or 3DES), as created by current
+versions of keytool and OpenSSL, and with the legacy PKCS#12 schemes
+(3DES and 40-bit RC2) are supported. JCEKS keystores are not supported.
+*/
+package keystore
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Keystore types, as used by the ssl.keystore.type and ssl.truststore.type
+// settings of Java clients.
+const (
+	TypePKCS12 = "PKCS12"
+	TypeJKS    = "JKS"
+)
+
+var (
+	// ErrInvalidKeystore is returned when a keystore cannot be decoded.
+	ErrInvalidKeystore = errors.New("keystore: invalid keystore")
+	// ErrIncorrectPassword is returned when the password of a keystore or of
+	// a private key is incorrect.
+	ErrIncorrectPassword = errors.New("keystore: incorrect password")
+	// ErrAliasNotFound is returned when a keystore has no entry with the
+	// requested alias.
+	ErrAliasNotFound = errors.New("keystore: alias not found")
+)
+
+// PrivateKeyEntry is a private key along with its certificate chain, leaf
+// certificate first.
+type PrivateKeyEntry struct {
+	Alias      string
+	PrivateKey crypto.PrivateKey
+	Chain      []*x509.Certificate
+}
+
+// TrustedCertificateEntry is a trusted certificate, usually of a CA.
+type TrustedCertificateEntry struct {
+	Alias       string
+	Certificate *x509.Certificate
+}
+
+// Keystore is a decoded keystore.
+type Keystore struct {
+	keys  []*PrivateKeyEntry
+	certs []*TrustedCertificateEntry
+}
+
+// Load reads and decodes the keystore at path. storeType is TypePKCS12 or
+// TypeJKS, or empty to detect the type from the content. keyPassword
+// decrypts the private keys, it defaults to password if empty.
+func Load(path, storeType, password, keyPassword string) (*Keystore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ks, err := Decode(data, storeType, password, keyPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load keystore %s: %w", path, err)
+	}
+	return ks, nil
+}
+
+// Decode decodes a keystore. storeType is TypePKCS12 or TypeJKS, or empty to
+// detect the type from the content. keyPassword decrypts the private keys, it
+// defaults to password if empty.
+func Decode(data []byte, storeType, password, keyPassword string) (*Keystore, error) {
+	switch strings.ToUpper(storeType) {
+	case TypePKCS12:
+		return DecodePKCS12(data, password, keyPassword)
+	case TypeJKS:
+		return DecodeJKS(data, password, keyPassword)
+	case "":
+		// PKCS#12 keystores are DER encoded, starting with a SEQUENCE
+		if len(data) > 0 && data[0] == 0x30 {
+			return DecodePKCS12(data, password, keyPassword)
+		}
+		return DecodeJKS(data, password, keyPassword)
+	default:
+		return nil, fmt.Errorf("%w: unsupported keystore type %s", ErrInvalidKeystore, storeType)
+	}
+}
+
+// PrivateKeys returns the private key entries of the keystore.
+func (ks *Keystore) PrivateKeys() []*PrivateKeyEntry {
+	return ks.keys
+}
+
+// TrustedCertificates returns the trusted certificate entries of the keystore.
+func (ks *Keystore) TrustedCertificates() []*TrustedCertificateEntry {
+	return ks.certs
+}
+
+// PrivateKey returns the private key entry with the given alias, or the only
+// private key entry of the keystore if alias is empty. Aliases are case
+// insensitive, as keytool lower-cases them.
+func (ks *Keystore) PrivateKey(alias string) (*PrivateKeyEntry, error) {
+	if alias == "" {
+		switch len(ks.keys) {
+		case 0:
+			return nil, fmt.Errorf("%w: the keystore contains no private key", ErrAliasNotFound)
+		case 1:
+			return ks.keys[0], nil
+		default:
+			return nil, fmt.Errorf("%w: the keystore contains %d private keys, an alias must be given", ErrAliasNotFound, len(ks.keys))
+		}
+	}
+	for _, entry := range ks.keys {
+		if strings.EqualFold(entry.Alias, alias) {
+			return entry, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no private key with alias %q", ErrAliasNotFound, alias)
+}
+
+// CertPool returns a pool of the trusted certificates of the keystore.
+func (ks *Keystore) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	for _, entry := range ks.certs {
+		pool.AddCert(entry.Certificate)
+	}
+	return pool
+}
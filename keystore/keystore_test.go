@@ -0,0 +1,281 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The PKCS#12 keystores in testdata were created with OpenSSL 3 from a
+// self-signed "Test CA" certificate and a "client" certificate with an EC key:
+//
+//	openssl pkcs12 -export -in client.pem -inkey client.key -certfile ca.pem -name client \
+//		-out client-aes.p12 -passout pass:changeit
+//	openssl pkcs12 -export -in client.pem -inkey client.key -certfile ca.pem -name client \
+//		-out client-3des.p12 -passout pass:changeit -certpbe PBE-SHA1-3DES -keypbe PBE-SHA1-3DES -macalg sha1
+//	openssl pkcs12 -export -legacy -in client.pem -inkey client.key -certfile ca.pem -name client \
+//		-out client-rc2.p12 -passout pass:changeit
+//	openssl pkcs12 -export -nokeys -in ca.pem -caname ca -out truststore.p12 -passout pass:changeit
+const testPassword = "changeit"
+
+func loadTestKeystore(t *testing.T, name string) *Keystore {
+	t.Helper()
+	ks, err := Load(filepath.Join("testdata", name), "", testPassword, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+func checkClientEntry(t *testing.T, entry *PrivateKeyEntry) {
+	t.Helper()
+	if entry.Alias != "client" {
+		t.Errorf("Expected alias client, got %q", entry.Alias)
+	}
+	if len(entry.Chain) != 2 || entry.Chain[0].Subject.CommonName != "client" || entry.Chain[1].Subject.CommonName != "Test CA" {
+		t.Fatalf("Expected the chain of client up to Test CA, got %v", entry.Chain)
+	}
+	key, ok := entry.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		t.Fatalf("Expected an EC private key, got %T", entry.PrivateKey)
+	}
+	if !key.PublicKey.Equal(entry.Chain[0].PublicKey) {
+		t.Error("Expected the private key to match the client certificate")
+	}
+}
+
+func TestDecodePKCS12(t *testing.T) {
+	for _, name := range []string{"client-aes.p12", "client-3des.p12", "client-rc2.p12"} {
+		t.Run(name, func(t *testing.T) {
+			ks := loadTestKeystore(t, name)
+			if len(ks.PrivateKeys()) != 1 {
+				t.Fatalf("Expected 1 private key, got %d", len(ks.PrivateKeys()))
+			}
+			checkClientEntry(t, ks.PrivateKeys()[0])
+			if len(ks.TrustedCertificates()) != 0 {
+				t.Errorf("Expected the CA to be part of the chain only, got %v", ks.TrustedCertificates())
+			}
+
+			data, err := os.ReadFile(filepath.Join("testdata", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := DecodePKCS12(data, "wrong", ""); !errors.Is(err, ErrIncorrectPassword) {
+				t.Errorf("Expected ErrIncorrectPassword, got %v", err)
+			}
+		})
+	}
+
+	ts := loadTestKeystore(t, "truststore.p12")
+	if len(ts.PrivateKeys()) != 0 || len(ts.TrustedCertificates()) != 1 {
+		t.Fatalf("Expected a single trusted certificate, got %v %v", ts.PrivateKeys(), ts.TrustedCertificates())
+	}
+	if entry := ts.TrustedCertificates()[0]; entry.Alias != "ca" || entry.Certificate.Subject.CommonName != "Test CA" {
+		t.Errorf("Unexpected trusted certificate %s %v", entry.Alias, entry.Certificate.Subject)
+	}
+}
+
+// encodeJKS encodes a version 2 JKS keystore, like keytool.
+func encodeJKS(t *testing.T, password string, keys []*PrivateKeyEntry, certs []*TrustedCertificateEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	write := func(v interface{}) {
+		if err := binary.Write(&buf, binary.BigEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeUTF := func(s string) {
+		write(uint16(len(s)))
+		buf.WriteString(s)
+	}
+	writeCert := func(cert *x509.Certificate) {
+		writeUTF("X.509")
+		write(uint32(len(cert.Raw)))
+		buf.Write(cert.Raw)
+	}
+
+	write(uint32(jksMagic))
+	write(uint32(2))
+	write(uint32(len(keys) + len(certs)))
+	for _, entry := range keys {
+		write(uint32(jksPrivateKeyTag))
+		writeUTF(entry.Alias)
+		write(int64(0))
+		protected := protectJKSKey(t, entry.PrivateKey, password)
+		write(uint32(len(protected)))
+		buf.Write(protected)
+		write(uint32(len(entry.Chain)))
+		for _, cert := range entry.Chain {
+			writeCert(cert)
+		}
+	}
+	for _, entry := range certs {
+		write(uint32(jksTrustedCertTag))
+		writeUTF(entry.Alias)
+		write(int64(0))
+		writeCert(entry.Certificate)
+	}
+
+	h := sha1.New()
+	h.Write(jksPassword(password))
+	h.Write([]byte("Mighty Aphrodite"))
+	h.Write(buf.Bytes())
+	buf.Write(h.Sum(nil))
+	return buf.Bytes()
+}
+
+func protectJKSKey(t *testing.T, key interface{}, password string) []byte {
+	t.Helper()
+	plain, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pw := jksPassword(password)
+	salt := bytes.Repeat([]byte{0x42}, sha1.Size)
+
+	protected := append([]byte{}, salt...)
+	digest := salt
+	for i := 0; i < len(plain); i += sha1.Size {
+		h := sha1.New()
+		h.Write(pw)
+		h.Write(digest)
+		digest = h.Sum(nil)
+		for j := 0; j < sha1.Size && i+j < len(plain); j++ {
+			protected = append(protected, plain[i+j]^digest[j])
+		}
+	}
+	h := sha1.New()
+	h.Write(pw)
+	h.Write(plain)
+	protected = append(protected, h.Sum(nil)...)
+
+	der, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidJKSKeyProtector, Parameters: asn1.NullRawValue},
+		EncryptedData: protected,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestDecodeJKS(t *testing.T) {
+	client := loadTestKeystore(t, "client-aes.p12").PrivateKeys()[0]
+	ca := client.Chain[1]
+
+	data := encodeJKS(t, testPassword,
+		[]*PrivateKeyEntry{client},
+		[]*TrustedCertificateEntry{{Alias: "ca", Certificate: ca}})
+
+	ks, err := Decode(data, "", testPassword, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ks.PrivateKeys()) != 1 || len(ks.TrustedCertificates()) != 1 {
+		t.Fatalf("Expected a private key and a trusted certificate, got %v %v", ks.PrivateKeys(), ks.TrustedCertificates())
+	}
+	checkClientEntry(t, ks.PrivateKeys()[0])
+	if entry := ks.TrustedCertificates()[0]; entry.Alias != "ca" || !entry.Certificate.Equal(ca) {
+		t.Errorf("Unexpected trusted certificate %s %v", entry.Alias, entry.Certificate.Subject)
+	}
+
+	if _, err := DecodeJKS(data, "wrong", ""); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("Expected ErrIncorrectPassword, got %v", err)
+	}
+	// the integrity of the keystore is not checked without a password
+	if _, err := DecodeJKS(data, "", testPassword); err != nil {
+		t.Errorf("Expected no error without a keystore password, got %v", err)
+	}
+	if _, err := DecodeJKS(data, testPassword, "wrong"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("Expected ErrIncorrectPassword for the key password, got %v", err)
+	}
+	if _, err := DecodeJKS(data[:len(data)-30], testPassword, ""); err == nil {
+		t.Error("Expected an error for a truncated keystore")
+	}
+	// a huge chain length is not trusted without a password either
+	var crafted bytes.Buffer
+	for _, v := range []interface{}{uint32(jksMagic), uint32(2), uint32(1), uint32(jksPrivateKeyTag),
+		uint16(1), byte('a'), int64(0), uint32(0), uint32(0xffffffff), [sha1.Size]byte{}} {
+		if err := binary.Write(&crafted, binary.BigEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := DecodeJKS(crafted.Bytes(), "", ""); !errors.Is(err, ErrInvalidKeystore) {
+		t.Errorf("Expected ErrInvalidKeystore for a huge certificate chain, got %v", err)
+	}
+
+	if _, err := Decode(data, TypePKCS12, testPassword, ""); !errors.Is(err, ErrInvalidKeystore) {
+		t.Errorf("Expected ErrInvalidKeystore when decoding a JKS keystore as PKCS12, got %v", err)
+	}
+}
+
+func TestKeystorePrivateKey(t *testing.T) {
+	client := loadTestKeystore(t, "client-aes.p12").PrivateKeys()[0]
+	other := &PrivateKeyEntry{Alias: "other", PrivateKey: client.PrivateKey, Chain: client.Chain}
+	ks := &Keystore{keys: []*PrivateKeyEntry{client, other}}
+
+	if entry, err := ks.PrivateKey("CLIENT"); err != nil || entry != client {
+		t.Errorf("Expected the client entry, got %v %v", entry, err)
+	}
+	if _, err := ks.PrivateKey(""); !errors.Is(err, ErrAliasNotFound) {
+		t.Errorf("Expected ErrAliasNotFound without alias, got %v", err)
+	}
+	if _, err := ks.PrivateKey("missing"); !errors.Is(err, ErrAliasNotFound) {
+		t.Errorf("Expected ErrAliasNotFound, got %v", err)
+	}
+}
+
+func TestConfigTLSConfig(t *testing.T) {
+	client := loadTestKeystore(t, "client-aes.p12").PrivateKeys()[0]
+	truststore := filepath.Join(t.TempDir(), "truststore.jks")
+	err := os.WriteFile(truststore, encodeJKS(t, testPassword, nil,
+		[]*TrustedCertificateEntry{{Alias: "ca", Certificate: client.Chain[1]}}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tlsConfig, err := (&Config{
+		KeystoreLocation:   filepath.Join("testdata", "client-3des.p12"),
+		KeystoreType:       "pkcs12",
+		KeystorePassword:   testPassword,
+		KeyAlias:           "client",
+		TruststoreLocation: truststore,
+		TruststorePassword: testPassword,
+	}).TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tlsConfig.Certificates) != 1 || len(tlsConfig.Certificates[0].Certificate) != 2 {
+		t.Fatalf("Expected the client certificate chain, got %v", tlsConfig.Certificates)
+	}
+	leaf := tlsConfig.Certificates[0].Leaf
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:     tlsConfig.RootCAs,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		t.Errorf("Expected the client certificate to be trusted by the truststore: %v", err)
+	}
+
+	if _, err := (&Config{
+		TruststoreLocation: filepath.Join("testdata", "client-aes.p12"),
+		TruststorePassword: testPassword,
+	}).TLSConfig(); !errors.Is(err, ErrInvalidKeystore) {
+		t.Errorf("Expected ErrInvalidKeystore for a truststore without trusted certificates, got %v", err)
+	}
+	if _, err := (&Config{
+		KeystoreLocation: filepath.Join("testdata", "client-aes.p12"),
+		KeystorePassword: testPassword,
+		KeyAlias:         "server",
+	}).TLSConfig(); !errors.Is(err, ErrAliasNotFound) {
+		t.Errorf("Expected ErrAliasNotFound, got %v", err)
+	}
+}
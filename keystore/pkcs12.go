@@ -0,0 +1,546 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"hash"
+	"math/big"
+	"unicode/utf16"
+
+	"github.com/kcore-io/sarama/keystore/internal/rc2"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var (
+	oidDataContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEncryptedDataContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+
+	oidKeyBag              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 1}
+	oidPKCS8ShroudedKeyBag = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidX509Certificate     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+
+	oidFriendlyName = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidLocalKeyID   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+
+	oidPBEWithSHAAnd3KeyTripleDESCBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	oidPBEWithSHAAnd40BitRC2CBC      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 6}
+	oidPBES2                         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2                        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1                  = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256                = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA512                = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC                     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC                     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC                     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC                    = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+
+	oidSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+// The structures below follow RFC 7292 (PKCS #12), RFC 2315 (PKCS #7) and
+// RFC 8018 (PKCS #5).
+
+type pfxPdu struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type encryptedData struct {
+	Version              int
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           []byte `asn1:"tag:0,optional"`
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue     `asn1:"tag:0,explicit"`
+	Attributes []pkcs12Attribute `asn1:"set,optional"`
+}
+
+type pkcs12Attribute struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+type certBag struct {
+	ID   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int                      `asn1:"optional"`
+	PRF        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// pkcs12Bag is a decoded key or certificate bag with its attributes.
+type pkcs12Bag struct {
+	alias      string
+	localKeyID []byte
+	key        interface{}
+	cert       *x509.Certificate
+}
+
+// DecodePKCS12 decodes a PKCS#12 keystore, such as the ones created by
+// openssl pkcs12 -export or by keytool with -storetype PKCS12. keyPassword
+// decrypts the private keys, it defaults to password if empty.
+func DecodePKCS12(data []byte, password, keyPassword string) (*Keystore, error) {
+	if keyPassword == "" {
+		keyPassword = password
+	}
+
+	var pfx pfxPdu
+	if rest, err := asn1.Unmarshal(data, &pfx); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing data", ErrInvalidKeystore)
+	}
+	if pfx.Version != 3 {
+		return nil, fmt.Errorf("%w: unsupported PKCS#12 version %d", ErrInvalidKeystore, pfx.Version)
+	}
+	if !pfx.AuthSafe.ContentType.Equal(oidDataContentType) {
+		return nil, fmt.Errorf("%w: only password-protected PKCS#12 keystores are supported", ErrInvalidKeystore)
+	}
+
+	var authSafeData []byte
+	if _, err := asn1.Unmarshal(pfx.AuthSafe.Content.Bytes, &authSafeData); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	if len(pfx.MacData.Mac.Algorithm.Algorithm) > 0 {
+		if err := verifyPKCS12MAC(&pfx.MacData, authSafeData, password); err != nil {
+			return nil, err
+		}
+	}
+
+	var authSafe []contentInfo
+	if _, err := asn1.Unmarshal(authSafeData, &authSafe); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+
+	var bags []*pkcs12Bag
+	for _, ci := range authSafe {
+		var safeContents []byte
+		switch {
+		case ci.ContentType.Equal(oidDataContentType):
+			if _, err := asn1.Unmarshal(ci.Content.Bytes, &safeContents); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+			}
+		case ci.ContentType.Equal(oidEncryptedDataContentType):
+			var ed encryptedData
+			if _, err := asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+			}
+			var err error
+			safeContents, err = pbeDecrypt(ed.EncryptedContentInfo.ContentEncryptionAlgorithm,
+				ed.EncryptedContentInfo.EncryptedContent, password)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported content type %v", ErrInvalidKeystore, ci.ContentType)
+		}
+
+		var safeBags []safeBag
+		if _, err := asn1.Unmarshal(safeContents, &safeBags); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+		}
+		for i := range safeBags {
+			bag, err := decodeSafeBag(&safeBags[i], keyPassword)
+			if err != nil {
+				return nil, err
+			}
+			if bag != nil {
+				bags = append(bags, bag)
+			}
+		}
+	}
+
+	return newPKCS12Keystore(bags)
+}
+
+func decodeSafeBag(sb *safeBag, keyPassword string) (*pkcs12Bag, error) {
+	bag := &pkcs12Bag{}
+	for _, attr := range sb.Attributes {
+		var value asn1.RawValue
+		if _, err := asn1.Unmarshal(attr.Value.Bytes, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+		}
+		switch {
+		case attr.ID.Equal(oidFriendlyName):
+			alias, err := decodeBMPString(value.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			bag.alias = alias
+		case attr.ID.Equal(oidLocalKeyID):
+			bag.localKeyID = value.Bytes
+		}
+	}
+
+	var err error
+	switch {
+	case sb.ID.Equal(oidKeyBag):
+		bag.key, err = x509.ParsePKCS8PrivateKey(sb.Value.Bytes)
+	case sb.ID.Equal(oidPKCS8ShroudedKeyBag):
+		var epki encryptedPrivateKeyInfo
+		if _, err := asn1.Unmarshal(sb.Value.Bytes, &epki); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+		}
+		var pkcs8 []byte
+		if pkcs8, err = pbeDecrypt(epki.Algorithm, epki.EncryptedData, keyPassword); err != nil {
+			return nil, err
+		}
+		bag.key, err = x509.ParsePKCS8PrivateKey(pkcs8)
+	case sb.ID.Equal(oidCertBag):
+		var cb certBag
+		if _, err := asn1.Unmarshal(sb.Value.Bytes, &cb); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+		}
+		if !cb.ID.Equal(oidX509Certificate) {
+			// e.g. SDSI certificates, which cannot be used for TLS
+			return nil, nil
+		}
+		bag.cert, err = x509.ParseCertificate(cb.Data)
+	default:
+		// e.g. CRL or secret bags, which cannot be used for TLS
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	return bag, nil
+}
+
+// newPKCS12Keystore matches the keys with their certificate chains. The
+// certificate of a key has the same local key ID as the key, and all other
+// certificates are either part of a chain or trusted certificates.
+func newPKCS12Keystore(bags []*pkcs12Bag) (*Keystore, error) {
+	var certs []*x509.Certificate
+	for _, bag := range bags {
+		if bag.cert != nil {
+			certs = append(certs, bag.cert)
+		}
+	}
+
+	ks := &Keystore{}
+	used := make(map[*x509.Certificate]bool)
+	for _, bag := range bags {
+		if bag.key == nil {
+			continue
+		}
+		var leaf *pkcs12Bag
+		for _, other := range bags {
+			if other.cert != nil && len(bag.localKeyID) > 0 && bytes.Equal(other.localKeyID, bag.localKeyID) {
+				leaf = other
+				break
+			}
+		}
+		if leaf == nil {
+			return nil, fmt.Errorf("%w: no certificate found for private key %q", ErrInvalidKeystore, bag.alias)
+		}
+		alias := bag.alias
+		if alias == "" {
+			alias = leaf.alias
+		}
+		chain := buildChain(leaf.cert, certs)
+		for _, cert := range chain {
+			used[cert] = true
+		}
+		ks.keys = append(ks.keys, &PrivateKeyEntry{Alias: alias, PrivateKey: bag.key, Chain: chain})
+	}
+
+	for _, bag := range bags {
+		if bag.cert != nil && !used[bag.cert] && bag.localKeyID == nil {
+			ks.certs = append(ks.certs, &TrustedCertificateEntry{Alias: bag.alias, Certificate: bag.cert})
+		}
+	}
+	return ks, nil
+}
+
+// buildChain orders the certificates issuing leaf, up to the root.
+func buildChain(leaf *x509.Certificate, certs []*x509.Certificate) []*x509.Certificate {
+	chain := []*x509.Certificate{leaf}
+	for current := leaf; !bytes.Equal(current.RawIssuer, current.RawSubject); {
+		var issuer *x509.Certificate
+		for _, cert := range certs {
+			if bytes.Equal(cert.RawSubject, current.RawIssuer) && current.CheckSignatureFrom(cert) == nil {
+				issuer = cert
+				break
+			}
+		}
+		if issuer == nil || len(chain) > len(certs) {
+			break
+		}
+		chain = append(chain, issuer)
+		current = issuer
+	}
+	return chain
+}
+
+func verifyPKCS12MAC(md *macData, content []byte, password string) error {
+	newHash, err := hashForAlgorithm(md.Mac.Algorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+	bmpPassword, err := bmpStringZeroTerminated(password)
+	if err != nil {
+		return err
+	}
+
+	key := pkcs12KDF(newHash, md.MacSalt, bmpPassword, md.Iterations, 3, newHash().Size())
+	mac := hmac.New(newHash, key)
+	mac.Write(content)
+	if !hmac.Equal(mac.Sum(nil), md.Mac.Digest) {
+		return ErrIncorrectPassword
+	}
+	return nil
+}
+
+func hashForAlgorithm(oid asn1.ObjectIdentifier) (func() hash.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA1), oid.Equal(oidHMACWithSHA1):
+		return sha1.New, nil
+	case oid.Equal(oidSHA256), oid.Equal(oidHMACWithSHA256):
+		return sha256.New, nil
+	case oid.Equal(oidSHA512), oid.Equal(oidHMACWithSHA512):
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported digest algorithm %v", ErrInvalidKeystore, oid)
+	}
+}
+
+// pbeDecrypt decrypts data encrypted with a password-based encryption scheme
+// of PKCS#12 (RFC 7292 appendix C) or with PBES2 (RFC 8018 section 6.2).
+func pbeDecrypt(algorithm pkix.AlgorithmIdentifier, data []byte, password string) ([]byte, error) {
+	var (
+		block cipher.Block
+		iv    []byte
+		err   error
+	)
+	switch {
+	case algorithm.Algorithm.Equal(oidPBEWithSHAAnd3KeyTripleDESCBC), algorithm.Algorithm.Equal(oidPBEWithSHAAnd40BitRC2CBC):
+		var params pbeParams
+		if _, err := asn1.Unmarshal(algorithm.Parameters.FullBytes, &params); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+		}
+		bmpPassword, err := bmpStringZeroTerminated(password)
+		if err != nil {
+			return nil, err
+		}
+		iv = pkcs12KDF(sha1.New, params.Salt, bmpPassword, params.Iterations, 2, 8)
+		if algorithm.Algorithm.Equal(oidPBEWithSHAAnd3KeyTripleDESCBC) {
+			block, err = des.NewTripleDESCipher(pkcs12KDF(sha1.New, params.Salt, bmpPassword, params.Iterations, 1, 24))
+		} else {
+			block, err = rc2.New(pkcs12KDF(sha1.New, params.Salt, bmpPassword, params.Iterations, 1, 5), 40)
+		}
+		if err != nil {
+			return nil, err
+		}
+	case algorithm.Algorithm.Equal(oidPBES2):
+		if block, iv, err = pbes2Cipher(algorithm, password); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported encryption algorithm %v", ErrInvalidKeystore, algorithm.Algorithm)
+	}
+
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, fmt.Errorf("%w: invalid encrypted data length", ErrInvalidKeystore)
+	}
+	decrypted := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, data)
+
+	// A wrong password almost always results in invalid padding
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > block.BlockSize() {
+		return nil, ErrIncorrectPassword
+	}
+	for _, b := range decrypted[len(decrypted)-padding:] {
+		if int(b) != padding {
+			return nil, ErrIncorrectPassword
+		}
+	}
+	return decrypted[:len(decrypted)-padding], nil
+}
+
+func pbes2Cipher(algorithm pkix.AlgorithmIdentifier, password string) (cipher.Block, []byte, error) {
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, nil, fmt.Errorf("%w: unsupported key derivation function %v", ErrInvalidKeystore, params.KeyDerivationFunc.Algorithm)
+	}
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	prf := sha1.New
+	if len(kdfParams.PRF.Algorithm) > 0 {
+		var err error
+		if prf, err = hashForAlgorithm(kdfParams.PRF.Algorithm); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+
+	var keyLength int
+	var newCipher func(key []byte) (cipher.Block, error)
+	switch scheme := params.EncryptionScheme.Algorithm; {
+	case scheme.Equal(oidAES128CBC):
+		keyLength, newCipher = 16, aes.NewCipher
+	case scheme.Equal(oidAES192CBC):
+		keyLength, newCipher = 24, aes.NewCipher
+	case scheme.Equal(oidAES256CBC):
+		keyLength, newCipher = 32, aes.NewCipher
+	case scheme.Equal(oidDESEDE3CBC):
+		keyLength, newCipher = 24, des.NewTripleDESCipher
+	default:
+		return nil, nil, fmt.Errorf("%w: unsupported encryption scheme %v", ErrInvalidKeystore, scheme)
+	}
+
+	// The password is used as is, unlike the BMPString of the PKCS#12 schemes
+	key := pbkdf2.Key([]byte(password), kdfParams.Salt, kdfParams.Iterations, keyLength, prf)
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, nil, fmt.Errorf("%w: invalid IV length %d", ErrInvalidKeystore, len(iv))
+	}
+	return block, iv, nil
+}
+
+// pkcs12KDF derives key material from a password as described in RFC 7292
+// appendix B.2. id is 1 for encryption keys, 2 for IVs and 3 for MAC keys.
+func pkcs12KDF(newHash func() hash.Hash, salt, password []byte, iterations int, id byte, size int) []byte {
+	h := newHash()
+	u := h.Size()
+	v := h.BlockSize()
+
+	d := bytes.Repeat([]byte{id}, v)
+	fill := func(b []byte) []byte {
+		if len(b) == 0 {
+			return nil
+		}
+		n := v * ((len(b) + v - 1) / v)
+		out := make([]byte, n)
+		for i := range out {
+			out[i] = b[i%len(b)]
+		}
+		return out
+	}
+	i := append(fill(salt), fill(password)...)
+
+	one := big.NewInt(1)
+	out := make([]byte, 0, size+u)
+	for len(out) < size {
+		h.Reset()
+		h.Write(d)
+		h.Write(i)
+		a := h.Sum(nil)
+		for r := 1; r < iterations; r++ {
+			h.Reset()
+			h.Write(a)
+			a = h.Sum(a[:0])
+		}
+		out = append(out, a...)
+
+		// I_j = (I_j + B + 1) mod 2^(v*8) for each v-byte block of I
+		b := new(big.Int).SetBytes(fill(a)[:v])
+		b.Add(b, one)
+		for j := 0; j < len(i); j += v {
+			ij := new(big.Int).SetBytes(i[j : j+v])
+			ij.Add(ij, b)
+			sum := ij.Bytes()
+			if len(sum) > v {
+				sum = sum[len(sum)-v:]
+			}
+			block := i[j : j+v]
+			for k := range block {
+				block[k] = 0
+			}
+			copy(block[v-len(sum):], sum)
+		}
+	}
+	return out[:size]
+}
+
+// bmpStringZeroTerminated encodes a password as the zero terminated BMPString
+// used by the PKCS#12 key derivation.
+func bmpStringZeroTerminated(s string) ([]byte, error) {
+	out := make([]byte, 0, 2*len(s)+2)
+	for _, r := range s {
+		if r > 0xffff {
+			return nil, fmt.Errorf("%w: password contains characters outside of the BMP", ErrInvalidKeystore)
+		}
+		out = append(out, byte(r>>8), byte(r))
+	}
+	return append(out, 0, 0), nil
+}
+
+func decodeBMPString(b []byte) (string, error) {
+	if len(b)%2 != 0 {
+		return "", fmt.Errorf("%w: invalid BMPString length", ErrInvalidKeystore)
+	}
+	s := make([]uint16, 0, len(b)/2)
+	for i := 0; i < len(b); i += 2 {
+		s = append(s, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(s)), nil
+}
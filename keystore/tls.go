@@ -0,0 +1,90 @@
+package keystore
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+)
+
+// Config configures TLS from a keystore holding the client certificate and a
+// truststore holding the trusted CA certificates, like the ssl.* settings of
+// Java clients.
+type Config struct {
+	// KeystoreLocation is the path of the keystore holding the client
+	// certificate (ssl.keystore.location). It is optional if the brokers do
+	// not require client authentication.
+	KeystoreLocation string
+	// KeystoreType is TypePKCS12 or TypeJKS (ssl.keystore.type), the type is
+	// detected from the content if empty.
+	KeystoreType string
+	// KeystorePassword is the password of the keystore
+	// (ssl.keystore.password).
+	KeystorePassword string
+	// KeyPassword is the password of the private key (ssl.key.password),
+	// defaults to KeystorePassword.
+	KeyPassword string
+	// KeyAlias selects the private key of the keystore to use, it is optional
+	// if the keystore contains a single private key.
+	KeyAlias string
+
+	// TruststoreLocation is the path of the truststore holding the trusted
+	// certificates (ssl.truststore.location). The system roots are trusted
+	// if empty.
+	TruststoreLocation string
+	// TruststoreType is TypePKCS12 or TypeJKS (ssl.truststore.type), the type
+	// is detected from the content if empty.
+	TruststoreType string
+	// TruststorePassword is the password of the truststore
+	// (ssl.truststore.password). The integrity of the truststore is not
+	// checked if empty, like in Java.
+	TruststorePassword string
+}
+
+// TLSConfig loads the keystore and the truststore, and returns a tls.Config
+// that presents the client certificate and trusts the certificates of the
+// truststore.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if c.KeystoreLocation != "" {
+		ks, err := Load(c.KeystoreLocation, c.KeystoreType, c.KeystorePassword, c.KeyPassword)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := ks.PrivateKey(c.KeyAlias)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load keystore %s: %w", c.KeystoreLocation, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{entry.Certificate()}
+	} else if c.KeyAlias != "" {
+		return nil, errors.New("keystore: KeyAlias is set but KeystoreLocation is empty")
+	}
+
+	if c.TruststoreLocation != "" {
+		ts, err := Load(c.TruststoreLocation, c.TruststoreType, c.TruststorePassword, "")
+		if err != nil {
+			return nil, err
+		}
+		if len(ts.TrustedCertificates()) == 0 {
+			return nil, fmt.Errorf("%w: truststore %s contains no trusted certificates", ErrInvalidKeystore, c.TruststoreLocation)
+		}
+		tlsConfig.RootCAs = ts.CertPool()
+	}
+
+	return tlsConfig, nil
+}
+
+// Certificate returns the private key and its certificate chain as a
+// tls.Certificate.
+func (e *PrivateKeyEntry) Certificate() tls.Certificate {
+	cert := tls.Certificate{PrivateKey: e.PrivateKey}
+	for _, c := range e.Chain {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+	if len(e.Chain) > 0 {
+		cert.Leaf = e.Chain[0]
+	}
+	return cert
+}
@@ -1,5 +1,7 @@
 package sarama
 
+import "errors"
+
 type MemberIdentity struct {
 	MemberId        string
 	GroupInstanceId *string
@@ -77,6 +79,25 @@ func (r *LeaveGroupRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *LeaveGroupRequest) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *LeaveGroupRequest) checkVersion(v int16) error {
+	if v >= 3 {
+		return nil
+	}
+	if len(r.Members) > 1 {
+		return errors.New("leaving with several members requires version 3")
+	}
+	for _, member := range r.Members {
+		if member.GroupInstanceId != nil || member.MemberId != r.MemberId {
+			return errors.New("the member identities require version 3")
+		}
+	}
+	return nil
+}
+
 func (r *LeaveGroupRequest) HeaderVersion() int16 {
 	return 1
 }
@@ -81,6 +81,10 @@ func (r *LeaveGroupResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *LeaveGroupResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *LeaveGroupResponse) HeaderVersion() int16 {
 	return 0
 }
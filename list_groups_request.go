@@ -1,8 +1,34 @@
 package sarama
 
+import "errors"
+
 type ListGroupsRequest struct {
 	Version      int16
 	StatesFilter []string // version 4 or later
+	TypesFilter  []string // version 5 or later
+}
+
+// newListGroupsRequest returns a ListGroupsRequest with the highest version
+// supported by the given Kafka version.
+func newListGroupsRequest(version KafkaVersion) *ListGroupsRequest {
+	request := &ListGroupsRequest{}
+	if version.IsAtLeast(V3_8_0_0) {
+		// Version 5 adds the TypesFilter field (KIP-848).
+		request.Version = 5
+	} else if version.IsAtLeast(V2_6_0_0) {
+		// Version 4 adds the StatesFilter field (KIP-518).
+		request.Version = 4
+	} else if version.IsAtLeast(V2_4_0_0) {
+		// Version 3 is the first flexible version.
+		request.Version = 3
+	} else if version.IsAtLeast(V2_0_0_0) {
+		// Version 2 is the same as version 0.
+		request.Version = 2
+	} else if version.IsAtLeast(V0_11_0_0) {
+		// Version 1 is the same as version 0.
+		request.Version = 1
+	}
+	return request
 }
 
 func (r *ListGroupsRequest) Encode(pe packetEncoder) error {
@@ -15,6 +41,15 @@ func (r *ListGroupsRequest) Encode(pe packetEncoder) error {
 			}
 		}
 	}
+	if r.Version >= 5 {
+		pe.putCompactArrayLength(len(r.TypesFilter))
+		for _, filter := range r.TypesFilter {
+			err := pe.putCompactString(filter)
+			if err != nil {
+				return err
+			}
+		}
+	}
 	if r.Version >= 3 {
 		pe.putEmptyTaggedFieldArray()
 	}
@@ -37,6 +72,20 @@ func (r *ListGroupsRequest) Decode(pd packetDecoder, version int16) (err error)
 			}
 		}
 	}
+	if r.Version >= 5 {
+		filterLen, err := pd.getCompactArrayLength()
+		if err != nil {
+			return err
+		}
+		if filterLen > 0 {
+			r.TypesFilter = make([]string, filterLen)
+			for i := 0; i < filterLen; i++ {
+				if r.TypesFilter[i], err = pd.getCompactString(); err != nil {
+					return err
+				}
+			}
+		}
+	}
 	if r.Version >= 3 {
 		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
 			return err
@@ -53,6 +102,20 @@ func (r *ListGroupsRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *ListGroupsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *ListGroupsRequest) checkVersion(v int16) error {
+	switch {
+	case len(r.StatesFilter) > 0 && v < 4:
+		return errors.New("the states filter requires version 4")
+	case len(r.TypesFilter) > 0 && v < 5:
+		return errors.New("the types filter requires version 5")
+	}
+	return nil
+}
+
 func (r *ListGroupsRequest) HeaderVersion() int16 {
 	if r.Version >= 3 {
 		return 2
@@ -61,11 +124,13 @@ func (r *ListGroupsRequest) HeaderVersion() int16 {
 }
 
 func (r *ListGroupsRequest) IsValidVersion() bool {
-	return r.Version >= 0 && r.Version <= 4
+	return r.Version >= 0 && r.Version <= 5
 }
 
 func (r *ListGroupsRequest) RequiredVersion() KafkaVersion {
 	switch r.Version {
+	case 5:
+		return V3_8_0_0
 	case 4:
 		return V2_6_0_0
 	case 3:
@@ -35,3 +35,17 @@ func TestListGroupsRequest(t *testing.T) {
 		0, // empty tag buffer
 	})
 }
+
+func TestListGroupsRequestV5(t *testing.T) {
+	testRequest(t, "ListGroupsRequest", &ListGroupsRequest{
+		Version:      5,
+		StatesFilter: []string{"Empty"},
+		TypesFilter:  []string{"classic"},
+	}, []byte{
+		2,                          // compact array length (1)
+		6, 'E', 'm', 'p', 't', 'y', // compact string
+		2,                                    // compact array length (1)
+		8, 'c', 'l', 'a', 's', 's', 'i', 'c', // compact string
+		0, // empty tags
+	})
+}
@@ -10,6 +10,7 @@ type ListGroupsResponse struct {
 
 type GroupData struct {
 	GroupState string // version 4 or later
+	GroupType  string // version 5 or later
 }
 
 func (r *ListGroupsResponse) Encode(pe packetEncoder) error {
@@ -46,8 +47,17 @@ func (r *ListGroupsResponse) Encode(pe packetEncoder) error {
 				if err := pe.putCompactString(groupData.GroupState); err != nil {
 					return err
 				}
+				if r.Version >= 5 {
+					if err := pe.putCompactString(groupData.GroupType); err != nil {
+						return err
+					}
+				}
 			}
+
+			pe.putEmptyTaggedFieldArray()
 		}
+
+		pe.putEmptyTaggedFieldArray()
 	}
 
 	return nil
@@ -115,9 +125,15 @@ func (r *ListGroupsResponse) Decode(pd packetDecoder, version int16) error {
 			if err != nil {
 				return err
 			}
-			r.GroupsData[groupId] = GroupData{
+			groupData := GroupData{
 				GroupState: groupState,
 			}
+			if r.Version >= 5 {
+				if groupData.GroupType, err = pd.getCompactString(); err != nil {
+					return err
+				}
+			}
+			r.GroupsData[groupId] = groupData
 		}
 
 		if r.Version >= 3 {
@@ -144,6 +160,10 @@ func (r *ListGroupsResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *ListGroupsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ListGroupsResponse) HeaderVersion() int16 {
 	if r.Version >= 3 {
 		return 1
@@ -152,11 +172,13 @@ func (r *ListGroupsResponse) HeaderVersion() int16 {
 }
 
 func (r *ListGroupsResponse) IsValidVersion() bool {
-	return r.Version >= 0 && r.Version <= 4
+	return r.Version >= 0 && r.Version <= 5
 }
 
 func (r *ListGroupsResponse) RequiredVersion() KafkaVersion {
 	switch r.Version {
+	case 5:
+		return V3_8_0_0
 	case 4:
 		return V2_6_0_0
 	case 3:
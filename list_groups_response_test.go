@@ -83,3 +83,24 @@ func TestListGroupsResponse(t *testing.T) {
 		t.Error("Expected foo grup to have empty state")
 	}
 }
+
+func TestListGroupsResponseV5(t *testing.T) {
+	response := &ListGroupsResponse{
+		Version: 5,
+		Err:     ErrNoError,
+		Groups:  map[string]string{"foo": "consumer"},
+		GroupsData: map[string]GroupData{
+			"foo": {GroupState: "Stable", GroupType: "classic"},
+		},
+	}
+
+	buf, err := Encode(response, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(ListGroupsResponse)
+	testVersionDecodable(t, "v5", decoded, buf, 5)
+	if decoded.GroupsData["foo"] != response.GroupsData["foo"] {
+		t.Errorf("Expected %+v, got %+v", response.GroupsData["foo"], decoded.GroupsData["foo"])
+	}
+}
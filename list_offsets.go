@@ -0,0 +1,196 @@
+package sarama
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// OffsetSpec specifies which offset of a partition ClusterAdmin.ListOffsets
+// returns. It is either one of the OffsetSpec constants or a timestamp given
+// through OffsetSpecForTimestamp.
+type OffsetSpec int64
+
+const (
+	// OffsetSpecLatest returns the high water mark, or the last stable offset
+	// with the ReadCommitted isolation level.
+	OffsetSpecLatest OffsetSpec = -1
+	// OffsetSpecEarliest returns the log start offset.
+	OffsetSpecEarliest OffsetSpec = -2
+	// OffsetSpecMaxTimestamp returns the offset and timestamp of the record
+	// with the largest timestamp (KIP-734). It requires Kafka 3.0 or later.
+	OffsetSpecMaxTimestamp OffsetSpec = -3
+)
+
+// OffsetSpecForTimestamp returns an OffsetSpec for the earliest offset whose
+// timestamp is equal to or later than t.
+func OffsetSpecForTimestamp(t time.Time) OffsetSpec {
+	return OffsetSpec(t.UnixNano() / int64(time.Millisecond))
+}
+
+// ListOffsetsResult is the offset of a single partition as returned by
+// ClusterAdmin.ListOffsets.
+type ListOffsetsResult struct {
+	// Offset is -1 if there is no such offset, for example when no record has a
+	// timestamp equal to or later than the requested one.
+	Offset int64
+	// Timestamp is the timestamp of the record at Offset. It is only set for
+	// timestamp and max timestamp specs, and only by Kafka 0.10.1 or later.
+	Timestamp time.Time
+	// LeaderEpoch is the leader epoch of the record at Offset, or -1 if unknown.
+	LeaderEpoch int32
+	Err         error
+}
+
+// isListOffsetsRetriable reports whether the offsets of a partition that failed
+// with err should be listed again after refreshing the metadata, because its
+// leadership moved or is moving.
+func isListOffsetsRetriable(err error) bool {
+	var kerr KError
+	if !errors.As(err, &kerr) {
+		// network errors and incomplete responses
+		return err != nil
+	}
+	switch kerr {
+	case ErrNotLeaderForPartition, ErrLeaderNotAvailable, ErrUnknownTopicOrPartition,
+		ErrFencedLeaderEpoch, ErrUnknownLeaderEpoch, ErrOffsetNotAvailable:
+		return true
+	}
+	return false
+}
+
+func (ca *clusterAdmin) ListOffsets(partitions map[string]map[int32]OffsetSpec, isolationLevel IsolationLevel) (map[string]map[int32]*ListOffsetsResult, error) {
+	results := make(map[string]map[int32]*ListOffsetsResult, len(partitions))
+	pending := partitions
+	for attemptsRemaining := ca.conf.Admin.Retry.Max + 1; ; {
+		retry := ca.listOffsets(pending, isolationLevel, results)
+		attemptsRemaining--
+		if len(retry) == 0 || attemptsRemaining <= 0 {
+			return results, nil
+		}
+
+		Logger.Printf(
+			"admin/request retrying list offsets of %d topics after %dms... (%d attempts remaining)\n",
+			len(retry), ca.conf.Admin.Retry.Backoff/time.Millisecond, attemptsRemaining)
+		time.Sleep(ca.conf.Admin.Retry.Backoff)
+
+		topics := make([]string, 0, len(retry))
+		for topic := range retry {
+			topics = append(topics, topic)
+		}
+		if err := ca.client.RefreshMetadata(topics...); err != nil {
+			Logger.Printf("admin/request failed to refresh metadata before retrying list offsets: %v\n", err)
+		}
+		pending = retry
+	}
+}
+
+// listOffsets sends one OffsetRequest per leader for the given partitions and
+// stores their outcome in results. It returns the partitions that failed with
+// an error for which isListOffsetsRetriable is true.
+func (ca *clusterAdmin) listOffsets(partitions map[string]map[int32]OffsetSpec, isolationLevel IsolationLevel, results map[string]map[int32]*ListOffsetsResult) map[string]map[int32]OffsetSpec {
+	var lock sync.Mutex
+	retry := make(map[string]map[int32]OffsetSpec)
+	setResult := func(topic string, partition int32, spec OffsetSpec, result *ListOffsetsResult) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if results[topic] == nil {
+			results[topic] = make(map[int32]*ListOffsetsResult)
+		}
+		results[topic][partition] = result
+		if isListOffsetsRetriable(result.Err) {
+			if retry[topic] == nil {
+				retry[topic] = make(map[int32]OffsetSpec)
+			}
+			retry[topic][partition] = spec
+		}
+	}
+	failed := func(err error) *ListOffsetsResult {
+		return &ListOffsetsResult{Offset: -1, LeaderEpoch: -1, Err: err}
+	}
+
+	brokers := make(map[int32]*Broker)
+	requests := make(map[int32]*OffsetRequest)
+	for topic, specs := range partitions {
+		for partition, spec := range specs {
+			broker, err := ca.client.Leader(topic, partition)
+			if err != nil {
+				setResult(topic, partition, spec, failed(err))
+				continue
+			}
+
+			request := requests[broker.ID()]
+			if request == nil {
+				request = newOffsetRequest(ca.conf.versionCeiling())
+				request.IsolationLevel = isolationLevel
+				// the max timestamp spec depends on the version supported by the leader
+				if request.Version, err = broker.negotiatedVersion(request); err != nil {
+					setResult(topic, partition, spec, failed(err))
+					continue
+				}
+				brokers[broker.ID()] = broker
+				requests[broker.ID()] = request
+			}
+			if spec == OffsetSpecMaxTimestamp && request.Version < 7 {
+				setResult(topic, partition, spec, failed(ErrUnsupportedVersion))
+				continue
+			}
+			request.AddBlock(topic, partition, int64(spec), 1)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for id, request := range requests {
+		if len(request.blocks) == 0 {
+			continue
+		}
+
+		broker, request := brokers[id], request
+		wg.Add(1)
+		go withRecover(func() {
+			defer wg.Done()
+
+			response, err := broker.GetAvailableOffsets(request)
+			if err != nil {
+				_ = broker.Close()
+			}
+			for topic, blocks := range request.blocks {
+				for partition, block := range blocks {
+					spec := OffsetSpec(block.timestamp)
+					if err != nil {
+						setResult(topic, partition, spec, failed(err))
+						continue
+					}
+					setResult(topic, partition, spec, newListOffsetsResult(response.GetBlock(topic, partition), request.Version, spec))
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	return retry
+}
+
+func newListOffsetsResult(block *OffsetResponseBlock, version int16, spec OffsetSpec) *ListOffsetsResult {
+	result := &ListOffsetsResult{Offset: -1, LeaderEpoch: -1}
+	switch {
+	case block == nil:
+		result.Err = ErrIncompleteResponse
+	case !errors.Is(block.Err, ErrNoError):
+		result.Err = block.Err
+	case version == 0:
+		if len(block.Offsets) > 0 {
+			result.Offset = block.Offsets[0]
+		}
+	default:
+		result.Offset = block.Offset
+		if spec != OffsetSpecLatest && spec != OffsetSpecEarliest && block.Timestamp >= 0 {
+			result.Timestamp = time.Unix(0, block.Timestamp*int64(time.Millisecond))
+		}
+		if version >= 4 {
+			result.LeaderEpoch = block.LeaderEpoch
+		}
+	}
+	return result
+}
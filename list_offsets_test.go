@@ -0,0 +1,166 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClusterAdminListOffsets(t *testing.T) {
+	topic := "my_topic"
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	leader := NewMockBroker(t, 2)
+	defer leader.Close()
+	movedLeader := NewMockBroker(t, 3)
+	defer movedLeader.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetBroker(leader.Addr(), leader.BrokerID()).
+			SetBroker(movedLeader.Addr(), movedLeader.BrokerID()).
+			SetLeader(topic, 0, leader.BrokerID()).
+			SetLeader(topic, 1, leader.BrokerID()).
+			SetLeader(topic, 2, movedLeader.BrokerID()),
+	})
+
+	timestamp := time.Unix(1600000000, 0)
+	leader.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset(topic, 0, int64(OffsetSpecEarliest), 5).
+			SetOffset(topic, 1, int64(OffsetSpecForTimestamp(timestamp)), 42),
+	})
+
+	notLeader := &OffsetResponse{Version: 7}
+	notLeader.Blocks = map[string]map[int32]*OffsetResponseBlock{
+		topic: {2: {Err: ErrNotLeaderForPartition}},
+	}
+	movedLeader.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"OffsetRequest": NewMockSequence(
+			NewMockWrapper(notLeader),
+			NewMockOffsetResponse(t).SetOffset(topic, 2, int64(OffsetSpecMaxTimestamp), 100),
+		),
+	})
+
+	config := NewTestConfig()
+	config.Version = V3_0_0_0
+	config.Admin.Retry.Backoff = 0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	results, err := admin.ListOffsets(map[string]map[int32]OffsetSpec{
+		topic: {
+			0: OffsetSpecEarliest,
+			1: OffsetSpecForTimestamp(timestamp),
+			2: OffsetSpecMaxTimestamp,
+		},
+	}, ReadUncommitted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for partition, expected := range map[int32]int64{0: 5, 1: 42, 2: 100} {
+		result := results[topic][partition]
+		if result == nil {
+			t.Fatalf("missing result for partition %d", partition)
+		}
+		if result.Err != nil {
+			t.Errorf("unexpected error for partition %d: %v", partition, result.Err)
+		}
+		if result.Offset != expected {
+			t.Errorf("expected offset %d for partition %d, got %d", expected, partition, result.Offset)
+		}
+	}
+
+	offsetRequests := 0
+	for _, entry := range leader.History() {
+		if _, ok := entry.Request.(*OffsetRequest); ok {
+			offsetRequests++
+		}
+	}
+	if offsetRequests != 1 {
+		t.Errorf("expected a single OffsetRequest for both partitions of the leader, got %d", offsetRequests)
+	}
+}
+
+func TestClusterAdminListOffsetsMaxTimestampUnsupported(t *testing.T) {
+	// the broker only supports ListOffsets v6
+	negotiated := NewMockApiVersionsResponse(t)
+	for i := range negotiated.apiKeys {
+		if negotiated.apiKeys[i].ApiKey == 2 {
+			negotiated.apiKeys[i].MaxVersion = 6
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		version     KafkaVersion
+		apiVersions *MockApiVersionsResponse
+	}{
+		"version":    {V2_8_0_0, NewMockApiVersionsResponse(t)},
+		"negotiated": {KafkaVersion{}, negotiated},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			topic := "my_topic"
+			seedBroker := NewMockBroker(t, 1)
+			defer seedBroker.Close()
+
+			seedBroker.SetHandlerByMap(map[string]MockResponse{
+				"ApiVersionsRequest": tc.apiVersions,
+				"MetadataRequest": NewMockMetadataResponse(t).
+					SetController(seedBroker.BrokerID()).
+					SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+					SetLeader(topic, 0, seedBroker.BrokerID()),
+			})
+
+			config := NewTestConfig()
+			config.Version = tc.version
+			admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer safeClose(t, admin)
+
+			results, err := admin.ListOffsets(map[string]map[int32]OffsetSpec{topic: {0: OffsetSpecMaxTimestamp}}, ReadUncommitted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result := results[topic][0]; !errors.Is(result.Err, ErrUnsupportedVersion) {
+				t.Errorf("expected ErrUnsupportedVersion, got %v", result.Err)
+			}
+			for _, entry := range seedBroker.History() {
+				if _, ok := entry.Request.(*OffsetRequest); ok {
+					t.Error("expected no OffsetRequest to be sent")
+				}
+			}
+		})
+	}
+}
+
+func TestNewListOffsetsResult(t *testing.T) {
+	result := newListOffsetsResult(&OffsetResponseBlock{Offsets: []int64{7}}, 0, OffsetSpecLatest)
+	if result.Offset != 7 || !result.Timestamp.IsZero() || result.LeaderEpoch != -1 {
+		t.Errorf("unexpected v0 result %+v", result)
+	}
+
+	result = newListOffsetsResult(&OffsetResponseBlock{Offset: 7, Timestamp: 1600000000000, LeaderEpoch: 2}, 7, OffsetSpecMaxTimestamp)
+	if result.Offset != 7 || !result.Timestamp.Equal(time.Unix(1600000000, 0)) || result.LeaderEpoch != 2 {
+		t.Errorf("unexpected v7 result %+v", result)
+	}
+
+	result = newListOffsetsResult(nil, 7, OffsetSpecLatest)
+	if !errors.Is(result.Err, ErrIncompleteResponse) || !isListOffsetsRetriable(result.Err) {
+		t.Errorf("expected retriable ErrIncompleteResponse, got %v", result.Err)
+	}
+	if isListOffsetsRetriable(ErrTopicAuthorizationFailed) {
+		t.Error("expected authorization errors not to be retried")
+	}
+}
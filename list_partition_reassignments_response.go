@@ -162,6 +162,10 @@ func (r *ListPartitionReassignmentsResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *ListPartitionReassignmentsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ListPartitionReassignmentsResponse) HeaderVersion() int16 {
 	return 1
 }
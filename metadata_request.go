@@ -1,6 +1,9 @@
 package sarama
 
-import "encoding/base64"
+import (
+	"encoding/base64"
+	"errors"
+)
 
 type Uuid [16]byte
 
@@ -19,6 +22,10 @@ type MetadataRequest struct {
 	AllowAutoTopicCreation             bool
 	IncludeClusterAuthorizedOperations bool // version 8 and up
 	IncludeTopicAuthorizedOperations   bool // version 8 and up
+	// autoTopicCreationDisabled is set when Metadata.AllowAutoTopicCreation
+	// is disabled, which brokers only honour from version 4: the topics
+	// would be created by older brokers.
+	autoTopicCreationDisabled bool
 }
 
 func NewMetadataRequest(version KafkaVersion, topics []string) *MetadataRequest {
@@ -201,6 +208,20 @@ func (r *MetadataRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *MetadataRequest) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *MetadataRequest) checkVersion(v int16) error {
+	switch {
+	case r.autoTopicCreationDisabled && len(r.Topics) > 0 && v < 4:
+		return errors.New("disabling the auto topic creation requires version 4")
+	case (r.IncludeClusterAuthorizedOperations || r.IncludeTopicAuthorizedOperations) && v < 8:
+		return errors.New("the authorized operations require version 8")
+	}
+	return nil
+}
+
 func (r *MetadataRequest) HeaderVersion() int16 {
 	if r.Version >= 9 {
 		return 2
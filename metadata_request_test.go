@@ -258,3 +258,17 @@ func TestMetadataRequestV10(t *testing.T) {
 	request.IncludeTopicAuthorizedOperations = true
 	testRequest(t, "one topic, auto create, cluster auth, topic auth", request, metadataRequestAutoCreateClusterAuthTopicAuthV10)
 }
+
+func TestMetadataRequestCheckVersion(t *testing.T) {
+	request := &MetadataRequest{Version: 10, Topics: []string{"topic1"}}
+	if err := request.checkVersion(2); err != nil {
+		t.Errorf("Expected the auto topic creation to be dropped unless disabled by the config, got %v", err)
+	}
+	request.autoTopicCreationDisabled = true
+	if err := request.checkVersion(2); err == nil {
+		t.Error("Expected an error when the auto topic creation is disabled by the config")
+	}
+	if err := request.checkVersion(4); err != nil {
+		t.Error(err)
+	}
+}
@@ -435,6 +435,10 @@ func (r *MetadataResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *MetadataResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *MetadataResponse) HeaderVersion() int16 {
 	if r.Version < 9 {
 		return 0
@@ -26,6 +26,10 @@ func getOrRegisterHistogram(name string, r metrics.Registry) metrics.Histogram {
 func getMetricNameForBroker(name string, broker *Broker) string {
 	// Use broker id like the Java client as it does not contain '.' or ':' characters that
 	// can be interpreted as special character by monitoring tool (e.g. Graphite)
+	if broker.connection != "" {
+		// metrics are kept per connection when there are several to the broker
+		return fmt.Sprintf(name+"-for-broker-%d-%s", broker.ID(), broker.connection)
+	}
 	return fmt.Sprintf(name+"-for-broker-%d", broker.ID())
 }
 
@@ -39,7 +39,10 @@ type RequestNotifierFunc func(bytesRead, bytesWritten int)
 // localhost port that can accept many connections. It reads Kafka requests
 // from that connection and returns responses programmed by the SetHandlerByMap
 // function. If a MockBroker receives a request that it has no programmed
-// response for, then it returns nothing and the request times out.
+// response for, then it returns nothing and the request times out. The
+// exception is ApiVersionsRequest, which is answered with all the versions
+// supported by Sarama unless programmed otherwise: the responses set with
+// Returns are not used for it.
 //
 // A set of MockRequest builders to define mappings used by MockBroker is
 // provided by Sarama. But users can develop MockRequests of their own and use
@@ -108,7 +111,11 @@ func (b *MockBroker) SetHandlerFuncByMap(handlerMap map[string]requestHandlerFun
 	}
 	b.setHandler(func(req *Request) (res EncoderWithHeader) {
 		reqTypeName := reflect.TypeOf(req.Body).Elem().Name()
-		return fnMap[reqTypeName](req)
+		handler := fnMap[reqTypeName]
+		if handler == nil {
+			return nil
+		}
+		return handler(req)
 	})
 }
 
@@ -284,6 +291,9 @@ func (b *MockBroker) handleRequests(conn io.ReadWriteCloser, idx int, wg *sync.W
 
 			b.lock.Lock()
 			res := b.handler(req)
+			if res == nil {
+				res = b.apiVersionsResponse(req)
+			}
 			b.history = append(b.history, RequestResponse{req.Body, res})
 			b.lock.Unlock()
 
@@ -367,6 +377,9 @@ func (b *MockBroker) encodeHeader(headerVersion int16, correlationId int32, payl
 }
 
 func (b *MockBroker) defaultRequestHandler(req *Request) (res EncoderWithHeader) {
+	if res := b.apiVersionsResponse(req); res != nil {
+		return res
+	}
 	select {
 	case res, ok := <-b.expectations:
 		if !ok {
@@ -378,6 +391,15 @@ func (b *MockBroker) defaultRequestHandler(req *Request) (res EncoderWithHeader)
 	}
 }
 
+// apiVersionsResponse returns the default reply to an ApiVersionsRequest
+// which has no programmed response, or nil for the other requests.
+func (b *MockBroker) apiVersionsResponse(req *Request) EncoderWithHeader {
+	if _, ok := req.Body.(*ApiVersionsRequest); !ok {
+		return nil
+	}
+	return NewMockApiVersionsResponse(b.t).For(req.Body)
+}
+
 func isConnectionClosedError(err error) bool {
 	var result bool
 	opError := &net.OpError{}
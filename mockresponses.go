@@ -2,6 +2,7 @@ package sarama
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"sync"
 )
@@ -70,14 +71,16 @@ func (mc *MockSequence) For(reqBody VersionedDecoder) (res EncoderWithHeader) {
 }
 
 type MockListGroupsResponse struct {
-	groups map[string]string
-	t      TestReporter
+	groups     map[string]string
+	groupsData map[string]GroupData
+	t          TestReporter
 }
 
 func NewMockListGroupsResponse(t TestReporter) *MockListGroupsResponse {
 	return &MockListGroupsResponse{
-		groups: make(map[string]string),
-		t:      t,
+		groups:     make(map[string]string),
+		groupsData: make(map[string]GroupData),
+		t:          t,
 	}
 }
 
@@ -85,7 +88,23 @@ func (m *MockListGroupsResponse) For(reqBody VersionedDecoder) EncoderWithHeader
 	request := reqBody.(*ListGroupsRequest)
 	response := &ListGroupsResponse{
 		Version: request.Version,
-		Groups:  m.groups,
+		Groups:  make(map[string]string),
+	}
+	if request.Version >= 4 {
+		response.GroupsData = make(map[string]GroupData)
+	}
+	for groupID, protocolType := range m.groups {
+		data := m.groupsData[groupID]
+		if len(request.StatesFilter) > 0 && !containsString(request.StatesFilter, data.GroupState) {
+			continue
+		}
+		if len(request.TypesFilter) > 0 && !containsString(request.TypesFilter, data.GroupType) {
+			continue
+		}
+		response.Groups[groupID] = protocolType
+		if response.GroupsData != nil {
+			response.GroupsData[groupID] = data
+		}
 	}
 	return response
 }
@@ -95,6 +114,23 @@ func (m *MockListGroupsResponse) AddGroup(groupID, protocolType string) *MockLis
 	return m
 }
 
+// AddGroupWithData adds a group with the state and type returned by version 4
+// and later, which are also used to apply the filters of the request.
+func (m *MockListGroupsResponse) AddGroupWithData(groupID, protocolType string, data GroupData) *MockListGroupsResponse {
+	m.groups[groupID] = protocolType
+	m.groupsData[groupID] = data
+	return m
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 type MockDescribeGroupsResponse struct {
 	groups map[string]*GroupDescription
 	t      TestReporter
@@ -721,6 +757,26 @@ func (mr *MockCreateTopicsResponse) For(reqBody VersionedDecoder) EncoderWithHea
 			continue
 		}
 		res.TopicErrors[topic] = &TopicError{Err: ErrNoError}
+
+		if res.Version >= 5 {
+			if res.CreatedTopics == nil {
+				res.CreatedTopics = make(map[string]*CreatedTopicDetail)
+			}
+			detail := req.TopicDetails[topic]
+			created := &CreatedTopicDetail{
+				NumPartitions:     detail.NumPartitions,
+				ReplicationFactor: detail.ReplicationFactor,
+				Configs:           []*CreatedTopicConfig{},
+			}
+			for name, value := range detail.ConfigEntries {
+				created.Configs = append(created.Configs, &CreatedTopicConfig{
+					Name:   name,
+					Value:  value,
+					Source: SourceTopic,
+				})
+			}
+			res.CreatedTopics[topic] = created
+		}
 	}
 	return res
 }
@@ -854,6 +910,12 @@ func (mr *MockDescribeConfigsResponse) For(reqBody VersionedDecoder) EncoderWith
 
 	includeSynonyms := req.Version > 0
 	includeSource := req.Version > 0
+	configType := func(t ConfigType) ConfigType {
+		if req.Version >= 3 {
+			return t
+		}
+		return ConfigTypeUnknown
+	}
 
 	for _, r := range req.Resources {
 		var configEntries []*ConfigEntry
@@ -866,11 +928,13 @@ func (mr *MockDescribeConfigsResponse) For(reqBody VersionedDecoder) EncoderWith
 					Value:    "2",
 					ReadOnly: false,
 					Default:  false,
+					Type:     configType(ConfigTypeInt),
 				},
 			)
 			res.Resources = append(
 				res.Resources, &ResourceResponse{
 					Name:    r.Name,
+					Type:    r.Type,
 					Configs: configEntries,
 				},
 			)
@@ -882,11 +946,13 @@ func (mr *MockDescribeConfigsResponse) For(reqBody VersionedDecoder) EncoderWith
 					Value:    "DEBUG",
 					ReadOnly: false,
 					Default:  false,
+					Type:     configType(ConfigTypeString),
 				},
 			)
 			res.Resources = append(
 				res.Resources, &ResourceResponse{
 					Name:    r.Name,
+					Type:    r.Type,
 					Configs: configEntries,
 				},
 			)
@@ -897,6 +963,7 @@ func (mr *MockDescribeConfigsResponse) For(reqBody VersionedDecoder) EncoderWith
 				ReadOnly:  false,
 				Default:   !includeSource,
 				Sensitive: false,
+				Type:      configType(ConfigTypeInt),
 			}
 			if includeSource {
 				maxMessageBytes.Source = SourceDefault
@@ -915,6 +982,7 @@ func (mr *MockDescribeConfigsResponse) For(reqBody VersionedDecoder) EncoderWith
 				ReadOnly:  false,
 				Default:   false,
 				Sensitive: false,
+				Type:      configType(ConfigTypeLong),
 			}
 			if includeSynonyms {
 				retentionMs.Synonyms = []*ConfigSynonym{
@@ -930,6 +998,7 @@ func (mr *MockDescribeConfigsResponse) For(reqBody VersionedDecoder) EncoderWith
 				ReadOnly:  false,
 				Default:   false,
 				Sensitive: true,
+				Type:      configType(ConfigTypePassword),
 			}
 			configEntries = append(
 				configEntries, maxMessageBytes, retentionMs, password,
@@ -937,11 +1006,20 @@ func (mr *MockDescribeConfigsResponse) For(reqBody VersionedDecoder) EncoderWith
 			res.Resources = append(
 				res.Resources, &ResourceResponse{
 					Name:    r.Name,
+					Type:    r.Type,
 					Configs: configEntries,
 				},
 			)
 		}
 	}
+
+	if req.IncludeDocumentation {
+		for _, resource := range res.Resources {
+			for _, entry := range resource.Configs {
+				entry.Documentation = "Documentation of " + entry.Name
+			}
+		}
+	}
 	return res
 }
 
@@ -1506,21 +1584,26 @@ type MockApiVersionsResponse struct {
 	apiKeys []ApiVersionsResponseKey
 }
 
+// NewMockApiVersionsResponse returns an ApiVersionsResponse builder which
+// advertises all the versions of the API keys supported by Sarama, unless
+// set with SetApiKeys.
 func NewMockApiVersionsResponse(t TestReporter) *MockApiVersionsResponse {
+	var apiKeys []ApiVersionsResponseKey
+	// API keys and versions are both far below math.MaxInt8, which bounds the
+	// probing without tracking the highest key known by allocateBody
+	for key := int16(0); key < math.MaxInt8; key++ {
+		if allocateBody(key, 0) == nil {
+			continue
+		}
+		maxVersion := int16(0)
+		for maxVersion < math.MaxInt8 && allocateBody(key, maxVersion+1).IsValidVersion() {
+			maxVersion++
+		}
+		apiKeys = append(apiKeys, ApiVersionsResponseKey{ApiKey: key, MaxVersion: maxVersion})
+	}
 	return &MockApiVersionsResponse{
-		t: t,
-		apiKeys: []ApiVersionsResponseKey{
-			{
-				ApiKey:     0,
-				MinVersion: 5,
-				MaxVersion: 8,
-			},
-			{
-				ApiKey:     1,
-				MinVersion: 7,
-				MaxVersion: 11,
-			},
-		},
+		t:       t,
+		apiKeys: apiKeys,
 	}
 }
 
@@ -1531,6 +1614,17 @@ func (m *MockApiVersionsResponse) SetApiKeys(apiKeys []ApiVersionsResponseKey) *
 
 func (m *MockApiVersionsResponse) For(reqBody VersionedDecoder) EncoderWithHeader {
 	req := reqBody.(*ApiVersionsRequest)
+	// like Kafka, reply with the versions of ApiVersionsRequest supported by
+	// the broker if the version of the request is not (KIP-511)
+	for _, key := range m.apiKeys {
+		if key.ApiKey == req.APIKey() && key.MaxVersion < req.Version {
+			return &ApiVersionsResponse{
+				Version:   0,
+				ErrorCode: int16(ErrUnsupportedVersion),
+				ApiKeys:   []ApiVersionsResponseKey{key},
+			}
+		}
+	}
 	res := &ApiVersionsResponse{
 		Version: req.Version,
 		ApiKeys: m.apiKeys,
@@ -234,6 +234,7 @@ func (c *Consumer) ExpectConsumePartition(topic string, partition int32, offset
 			messages:            make(chan *sarama.ConsumerMessage, c.config.ChannelBufferSize),
 			suppressedMessages:  make(chan *sarama.ConsumerMessage, c.config.ChannelBufferSize),
 			errors:              make(chan *sarama.ConsumerError, c.config.ChannelBufferSize),
+			events:              make(chan sarama.PartitionEvent, c.config.ChannelBufferSize),
 		}
 	}
 
@@ -260,6 +261,7 @@ type PartitionConsumer struct {
 	suppressedMessages            chan *sarama.ConsumerMessage
 	suppressedHighWaterMarkOffset int64
 	errors                        chan *sarama.ConsumerError
+	events                        chan sarama.PartitionEvent
 	singleClose                   sync.Once
 	consumed                      bool
 	errorsShouldBeDrained         bool
@@ -278,6 +280,7 @@ func (pc *PartitionConsumer) AsyncClose() {
 			close(pc.suppressedMessages)
 			close(pc.messages)
 			close(pc.errors)
+			close(pc.events)
 		},
 	)
 }
@@ -333,6 +336,14 @@ func (pc *PartitionConsumer) Close() error {
 		}
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range pc.events {
+			// drain
+		}
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -350,6 +361,11 @@ func (pc *PartitionConsumer) Errors() <-chan *sarama.ConsumerError {
 	return pc.errors
 }
 
+// Events implements the Events method from the sarama.PartitionConsumer interface.
+func (pc *PartitionConsumer) Events() <-chan sarama.PartitionEvent {
+	return pc.events
+}
+
 // Messages implements the Messages method from the sarama.PartitionConsumer interface.
 func (pc *PartitionConsumer) Messages() <-chan *sarama.ConsumerMessage {
 	return pc.messages
@@ -433,6 +449,14 @@ func (pc *PartitionConsumer) YieldError(err error) *PartitionConsumer {
 	return pc
 }
 
+// YieldEvent will yield a PartitionEOF or PartitionIdle event on the Events
+// channel of this partition consumer when it is consumed.
+func (pc *PartitionConsumer) YieldEvent(event sarama.PartitionEvent) *PartitionConsumer {
+	pc.events <- event
+
+	return pc
+}
+
 // ExpectMessagesDrainedOnClose sets an expectation on the partition consumer
 // that the messages channel will be fully drained when Close is called. If this
 // expectation is not met, an error is reported to the error reporter.
@@ -430,3 +430,24 @@ func TestConsumerInvalidConfiguration(t *testing.T) {
 		t.Errorf("Unexpected error: %s", trm.errors[0])
 	}
 }
+
+func TestConsumerYieldsPartitionEvents(t *testing.T) {
+	consumer := NewConsumer(t, NewTestConfig())
+	defer func() {
+		if err := consumer.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	consumer.ExpectConsumePartition("test", 0, sarama.OffsetOldest).
+		YieldEvent(&sarama.PartitionEOF{Topic: "test", Partition: 0, Offset: 10})
+
+	pc, err := consumer.ConsumePartition("test", 0, sarama.OffsetOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eof, ok := (<-pc.Events()).(*sarama.PartitionEOF)
+	if !ok || eof.Offset != 10 {
+		t.Error("Event was not as expected:", eof)
+	}
+}
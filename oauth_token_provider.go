@@ -0,0 +1,339 @@
+package sarama
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClientCredentialsConfig is the configuration of a
+// ClientCredentialsTokenProvider.
+type ClientCredentialsConfig struct {
+	// TokenURL is the URL of the token endpoint of the OAuth 2.0
+	// authorization server.
+	TokenURL string
+	// ClientID and ClientSecret are the credentials of the client. They are
+	// sent to the token endpoint using HTTP basic authentication.
+	ClientID     string
+	ClientSecret string
+	// Scopes are the optional scopes requested for the access token.
+	Scopes []string
+	// Audience is the optional audience requested for the access token.
+	Audience string
+	// Extensions are the optional SASL extensions sent along with the access
+	// token to the broker (KIP-342).
+	Extensions map[string]string
+	// RefreshWindowFactor is the fraction of the lifetime of the access token
+	// after which it is refreshed in the background (default 0.8).
+	RefreshWindowFactor float64
+	// RetryBackoff is the initial backoff between failed attempts to refresh
+	// the access token, it is doubled after each failure up to
+	// RetryBackoffMax (default 100ms and 10s).
+	RetryBackoff    time.Duration
+	RetryBackoffMax time.Duration
+	// Timeout is the timeout of a request to the token endpoint (default 10s).
+	Timeout time.Duration
+	// HTTPClient is the client used to send requests to the token endpoint
+	// (default http.DefaultClient).
+	HTTPClient *http.Client
+}
+
+// ClientCredentialsTokenProvider is an AccessTokenProvider that gets access
+// tokens from an OAuth 2.0 authorization server using the client credentials
+// grant (KIP-768). Access tokens are cached and refreshed in the background
+// once RefreshWindowFactor of their lifetime has passed.
+type ClientCredentialsTokenProvider struct {
+	conf ClientCredentialsConfig
+
+	lock         sync.Mutex
+	token        *AccessToken
+	expiry       time.Time
+	backoff      time.Duration
+	refreshTimer *time.Timer
+	closed       bool
+	// pending is the request in flight for callers of Token without an
+	// unexpired access token, which wait for it without holding lock.
+	pending *tokenRequest
+}
+
+// tokenRequest is a request to the token endpoint shared by the concurrent
+// callers of Token. done is closed once its result is set.
+type tokenRequest struct {
+	done   chan none
+	token  *AccessToken
+	expiry time.Time
+	err    error
+}
+
+// NewClientCredentialsTokenProvider creates a ClientCredentialsTokenProvider
+// with the given configuration. No access token is requested until Token is
+// called for the first time.
+func NewClientCredentialsTokenProvider(conf ClientCredentialsConfig) (*ClientCredentialsTokenProvider, error) {
+	if conf.TokenURL == "" {
+		return nil, ConfigurationError("ClientCredentialsConfig.TokenURL must not be empty")
+	}
+	if _, err := url.Parse(conf.TokenURL); err != nil {
+		return nil, ConfigurationError(fmt.Sprintf("ClientCredentialsConfig.TokenURL is invalid: %v", err))
+	}
+	if conf.ClientID == "" {
+		return nil, ConfigurationError("ClientCredentialsConfig.ClientID must not be empty")
+	}
+	if _, ok := conf.Extensions[SASLExtKeyAuth]; ok {
+		return nil, ConfigurationError(fmt.Sprintf("ClientCredentialsConfig.Extensions must not contain %s", SASLExtKeyAuth))
+	}
+
+	if conf.RefreshWindowFactor == 0 {
+		conf.RefreshWindowFactor = 0.8
+	}
+	if conf.RetryBackoff == 0 {
+		conf.RetryBackoff = 100 * time.Millisecond
+	}
+	if conf.RetryBackoffMax == 0 {
+		conf.RetryBackoffMax = 10 * time.Second
+	}
+	if conf.Timeout == 0 {
+		conf.Timeout = 10 * time.Second
+	}
+	if conf.HTTPClient == nil {
+		conf.HTTPClient = http.DefaultClient
+	}
+
+	switch {
+	case conf.RefreshWindowFactor < 0.5 || conf.RefreshWindowFactor > 1:
+		return nil, ConfigurationError("ClientCredentialsConfig.RefreshWindowFactor must be between 0.5 and 1")
+	case conf.RetryBackoff < 0:
+		return nil, ConfigurationError("ClientCredentialsConfig.RetryBackoff must be > 0")
+	case conf.RetryBackoffMax < conf.RetryBackoff:
+		return nil, ConfigurationError("ClientCredentialsConfig.RetryBackoffMax must be >= RetryBackoff")
+	case conf.Timeout < 0:
+		return nil, ConfigurationError("ClientCredentialsConfig.Timeout must be > 0")
+	}
+
+	return &ClientCredentialsTokenProvider{conf: conf}, nil
+}
+
+// Token returns the cached access token, or requests a new one from the token
+// endpoint if there is no unexpired access token. Concurrent callers share a
+// single request.
+func (p *ClientCredentialsTokenProvider) Token() (*AccessToken, error) {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return nil, ErrClosedTokenProvider
+	}
+	if p.token != nil && time.Now().Before(p.expiry) {
+		token := p.token
+		p.lock.Unlock()
+		return token, nil
+	}
+
+	req := p.pending
+	if req != nil {
+		p.lock.Unlock()
+		<-req.done
+		return req.token, req.err
+	}
+	req = &tokenRequest{done: make(chan none)}
+	p.pending = req
+	p.lock.Unlock()
+
+	req.token, req.expiry, req.err = p.requestToken()
+
+	p.lock.Lock()
+	p.pending = nil
+	if req.err == nil {
+		if p.closed {
+			req.token, req.err = nil, ErrClosedTokenProvider
+		} else {
+			p.setToken(req.token, req.expiry)
+		}
+	}
+	p.lock.Unlock()
+	close(req.done)
+
+	return req.token, req.err
+}
+
+// Close stops refreshing the access token in the background.
+func (p *ClientCredentialsTokenProvider) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.closed = true
+	p.token = nil
+	if p.refreshTimer != nil {
+		p.refreshTimer.Stop()
+		p.refreshTimer = nil
+	}
+	return nil
+}
+
+// setToken caches the access token and schedules its refresh.
+// p.lock must be held by caller
+func (p *ClientCredentialsTokenProvider) setToken(token *AccessToken, expiry time.Time) {
+	p.token = token
+	p.expiry = expiry
+	p.backoff = 0
+
+	lifetime := time.Until(expiry)
+	p.scheduleRefresh(time.Duration(float64(lifetime) * p.conf.RefreshWindowFactor))
+}
+
+// p.lock must be held by caller
+func (p *ClientCredentialsTokenProvider) scheduleRefresh(delay time.Duration) {
+	if p.refreshTimer != nil {
+		p.refreshTimer.Stop()
+	}
+	p.refreshTimer = time.AfterFunc(delay, p.refresh)
+}
+
+func (p *ClientCredentialsTokenProvider) refresh() {
+	// Token keeps returning the cached access token while it is refreshed.
+	token, expiry, err := p.requestToken()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return
+	}
+	if err == nil {
+		p.setToken(token, expiry)
+		return
+	}
+
+	if p.backoff == 0 {
+		p.backoff = p.conf.RetryBackoff
+	} else if p.backoff *= 2; p.backoff > p.conf.RetryBackoffMax {
+		p.backoff = p.conf.RetryBackoffMax
+	}
+	Logger.Printf("Failed to refresh OAuth access token, retrying in %s: %v\n", p.backoff, err)
+	p.scheduleRefresh(p.backoff)
+}
+
+// tokenResponse is a successful response of the token endpoint (RFC 6749
+// section 5.1) or an error response (RFC 6749 section 5.2).
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// requestToken requests a new access token from the token endpoint and
+// returns it along with its expiry.
+func (p *ClientCredentialsTokenProvider) requestToken() (*AccessToken, time.Time, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(p.conf.Scopes) > 0 {
+		form.Set("scope", strings.Join(p.conf.Scopes, " "))
+	}
+	if p.conf.Audience != "" {
+		form.Set("audience", p.conf.Audience)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.conf.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.conf.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.conf.ClientID), url.QueryEscape(p.conf.ClientSecret))
+
+	requestTime := time.Now()
+	res, err := p.conf.HTTPClient.Do(req)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("kafka: failed to request OAuth access token: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("kafka: failed to read OAuth token response: %w", err)
+	}
+
+	var tokenRes tokenResponse
+	if err := json.Unmarshal(body, &tokenRes); err != nil && res.StatusCode == http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("kafka: failed to decode OAuth token response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK || tokenRes.Error != "" {
+		if tokenRes.Error == "" {
+			return nil, time.Time{}, fmt.Errorf("kafka: token endpoint returned %s", res.Status)
+		}
+		return nil, time.Time{}, fmt.Errorf("kafka: token endpoint returned %s: %s %s",
+			res.Status, tokenRes.Error, tokenRes.ErrorDescription)
+	}
+	if tokenRes.AccessToken == "" {
+		return nil, time.Time{}, fmt.Errorf("%w: token endpoint returned no access_token", ErrInvalidAccessToken)
+	}
+	if tokenRes.TokenType != "" && !strings.EqualFold(tokenRes.TokenType, "bearer") {
+		return nil, time.Time{}, fmt.Errorf("%w: unsupported token_type %q", ErrInvalidAccessToken, tokenRes.TokenType)
+	}
+
+	var expiry time.Time
+	if tokenRes.ExpiresIn > 0 {
+		expiry = requestTime.Add(time.Duration(tokenRes.ExpiresIn) * time.Second)
+	}
+	jwtExpiry, err := jwtExpiry(tokenRes.AccessToken)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if !jwtExpiry.IsZero() && (expiry.IsZero() || jwtExpiry.Before(expiry)) {
+		expiry = jwtExpiry
+	}
+	if expiry.IsZero() {
+		return nil, time.Time{}, fmt.Errorf("%w: the expiry of the access token is unknown", ErrInvalidAccessToken)
+	}
+	if !expiry.After(time.Now()) {
+		return nil, time.Time{}, fmt.Errorf("%w: the access token expired at %s", ErrInvalidAccessToken, expiry)
+	}
+
+	token := &AccessToken{Token: tokenRes.AccessToken}
+	if len(p.conf.Extensions) > 0 {
+		token.Extensions = make(map[string]string, len(p.conf.Extensions))
+		for k, v := range p.conf.Extensions {
+			token.Extensions[k] = v
+		}
+	}
+	return token, expiry, nil
+}
+
+// jwtExpiry returns the expiry of the access token from its exp claim, if the
+// access token is a JWT. The zero time is returned for opaque access tokens.
+// The signature of the JWT is not verified, this is left to the broker.
+func jwtExpiry(accessToken string) (time.Time, error) {
+	parts := strings.Split(accessToken, ".")
+	if len(parts) != 3 {
+		return time.Time{}, nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: malformed JWT payload: %v", ErrInvalidAccessToken, err)
+	}
+
+	var claims struct {
+		Exp *json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("%w: malformed JWT claims: %v", ErrInvalidAccessToken, err)
+	}
+	if claims.Exp == nil {
+		return time.Time{}, fmt.Errorf("%w: JWT has no exp claim", ErrInvalidAccessToken)
+	}
+	exp, err := claims.Exp.Float64()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: malformed JWT exp claim: %v", ErrInvalidAccessToken, err)
+	}
+	return time.Unix(0, int64(exp*float64(time.Second))), nil
+}
@@ -0,0 +1,306 @@
+package sarama
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestJWT(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"none"}`)) + "." + encode(payload) + "." + encode([]byte("signature"))
+}
+
+// newTestTokenServer returns a token endpoint that issues the access tokens
+// returned by issue and counts the number of requests.
+func newTestTokenServer(t *testing.T, issue func(r *http.Request) (int, interface{})) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		status, body := issue(r)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestClientCredentialsTokenProvider(t *testing.T) {
+	server, requests := newTestTokenServer(t, func(r *http.Request) (int, interface{}) {
+		if r.Method != http.MethodPost || r.PostForm.Get("grant_type") != "client_credentials" {
+			t.Errorf("Unexpected token request %s %v", r.Method, r.PostForm)
+		}
+		// the credentials are form-urlencoded before basic auth (RFC 6749 section 2.3.1)
+		id, secret, _ := r.BasicAuth()
+		if secret, _ = url.QueryUnescape(secret); id != "my-client" || secret != "s%cr%t" {
+			t.Errorf("Unexpected client credentials %q %q", id, secret)
+		}
+		if r.PostForm.Get("scope") != "kafka profile" || r.PostForm.Get("audience") != "my-cluster" {
+			t.Errorf("Unexpected scope and audience %v", r.PostForm)
+		}
+		return http.StatusOK, map[string]interface{}{
+			"access_token": "opaque-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		}
+	})
+
+	provider, err := NewClientCredentialsTokenProvider(ClientCredentialsConfig{
+		TokenURL:     server.URL,
+		ClientID:     "my-client",
+		ClientSecret: "s%cr%t",
+		Scopes:       []string{"kafka", "profile"},
+		Audience:     "my-cluster",
+		Extensions:   map[string]string{"logicalCluster": "lkc-123"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, provider)
+
+	for i := 0; i < 3; i++ {
+		token, err := provider.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.Token != "opaque-token" || token.Extensions["logicalCluster"] != "lkc-123" {
+			t.Errorf("Unexpected token %+v", token)
+		}
+	}
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Errorf("Expected the token to be cached, got %d token requests", n)
+	}
+}
+
+func TestClientCredentialsTokenProviderRefresh(t *testing.T) {
+	var issued int32
+	server, requests := newTestTokenServer(t, func(r *http.Request) (int, interface{}) {
+		n := atomic.AddInt32(&issued, 1)
+		if n == 2 {
+			return http.StatusServiceUnavailable, map[string]string{"error": "temporarily_unavailable"}
+		}
+		return http.StatusOK, map[string]interface{}{
+			"access_token": newTestJWT(t, map[string]interface{}{
+				"sub": "my-client",
+				"exp": float64(time.Now().Add(400*time.Millisecond).UnixNano()) / float64(time.Second),
+			}),
+			"token_type": "bearer",
+			"expires_in": 3600,
+		}
+	})
+
+	provider, err := NewClientCredentialsTokenProvider(ClientCredentialsConfig{
+		TokenURL:            server.URL,
+		ClientID:            "my-client",
+		RefreshWindowFactor: 0.5,
+		RetryBackoff:        10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := provider.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the JWT exp claim takes precedence over expires_in, the token is
+	// refreshed in the background after 200ms, and again after the failure
+	timeout := time.After(time.Second)
+	for atomic.LoadInt32(requests) < 3 {
+		select {
+		case <-timeout:
+			t.Fatalf("Expected the token to be refreshed in the background, got %d token requests", atomic.LoadInt32(requests))
+		default:
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	second, err := provider.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Token == first.Token {
+		t.Error("Expected a refreshed token")
+	}
+
+	safeClose(t, provider)
+	closedRequests := atomic.LoadInt32(requests)
+	time.Sleep(500 * time.Millisecond)
+	if n := atomic.LoadInt32(requests); n != closedRequests {
+		t.Errorf("Expected no token requests after close, got %d instead of %d", n, closedRequests)
+	}
+	if _, err := provider.Token(); !errors.Is(err, ErrClosedTokenProvider) {
+		t.Errorf("Expected ErrClosedTokenProvider, got %v", err)
+	}
+}
+
+func TestClientCredentialsTokenProviderConcurrentRequests(t *testing.T) {
+	release := make(chan none, 2)
+	server, requests := newTestTokenServer(t, func(r *http.Request) (int, interface{}) {
+		<-release
+		return http.StatusOK, map[string]interface{}{
+			"access_token": "opaque-token",
+			"expires_in":   3600,
+		}
+	})
+	newProvider := func() *ClientCredentialsTokenProvider {
+		provider, err := NewClientCredentialsTokenProvider(ClientCredentialsConfig{
+			TokenURL: server.URL,
+			ClientID: "my-client",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return provider
+	}
+	awaitRequests := func(n int32) {
+		for atomic.LoadInt32(requests) < n {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// concurrent callers share the token request
+	provider := newProvider()
+	defer safeClose(t, provider)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if token, err := provider.Token(); err != nil || token.Token != "opaque-token" {
+				t.Errorf("Unexpected token %+v: %v", token, err)
+			}
+		}()
+	}
+	awaitRequests(1)
+	time.Sleep(10 * time.Millisecond)
+	release <- none{}
+	wg.Wait()
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Errorf("Expected a single token request, got %d", n)
+	}
+
+	// the lock is not held while the token is requested
+	provider = newProvider()
+	errs := make(chan error, 1)
+	go func() {
+		_, err := provider.Token()
+		errs <- err
+	}()
+	awaitRequests(2)
+	closed := make(chan none)
+	go func() {
+		defer close(closed)
+		safeClose(t, provider)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Error("Close blocked on the token request")
+	}
+	release <- none{}
+	if err := <-errs; !errors.Is(err, ErrClosedTokenProvider) {
+		t.Errorf("Expected ErrClosedTokenProvider, got %v", err)
+	}
+}
+
+func TestClientCredentialsTokenProviderInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response interface{}
+		err      error
+	}{
+		{
+			"expired JWT",
+			http.StatusOK,
+			map[string]interface{}{
+				"access_token": newTestJWT(t, map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix()}),
+				"expires_in":   3600,
+			},
+			ErrInvalidAccessToken,
+		},
+		{
+			"JWT without exp claim",
+			http.StatusOK,
+			map[string]interface{}{"access_token": newTestJWT(t, map[string]interface{}{"sub": "my-client"})},
+			ErrInvalidAccessToken,
+		},
+		{
+			"opaque token without expires_in",
+			http.StatusOK,
+			map[string]interface{}{"access_token": "opaque-token"},
+			ErrInvalidAccessToken,
+		},
+		{
+			"unsupported token type",
+			http.StatusOK,
+			map[string]interface{}{"access_token": "opaque-token", "token_type": "mac", "expires_in": 3600},
+			ErrInvalidAccessToken,
+		},
+		{
+			"error response",
+			http.StatusUnauthorized,
+			map[string]string{"error": "invalid_client", "error_description": "unknown client"},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newTestTokenServer(t, func(r *http.Request) (int, interface{}) {
+				return tt.status, tt.response
+			})
+			provider, err := NewClientCredentialsTokenProvider(ClientCredentialsConfig{
+				TokenURL: server.URL,
+				ClientID: "my-client",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer safeClose(t, provider)
+
+			_, err = provider.Token()
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("Expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestClientCredentialsTokenProviderConfigValidation(t *testing.T) {
+	tests := []ClientCredentialsConfig{
+		{ClientID: "my-client"},
+		{TokenURL: "http://localhost/token"},
+		{TokenURL: "http://localhost/token", ClientID: "my-client", RefreshWindowFactor: 0.2},
+		{TokenURL: "http://localhost/token", ClientID: "my-client", RetryBackoff: time.Second, RetryBackoffMax: time.Millisecond},
+		{TokenURL: "http://localhost/token", ClientID: "my-client", Extensions: map[string]string{SASLExtKeyAuth: "x"}},
+	}
+	for i, conf := range tests {
+		var configErr ConfigurationError
+		if _, err := NewClientCredentialsTokenProvider(conf); !errors.As(err, &configErr) {
+			t.Errorf("[%d] Expected a ConfigurationError, got %v", i, err)
+		}
+	}
+}
@@ -197,6 +197,20 @@ func (r *OffsetCommitRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *OffsetCommitRequest) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *OffsetCommitRequest) checkVersion(v int16) error {
+	switch {
+	case r.Version >= 1 && v < 1:
+		return errors.New("committing offsets to Kafka requires version 1")
+	case r.GroupInstanceId != nil && v < 7:
+		return errors.New("the group instance ID requires version 7")
+	}
+	return nil
+}
+
 func (r *OffsetCommitRequest) HeaderVersion() int16 {
 	return 1
 }
@@ -1,5 +1,7 @@
 package sarama
 
+import "errors"
+
 type OffsetFetchRequest struct {
 	Version       int16
 	ConsumerGroup string
@@ -200,6 +202,22 @@ func (r *OffsetFetchRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *OffsetFetchRequest) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *OffsetFetchRequest) checkVersion(v int16) error {
+	switch {
+	case r.Version >= 1 && v < 1:
+		return errors.New("fetching offsets from Kafka requires version 1")
+	case r.partitions == nil && v < 2:
+		return errors.New("fetching all the offsets requires version 2")
+	case r.RequireStable && v < 7:
+		return errors.New("requiring stable offsets requires version 7")
+	}
+	return nil
+}
+
 func (r *OffsetFetchRequest) HeaderVersion() int16 {
 	if r.Version >= 6 {
 		return 2
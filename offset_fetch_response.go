@@ -230,6 +230,10 @@ func (r *OffsetFetchResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *OffsetFetchResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *OffsetFetchResponse) HeaderVersion() int16 {
 	if r.Version >= 6 {
 		return 1
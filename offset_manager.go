@@ -1,6 +1,8 @@
 package sarama
 
 import (
+	"context"
+	"fmt"
 	"sync"
 	"time"
 )
@@ -23,6 +25,43 @@ type OffsetManager interface {
 	// Commit commits the offsets. This method can be used if AutoCommit.Enable is
 	// set to false.
 	Commit()
+
+	// CommitSync commits the marked offsets and blocks until the coordinator has
+	// responded or ctx is done. It returns nil once every marked offset has been
+	// committed, OffsetCommitErrors if some partitions failed, or the error that
+	// prevented the request from being sent. Returning because ctx is done does not
+	// abort the commit, which still completes in the background.
+	CommitSync(ctx context.Context) error
+
+	// CommitAsync commits the marked offsets in the background and invokes
+	// callback, if not nil, with the per-partition results once the coordinator
+	// has responded. Callbacks of concurrent commits may run concurrently and in
+	// any order.
+	CommitAsync(callback OffsetCommitCallback)
+}
+
+// OffsetCommitResult is the outcome of committing the offset of a single partition.
+type OffsetCommitResult struct {
+	Topic       string
+	Partition   int32
+	Offset      int64
+	LeaderEpoch int32
+	Metadata    string
+	// Err is nil if the offset was committed.
+	Err error
+}
+
+// OffsetCommitCallback is invoked by CommitAsync with the per-partition results
+// of a commit. err is set if the commit request itself failed, in which case
+// results is empty. Both are empty when there was nothing to commit.
+type OffsetCommitCallback func(results []*OffsetCommitResult, err error)
+
+// OffsetCommitErrors is returned by CommitSync when the offsets of one or more
+// partitions could not be committed. Each entry carries the error of its partition.
+type OffsetCommitErrors []*OffsetCommitResult
+
+func (oe OffsetCommitErrors) Error() string {
+	return fmt.Sprintf("kafka: %d errors while committing offsets", len(oe))
 }
 
 type offsetManager struct {
@@ -36,6 +75,8 @@ type offsetManager struct {
 	groupInstanceId *string
 	generation      int32
 
+	store OffsetStore
+
 	broker     *Broker
 	brokerLock sync.RWMutex
 
@@ -69,6 +110,7 @@ func newOffsetManagerFromClient(group, memberID string, generation int32, client
 
 		memberID:   memberID,
 		generation: generation,
+		store:      conf.Consumer.Offsets.Store,
 
 		closing: make(chan none),
 		closed:  make(chan none),
@@ -145,6 +187,14 @@ func (om *offsetManager) computeBackoff(retries int) time.Duration {
 }
 
 func (om *offsetManager) fetchInitialOffset(topic string, partition int32, retries int) (int64, int32, string, error) {
+	if om.store != nil {
+		stored, err := om.store.FetchOffset(om.group, topic, partition)
+		if err != nil {
+			return 0, 0, "", err
+		}
+		return stored.Offset, stored.LeaderEpoch, stored.Metadata, nil
+	}
+
 	broker, err := om.coordinator()
 	if err != nil {
 		if retries <= 0 {
@@ -154,7 +204,7 @@ func (om *offsetManager) fetchInitialOffset(topic string, partition int32, retri
 	}
 
 	partitions := map[string][]int32{topic: {partition}}
-	req := NewOffsetFetchRequest(om.conf.Version, om.group, partitions)
+	req := NewOffsetFetchRequest(om.conf.versionCeiling(), om.group, partitions)
 	resp, err := broker.FetchOffset(req)
 	if err != nil {
 		if retries <= 0 {
@@ -246,20 +296,72 @@ func (om *offsetManager) mainLoop() {
 }
 
 func (om *offsetManager) Commit() {
-	om.flushToBroker()
+	om.commit()
+}
+
+func (om *offsetManager) CommitSync(ctx context.Context) error {
+	type commitOutcome struct {
+		results []*OffsetCommitResult
+		err     error
+	}
+
+	done := make(chan commitOutcome, 1)
+	go withRecover(func() {
+		results, err := om.commit()
+		done <- commitOutcome{results, err}
+	})
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case outcome := <-done:
+		if outcome.err != nil {
+			return outcome.err
+		}
+		var errs OffsetCommitErrors
+		for _, result := range outcome.results {
+			if result.Err != nil {
+				errs = append(errs, result)
+			}
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		return nil
+	}
+}
+
+func (om *offsetManager) CommitAsync(callback OffsetCommitCallback) {
+	go withRecover(func() {
+		results, err := om.commit()
+		if callback != nil {
+			callback(results, err)
+		}
+	})
+}
+
+func (om *offsetManager) commit() ([]*OffsetCommitResult, error) {
+	results, err := om.flushToBroker()
 	om.releasePOMs(false)
+	return results, err
 }
 
-func (om *offsetManager) flushToBroker() {
+// flushToBroker commits all dirty offsets and returns the per-partition results,
+// or the error that prevented the request from being sent.
+func (om *offsetManager) flushToBroker() ([]*OffsetCommitResult, error) {
+	if om.store != nil {
+		return om.flushToStore()
+	}
+
 	req := om.constructRequest()
 	if req == nil {
-		return
+		return nil, nil
 	}
 
 	broker, err := om.coordinator()
 	if err != nil {
 		om.handleError(err)
-		return
+		return nil, err
 	}
 
 	resp, err := broker.CommitOffset(req)
@@ -267,50 +369,126 @@ func (om *offsetManager) flushToBroker() {
 		om.handleError(err)
 		om.releaseCoordinator(broker)
 		_ = broker.Close()
-		return
+		return nil, err
 	}
 
-	om.handleResponse(broker, req, resp)
+	return om.handleResponse(broker, req, resp), nil
+}
+
+// flushToStore commits all dirty offsets to the configured OffsetStore.
+func (om *offsetManager) flushToStore() ([]*OffsetCommitResult, error) {
+	var offsets []*StoredOffset
+	om.pomsLock.RLock()
+	for _, topicManagers := range om.poms {
+		for _, pom := range topicManagers {
+			pom.lock.Lock()
+			if pom.dirty {
+				offsets = append(offsets, &StoredOffset{
+					Topic:       pom.topic,
+					Partition:   pom.partition,
+					Offset:      pom.offset,
+					LeaderEpoch: pom.leaderEpoch,
+					Metadata:    pom.metadata,
+				})
+			}
+			pom.lock.Unlock()
+		}
+	}
+	om.pomsLock.RUnlock()
+
+	if len(offsets) == 0 {
+		return nil, nil
+	}
+
+	results, err := om.store.CommitOffsets(om.group, offsets)
+	if err != nil {
+		om.handleError(err)
+		return nil, err
+	}
+
+	for _, result := range results {
+		pom := om.findPOM(result.Topic, result.Partition)
+		if pom == nil {
+			continue
+		}
+		if result.Err != nil {
+			pom.handleError(result.Err)
+			continue
+		}
+		pom.updateCommitted(result.Offset, result.Metadata)
+	}
+	return results, nil
 }
 
 func (om *offsetManager) constructRequest() *OffsetCommitRequest {
+	r := om.newCommitRequest()
+
+	// commit timestamp was only briefly supported in V1 where we set it to
+	// ReceiveTime (-1) to tell the broker to set it to the time when the commit
+	// request was received
+	var commitTimestamp int64
+	if r.Version == 1 {
+		commitTimestamp = ReceiveTime
+	}
+
+	om.pomsLock.RLock()
+	defer om.pomsLock.RUnlock()
+
+	for _, topicManagers := range om.poms {
+		for _, pom := range topicManagers {
+			pom.lock.Lock()
+			if pom.dirty {
+				r.AddBlockWithLeaderEpoch(pom.topic, pom.partition, pom.offset, pom.leaderEpoch, commitTimestamp, pom.metadata)
+			}
+			pom.lock.Unlock()
+		}
+	}
+
+	if len(r.blocks) > 0 {
+		return r
+	}
+
+	return nil
+}
+
+// newCommitRequest returns an empty OffsetCommitRequest for the configured
+// version, group and generation.
+func (om *offsetManager) newCommitRequest() *OffsetCommitRequest {
+	return newOffsetCommitRequest(om.conf, om.group, om.memberID, om.generation, om.groupInstanceId)
+}
+
+// newOffsetCommitRequest returns an empty OffsetCommitRequest of the highest
+// version allowed by the Version of conf.
+func newOffsetCommitRequest(conf *Config, group, memberID string, generation int32, groupInstanceId *string) *OffsetCommitRequest {
 	r := &OffsetCommitRequest{
 		Version:                 1,
-		ConsumerGroup:           om.group,
-		ConsumerID:              om.memberID,
-		ConsumerGroupGeneration: om.generation,
+		ConsumerGroup:           group,
+		ConsumerID:              memberID,
+		ConsumerGroupGeneration: generation,
 	}
 	// Version 1 adds timestamp and group membership information, as well as the commit timestamp.
 	//
 	// Version 2 adds retention time.  It removes the commit timestamp added in version 1.
-	if om.conf.Version.IsAtLeast(V0_9_0_0) {
+	if conf.versionCeiling().IsAtLeast(V0_9_0_0) {
 		r.Version = 2
 	}
 	// Version 3 and 4 are the same as version 2.
-	if om.conf.Version.IsAtLeast(V0_11_0_0) {
+	if conf.versionCeiling().IsAtLeast(V0_11_0_0) {
 		r.Version = 3
 	}
-	if om.conf.Version.IsAtLeast(V2_0_0_0) {
+	if conf.versionCeiling().IsAtLeast(V2_0_0_0) {
 		r.Version = 4
 	}
 	// Version 5 removes the retention time, which is now controlled only by a broker configuration.
 	//
 	// Version 6 adds the leader epoch for fencing.
-	if om.conf.Version.IsAtLeast(V2_1_0_0) {
+	if conf.versionCeiling().IsAtLeast(V2_1_0_0) {
 		r.Version = 6
 	}
 	// version 7 adds a new field called groupInstanceId to indicate member identity across restarts.
-	if om.conf.Version.IsAtLeast(V2_3_0_0) {
+	if conf.versionCeiling().IsAtLeast(V2_3_0_0) {
 		r.Version = 7
-		r.GroupInstanceId = om.groupInstanceId
-	}
-
-	// commit timestamp was only briefly supported in V1 where we set it to
-	// ReceiveTime (-1) to tell the broker to set it to the time when the commit
-	// request was received
-	var commitTimestamp int64
-	if r.Version == 1 {
-		commitTimestamp = ReceiveTime
+		r.GroupInstanceId = groupInstanceId
 	}
 
 	// request controlled retention was only supported from V2-V4 (it became
@@ -319,32 +497,15 @@ func (om *offsetManager) constructRequest() *OffsetCommitRequest {
 	if r.Version >= 2 && r.Version < 5 {
 		// Map Sarama's default of 0 to Kafka's default of -1
 		r.RetentionTime = -1
-		if om.conf.Consumer.Offsets.Retention > 0 {
-			r.RetentionTime = int64(om.conf.Consumer.Offsets.Retention / time.Millisecond)
+		if conf.Consumer.Offsets.Retention > 0 {
+			r.RetentionTime = int64(conf.Consumer.Offsets.Retention / time.Millisecond)
 		}
 	}
 
-	om.pomsLock.RLock()
-	defer om.pomsLock.RUnlock()
-
-	for _, topicManagers := range om.poms {
-		for _, pom := range topicManagers {
-			pom.lock.Lock()
-			if pom.dirty {
-				r.AddBlockWithLeaderEpoch(pom.topic, pom.partition, pom.offset, pom.leaderEpoch, commitTimestamp, pom.metadata)
-			}
-			pom.lock.Unlock()
-		}
-	}
-
-	if len(r.blocks) > 0 {
-		return r
-	}
-
-	return nil
+	return r
 }
 
-func (om *offsetManager) handleResponse(broker *Broker, req *OffsetCommitRequest, resp *OffsetCommitResponse) {
+func (om *offsetManager) handleResponse(broker *Broker, req *OffsetCommitRequest, resp *OffsetCommitResponse) (results []*OffsetCommitResult) {
 	om.pomsLock.RLock()
 	defer om.pomsLock.RUnlock()
 
@@ -354,21 +515,35 @@ func (om *offsetManager) handleResponse(broker *Broker, req *OffsetCommitRequest
 				continue
 			}
 
+			block := req.blocks[pom.topic][pom.partition]
+			result := &OffsetCommitResult{
+				Topic:       pom.topic,
+				Partition:   pom.partition,
+				Offset:      block.offset,
+				LeaderEpoch: block.committedLeaderEpoch,
+				Metadata:    block.metadata,
+			}
+			results = append(results, result)
+
 			var err KError
 			var ok bool
 
 			if resp.Errors[pom.topic] == nil {
+				result.Err = ErrIncompleteResponse
 				pom.handleError(ErrIncompleteResponse)
 				continue
 			}
 			if err, ok = resp.Errors[pom.topic][pom.partition]; !ok {
+				result.Err = ErrIncompleteResponse
 				pom.handleError(ErrIncompleteResponse)
 				continue
 			}
+			if err != ErrNoError {
+				result.Err = err
+			}
 
 			switch err {
 			case ErrNoError:
-				block := req.blocks[pom.topic][pom.partition]
 				pom.updateCommitted(block.offset, block.metadata)
 			case ErrNotLeaderForPartition, ErrLeaderNotAvailable,
 				ErrConsumerCoordinatorNotAvailable, ErrNotCoordinatorForConsumer:
@@ -396,6 +571,7 @@ func (om *offsetManager) handleResponse(broker *Broker, req *OffsetCommitRequest
 			}
 		}
 	}
+	return results
 }
 
 func (om *offsetManager) handleError(err error) {
@@ -1,6 +1,7 @@
 package sarama
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync/atomic"
@@ -633,3 +634,76 @@ func TestConstructRequestRetentionTime(t *testing.T) {
 		}
 	}
 }
+
+func TestOffsetManagerHandleResponseResults(t *testing.T) {
+	conf := NewTestConfig()
+	om := &offsetManager{conf: conf}
+	pom0 := &partitionOffsetManager{parent: om, topic: "my_topic", partition: 0, offset: 10, metadata: "m0", dirty: true, errors: make(chan *ConsumerError, 1)}
+	pom1 := &partitionOffsetManager{parent: om, topic: "my_topic", partition: 1, offset: 20, metadata: "m1", dirty: true, errors: make(chan *ConsumerError, 1)}
+	om.poms = map[string]map[int32]*partitionOffsetManager{
+		"my_topic": {0: pom0, 1: pom1},
+	}
+
+	req := om.constructRequest()
+	resp := new(OffsetCommitResponse)
+	resp.AddError("my_topic", 0, ErrNoError)
+	resp.AddError("my_topic", 1, ErrOffsetMetadataTooLarge)
+
+	results := om.handleResponse(nil, req, resp)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	for _, result := range results {
+		switch result.Partition {
+		case 0:
+			if result.Err != nil || result.Offset != 10 || result.Metadata != "m0" {
+				t.Errorf("unexpected result for partition 0: %+v", result)
+			}
+		case 1:
+			if !errors.Is(result.Err, ErrOffsetMetadataTooLarge) || result.Offset != 20 {
+				t.Errorf("unexpected result for partition 1: %+v", result)
+			}
+		}
+	}
+	if pom0.dirty {
+		t.Error("expected committed partition to be clean")
+	}
+	if !pom1.dirty {
+		t.Error("expected failed partition to remain dirty")
+	}
+}
+
+func TestOffsetManagerCommitNothingMarked(t *testing.T) {
+	om := &offsetManager{
+		conf: NewTestConfig(),
+		poms: make(map[string]map[int32]*partitionOffsetManager),
+	}
+
+	if err := om.CommitSync(context.Background()); err != nil {
+		t.Error(err)
+	}
+
+	done := make(chan none)
+	om.CommitAsync(func(results []*OffsetCommitResult, err error) {
+		if len(results) != 0 || err != nil {
+			t.Errorf("expected empty results, got %v and %v", results, err)
+		}
+		close(done)
+	})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("callback was not invoked")
+	}
+}
+
+func TestOffsetCommitErrors(t *testing.T) {
+	var err error = OffsetCommitErrors{{Topic: "my_topic", Partition: 0, Err: ErrOffsetMetadataTooLarge}}
+	var commitErrs OffsetCommitErrors
+	if !errors.As(err, &commitErrs) || len(commitErrs) != 1 {
+		t.Fatalf("expected OffsetCommitErrors, got %v", err)
+	}
+	if err.Error() != "kafka: 1 errors while committing offsets" {
+		t.Error("unexpected error message", err)
+	}
+}
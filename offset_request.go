@@ -1,5 +1,7 @@
 package sarama
 
+import "errors"
+
 type offsetRequestBlock struct {
 	// currentLeaderEpoch contains the current leader epoch (used in version 4+).
 	currentLeaderEpoch int32
@@ -20,6 +22,10 @@ func (b *offsetRequestBlock) encode(pe packetEncoder, version int16) error {
 		pe.putInt32(b.maxNumOffsets)
 	}
 
+	if version >= 6 {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
@@ -41,9 +47,45 @@ func (b *offsetRequestBlock) Decode(pd packetDecoder, version int16) (err error)
 		}
 	}
 
+	if version >= 6 {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// newOffsetRequest returns an OffsetRequest with the highest version supported
+// by the given Kafka version.
+func newOffsetRequest(version KafkaVersion) *OffsetRequest {
+	request := &OffsetRequest{}
+	if version.IsAtLeast(V3_0_0_0) {
+		// Version 7 adds the max timestamp spec (KIP-734).
+		request.Version = 7
+	} else if version.IsAtLeast(V2_5_0_0) {
+		// Version 6 is the first flexible version.
+		request.Version = 6
+	} else if version.IsAtLeast(V2_2_0_0) {
+		// Version 5 is the same as version 4.
+		request.Version = 5
+	} else if version.IsAtLeast(V2_1_0_0) {
+		// Version 4 adds the current leader epoch, which is used for fencing.
+		request.Version = 4
+	} else if version.IsAtLeast(V2_0_0_0) {
+		// Version 3 is the same as version 2.
+		request.Version = 3
+	} else if version.IsAtLeast(V0_11_0_0) {
+		// Version 2 adds the isolation level, which is used for transactional reads.
+		request.Version = 2
+	} else if version.IsAtLeast(V0_10_1_0) {
+		// Version 1 removes MaxNumOffsets.  From this version forward, only a single
+		// offset can be returned.
+		request.Version = 1
+	}
+	return request
+}
+
 type OffsetRequest struct {
 	Version        int16
 	IsolationLevel IsolationLevel
@@ -64,25 +106,28 @@ func (r *OffsetRequest) Encode(pe packetEncoder) error {
 		pe.putBool(r.IsolationLevel == ReadCommitted)
 	}
 
-	err := pe.putArrayLength(len(r.blocks))
-	if err != nil {
+	if err := r.putArrayLength(pe, len(r.blocks)); err != nil {
 		return err
 	}
 	for topic, partitions := range r.blocks {
-		err = pe.putString(topic)
-		if err != nil {
+		if err := r.putString(pe, topic); err != nil {
 			return err
 		}
-		err = pe.putArrayLength(len(partitions))
-		if err != nil {
+		if err := r.putArrayLength(pe, len(partitions)); err != nil {
 			return err
 		}
 		for partition, block := range partitions {
 			pe.putInt32(partition)
-			if err = block.encode(pe, r.Version); err != nil {
+			if err := block.encode(pe, r.Version); err != nil {
 				return err
 			}
 		}
+		if r.isFlexible() {
+			pe.putEmptyTaggedFieldArray()
+		}
+	}
+	if r.isFlexible() {
+		pe.putEmptyTaggedFieldArray()
 	}
 	return nil
 }
@@ -110,20 +155,19 @@ func (r *OffsetRequest) Decode(pd packetDecoder, version int16) error {
 		}
 	}
 
-	blockCount, err := pd.getArrayLength()
+	blockCount, err := r.getArrayLength(pd)
 	if err != nil {
 		return err
 	}
-	if blockCount == 0 {
-		return nil
+	if blockCount > 0 {
+		r.blocks = make(map[string]map[int32]*offsetRequestBlock)
 	}
-	r.blocks = make(map[string]map[int32]*offsetRequestBlock)
 	for i := 0; i < blockCount; i++ {
-		topic, err := pd.getString()
+		topic, err := r.getString(pd)
 		if err != nil {
 			return err
 		}
-		partitionCount, err := pd.getArrayLength()
+		partitionCount, err := r.getArrayLength(pd)
 		if err != nil {
 			return err
 		}
@@ -139,10 +183,53 @@ func (r *OffsetRequest) Decode(pd packetDecoder, version int16) error {
 			}
 			r.blocks[topic][partition] = block
 		}
+		if r.isFlexible() {
+			if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
+	}
+	if r.isFlexible() {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+func (r *OffsetRequest) isFlexible() bool {
+	return r.Version >= 6
+}
+
+func (r *OffsetRequest) putArrayLength(pe packetEncoder, length int) error {
+	if r.isFlexible() {
+		pe.putCompactArrayLength(length)
+		return nil
+	}
+	return pe.putArrayLength(length)
+}
+
+func (r *OffsetRequest) putString(pe packetEncoder, s string) error {
+	if r.isFlexible() {
+		return pe.putCompactString(s)
+	}
+	return pe.putString(s)
+}
+
+func (r *OffsetRequest) getArrayLength(pd packetDecoder) (int, error) {
+	if r.isFlexible() {
+		return pd.getCompactArrayLength()
+	}
+	return pd.getArrayLength()
+}
+
+func (r *OffsetRequest) getString(pd packetDecoder) (string, error) {
+	if r.isFlexible() {
+		return pd.getCompactString()
+	}
+	return pd.getString()
+}
+
 func (r *OffsetRequest) APIKey() int16 {
 	return 2
 }
@@ -151,16 +238,46 @@ func (r *OffsetRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *OffsetRequest) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *OffsetRequest) checkVersion(v int16) error {
+	switch {
+	case r.Version >= 1 && v < 1:
+		return errors.New("a single offset per partition requires version 1")
+	case r.IsolationLevel == ReadCommitted && v < 2:
+		return errors.New("the read committed isolation level requires version 2")
+	}
+	for _, partitions := range r.blocks {
+		for _, block := range partitions {
+			if block.timestamp == int64(OffsetSpecMaxTimestamp) && v < 7 {
+				return errors.New("the max timestamp offset spec requires version 7")
+			}
+		}
+	}
+	return nil
+}
+
 func (r *OffsetRequest) HeaderVersion() int16 {
+	if r.isFlexible() {
+		return 2
+	}
 	return 1
 }
 
 func (r *OffsetRequest) IsValidVersion() bool {
-	return r.Version >= 0 && r.Version <= 4
+	return r.Version >= 0 && r.Version <= 7
 }
 
 func (r *OffsetRequest) RequiredVersion() KafkaVersion {
 	switch r.Version {
+	case 7:
+		return V3_0_0_0
+	case 6:
+		return V2_5_0_0
+	case 5:
+		return V2_2_0_0
 	case 4:
 		return V2_1_0_0
 	case 3:
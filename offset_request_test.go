@@ -106,3 +106,46 @@ func TestOffsetRequestV4(t *testing.T) {
 	request.AddBlock("dnwe", 9, -1, -1)
 	testRequest(t, "V4", request, offsetRequestV4)
 }
+
+var offsetRequestV7 = []byte{
+	0xff, 0xff, 0xff, 0xff, // replicaID
+	0x00,                         // IsolationLevel
+	0x02,                         // compact array length of topics
+	0x05, 0x64, 0x6e, 0x77, 0x65, // compact topic name
+	0x02,                   // compact array length of partitions
+	0x00, 0x00, 0x00, 0x09, // partitionID
+	0xff, 0xff, 0xff, 0xff, // leader epoch
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfd, // timestamp (max timestamp)
+	0x00, // partition tagged fields
+	0x00, // topic tagged fields
+	0x00, // tagged fields
+}
+
+func TestOffsetRequestV7(t *testing.T) {
+	request := new(OffsetRequest)
+	request.Version = 7
+	request.AddBlock("dnwe", 9, int64(OffsetSpecMaxTimestamp), 1)
+	testRequest(t, "V7", request, offsetRequestV7)
+
+	if request.HeaderVersion() != 2 {
+		t.Errorf("expected flexible header version 2, got %d", request.HeaderVersion())
+	}
+}
+
+func TestNewOffsetRequest(t *testing.T) {
+	for _, tc := range []struct {
+		version  KafkaVersion
+		expected int16
+	}{
+		{V0_10_0_0, 0},
+		{V0_11_0_0, 2},
+		{V2_1_0_0, 4},
+		{V2_4_0_0, 5},
+		{V2_5_0_0, 6},
+		{V3_3_1_0, 7},
+	} {
+		if request := newOffsetRequest(tc.version); request.Version != tc.expected {
+			t.Errorf("expected version %d for Kafka %s, got %d", tc.expected, tc.version, request.Version)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package sarama
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// OffsetResetMode is the way PlanConsumerGroupOffsetsReset computes new offsets,
+// mirroring the reset options of the kafka-consumer-groups tool.
+type OffsetResetMode int
+
+const (
+	// OffsetResetToEarliest resets to the earliest available offset (--to-earliest).
+	OffsetResetToEarliest OffsetResetMode = iota
+	// OffsetResetToLatest resets to the high water mark (--to-latest).
+	OffsetResetToLatest
+	// OffsetResetToDatetime resets to the first offset whose timestamp is equal to
+	// or later than OffsetResetSpec.Datetime (--to-datetime).
+	OffsetResetToDatetime
+	// OffsetResetByDuration resets to the first offset whose timestamp is equal to
+	// or later than OffsetResetSpec.Duration ago (--by-duration).
+	OffsetResetByDuration
+	// OffsetResetShiftBy shifts the committed offset by OffsetResetSpec.Shift,
+	// which may be negative (--shift-by).
+	OffsetResetShiftBy
+	// OffsetResetToOffset resets to OffsetResetSpec.Offset (--to-offset).
+	OffsetResetToOffset
+)
+
+func (m OffsetResetMode) String() string {
+	switch m {
+	case OffsetResetToEarliest:
+		return "to-earliest"
+	case OffsetResetToLatest:
+		return "to-latest"
+	case OffsetResetToDatetime:
+		return "to-datetime"
+	case OffsetResetByDuration:
+		return "by-duration"
+	case OffsetResetShiftBy:
+		return "shift-by"
+	case OffsetResetToOffset:
+		return "to-offset"
+	}
+	return fmt.Sprintf("OffsetResetMode(%d)", int(m))
+}
+
+// OffsetResetSpec describes how to reset the offsets of a consumer group. Only
+// the field that belongs to Mode is used.
+type OffsetResetSpec struct {
+	Mode     OffsetResetMode
+	Datetime time.Time
+	Duration time.Duration
+	Shift    int64
+	Offset   int64
+}
+
+// PartitionOffsetReset is the planned reset of a single partition.
+type PartitionOffsetReset struct {
+	Topic     string
+	Partition int32
+	// Current is the committed offset, or -1 if the group has none.
+	Current int64
+	// Target is the offset the partition will be reset to. It is always within
+	// [Earliest, Latest].
+	Target   int64
+	Earliest int64
+	Latest   int64
+}
+
+// OffsetResetPlan is the result of PlanConsumerGroupOffsetsReset. Printing it
+// serves as a dry run, applying it is done with ApplyConsumerGroupOffsetsReset.
+type OffsetResetPlan struct {
+	Group string
+	Spec  OffsetResetSpec
+	// Partitions are sorted by topic and partition.
+	Partitions []*PartitionOffsetReset
+}
+
+// Offsets returns the target offsets of the plan by topic and partition.
+func (p *OffsetResetPlan) Offsets() map[string]map[int32]int64 {
+	offsets := make(map[string]map[int32]int64)
+	for _, reset := range p.Partitions {
+		if offsets[reset.Topic] == nil {
+			offsets[reset.Topic] = make(map[int32]int64)
+		}
+		offsets[reset.Topic][reset.Partition] = reset.Target
+	}
+	return offsets
+}
+
+func (ca *clusterAdmin) PlanConsumerGroupOffsetsReset(group string, topicPartitions map[string][]int32, spec OffsetResetSpec) (*OffsetResetPlan, error) {
+	if spec.Mode < OffsetResetToEarliest || spec.Mode > OffsetResetToOffset {
+		return nil, ConfigurationError(fmt.Sprintf("unknown offset reset mode %d", int(spec.Mode)))
+	}
+
+	requested := make(map[string][]int32, len(topicPartitions))
+	for topic, partitions := range topicPartitions {
+		if len(partitions) == 0 {
+			all, err := ca.client.Partitions(topic)
+			if err != nil {
+				return nil, err
+			}
+			partitions = all
+		}
+		requested[topic] = partitions
+	}
+
+	var fetchPartitions map[string][]int32
+	if topicPartitions != nil {
+		fetchPartitions = requested
+	}
+	committed, err := ca.ListConsumerGroupOffsets(group, fetchPartitions)
+	if err != nil {
+		return nil, err
+	}
+	if !errors.Is(committed.Err, ErrNoError) {
+		return nil, committed.Err
+	}
+	if topicPartitions == nil {
+		for topic, blocks := range committed.Blocks {
+			for partition, block := range blocks {
+				if block.Offset >= 0 {
+					requested[topic] = append(requested[topic], partition)
+				}
+			}
+		}
+	}
+
+	plan := &OffsetResetPlan{Group: group, Spec: spec}
+	for topic, partitions := range requested {
+		for _, partition := range partitions {
+			current := int64(-1)
+			if block := committed.GetBlock(topic, partition); block != nil {
+				if !errors.Is(block.Err, ErrNoError) {
+					return nil, block.Err
+				}
+				current = block.Offset
+			}
+
+			reset, err := ca.planPartitionOffsetReset(topic, partition, current, spec)
+			if err != nil {
+				return nil, err
+			}
+			plan.Partitions = append(plan.Partitions, reset)
+		}
+	}
+
+	sort.Slice(plan.Partitions, func(i, j int) bool {
+		a, b := plan.Partitions[i], plan.Partitions[j]
+		if a.Topic != b.Topic {
+			return a.Topic < b.Topic
+		}
+		return a.Partition < b.Partition
+	})
+	return plan, nil
+}
+
+func (ca *clusterAdmin) planPartitionOffsetReset(topic string, partition int32, current int64, spec OffsetResetSpec) (*PartitionOffsetReset, error) {
+	earliest, err := ca.client.GetOffset(topic, partition, OffsetOldest)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := ca.client.GetOffset(topic, partition, OffsetNewest)
+	if err != nil {
+		return nil, err
+	}
+
+	reset := &PartitionOffsetReset{
+		Topic:     topic,
+		Partition: partition,
+		Current:   current,
+		Earliest:  earliest,
+		Latest:    latest,
+	}
+
+	var target int64
+	switch spec.Mode {
+	case OffsetResetToEarliest:
+		target = earliest
+	case OffsetResetToLatest:
+		target = latest
+	case OffsetResetToDatetime, OffsetResetByDuration:
+		datetime := spec.Datetime
+		if spec.Mode == OffsetResetByDuration {
+			datetime = time.Now().Add(-spec.Duration)
+		}
+		target, err = ca.client.GetOffset(topic, partition, datetime.UnixNano()/int64(time.Millisecond))
+		if err != nil {
+			return nil, err
+		}
+		if target < 0 {
+			// no record at or after the datetime
+			target = latest
+		}
+	case OffsetResetShiftBy:
+		if current < 0 {
+			return nil, fmt.Errorf("kafka: cannot shift offset of %s/%d without a committed offset", topic, partition)
+		}
+		target = current + spec.Shift
+	case OffsetResetToOffset:
+		target = spec.Offset
+	default:
+		return nil, ConfigurationError(fmt.Sprintf("unknown offset reset mode %d", int(spec.Mode)))
+	}
+
+	reset.Target = clampOffset(target, earliest, latest)
+	return reset, nil
+}
+
+func clampOffset(offset, earliest, latest int64) int64 {
+	if offset < earliest {
+		return earliest
+	}
+	if offset > latest {
+		return latest
+	}
+	return offset
+}
+
+func (ca *clusterAdmin) ApplyConsumerGroupOffsetsReset(plan *OffsetResetPlan) error {
+	groups, err := ca.DescribeConsumerGroups([]string{plan.Group})
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if group.GroupId != plan.Group {
+			continue
+		}
+		if !errors.Is(group.Err, ErrNoError) {
+			return group.Err
+		}
+		if len(group.Members) > 0 {
+			return ErrNonEmptyGroup
+		}
+	}
+
+	response, err := ca.AlterConsumerGroupOffsets(plan.Group, plan.Offsets())
+	if err != nil {
+		return err
+	}
+	for _, reset := range plan.Partitions {
+		kerr, ok := response.Errors[reset.Topic][reset.Partition]
+		if !ok {
+			return ErrIncompleteResponse
+		}
+		if !errors.Is(kerr, ErrNoError) {
+			return kerr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClampOffset(t *testing.T) {
+	for _, tc := range []struct {
+		offset, expected int64
+	}{
+		{5, 10},
+		{10, 10},
+		{15, 15},
+		{20, 20},
+		{25, 20},
+	} {
+		if got := clampOffset(tc.offset, 10, 20); got != tc.expected {
+			t.Errorf("clampOffset(%d, 10, 20) = %d, expected %d", tc.offset, got, tc.expected)
+		}
+	}
+}
+
+func TestOffsetResetPlanOffsets(t *testing.T) {
+	plan := &OffsetResetPlan{
+		Group: "group",
+		Partitions: []*PartitionOffsetReset{
+			{Topic: "a", Partition: 0, Target: 1},
+			{Topic: "a", Partition: 1, Target: 2},
+			{Topic: "b", Partition: 0, Target: 3},
+		},
+	}
+
+	offsets := plan.Offsets()
+	if len(offsets) != 2 || offsets["a"][0] != 1 || offsets["a"][1] != 2 || offsets["b"][0] != 3 {
+		t.Errorf("unexpected offsets %v", offsets)
+	}
+}
+
+func TestPlanConsumerGroupOffsetsResetUnknownMode(t *testing.T) {
+	ca := &clusterAdmin{conf: NewTestConfig()}
+	_, err := ca.PlanConsumerGroupOffsetsReset("group", nil, OffsetResetSpec{Mode: OffsetResetMode(42)})
+	var configErr ConfigurationError
+	if !errors.As(err, &configErr) {
+		t.Errorf("expected ConfigurationError, got %v", err)
+	}
+	if OffsetResetShiftBy.String() != "shift-by" {
+		t.Error("unexpected mode name", OffsetResetShiftBy.String())
+	}
+}
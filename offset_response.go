@@ -47,6 +47,12 @@ func (b *OffsetResponseBlock) Decode(pd packetDecoder, version int16) (err error
 		}
 	}
 
+	if version >= 6 {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -66,6 +72,10 @@ func (b *OffsetResponseBlock) encode(pe packetEncoder, version int16) (err error
 		pe.putInt32(b.LeaderEpoch)
 	}
 
+	if version >= 6 {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
@@ -76,6 +86,7 @@ type OffsetResponse struct {
 }
 
 func (r *OffsetResponse) Decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
 	if version >= 2 {
 		r.ThrottleTimeMs, err = pd.getInt32()
 		if err != nil {
@@ -83,19 +94,19 @@ func (r *OffsetResponse) Decode(pd packetDecoder, version int16) (err error) {
 		}
 	}
 
-	numTopics, err := pd.getArrayLength()
+	numTopics, err := r.getArrayLength(pd)
 	if err != nil {
 		return err
 	}
 
 	r.Blocks = make(map[string]map[int32]*OffsetResponseBlock, numTopics)
 	for i := 0; i < numTopics; i++ {
-		name, err := pd.getString()
+		name, err := r.getString(pd)
 		if err != nil {
 			return err
 		}
 
-		numBlocks, err := pd.getArrayLength()
+		numBlocks, err := r.getArrayLength(pd)
 		if err != nil {
 			return err
 		}
@@ -115,6 +126,18 @@ func (r *OffsetResponse) Decode(pd packetDecoder, version int16) (err error) {
 			}
 			r.Blocks[name][id] = block
 		}
+
+		if r.isFlexible() {
+			if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.isFlexible() {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -151,15 +174,15 @@ func (r *OffsetResponse) Encode(pe packetEncoder) (err error) {
 		pe.putInt32(r.ThrottleTimeMs)
 	}
 
-	if err = pe.putArrayLength(len(r.Blocks)); err != nil {
+	if err = r.putArrayLength(pe, len(r.Blocks)); err != nil {
 		return err
 	}
 
 	for topic, partitions := range r.Blocks {
-		if err = pe.putString(topic); err != nil {
+		if err = r.putString(pe, topic); err != nil {
 			return err
 		}
-		if err = pe.putArrayLength(len(partitions)); err != nil {
+		if err = r.putArrayLength(pe, len(partitions)); err != nil {
 			return err
 		}
 		for partition, block := range partitions {
@@ -168,11 +191,51 @@ func (r *OffsetResponse) Encode(pe packetEncoder) (err error) {
 				return err
 			}
 		}
+		if r.isFlexible() {
+			pe.putEmptyTaggedFieldArray()
+		}
+	}
+
+	if r.isFlexible() {
+		pe.putEmptyTaggedFieldArray()
 	}
 
 	return nil
 }
 
+func (r *OffsetResponse) isFlexible() bool {
+	return r.Version >= 6
+}
+
+func (r *OffsetResponse) putArrayLength(pe packetEncoder, length int) error {
+	if r.isFlexible() {
+		pe.putCompactArrayLength(length)
+		return nil
+	}
+	return pe.putArrayLength(length)
+}
+
+func (r *OffsetResponse) putString(pe packetEncoder, s string) error {
+	if r.isFlexible() {
+		return pe.putCompactString(s)
+	}
+	return pe.putString(s)
+}
+
+func (r *OffsetResponse) getArrayLength(pd packetDecoder) (int, error) {
+	if r.isFlexible() {
+		return pd.getCompactArrayLength()
+	}
+	return pd.getArrayLength()
+}
+
+func (r *OffsetResponse) getString(pd packetDecoder) (string, error) {
+	if r.isFlexible() {
+		return pd.getCompactString()
+	}
+	return pd.getString()
+}
+
 func (r *OffsetResponse) APIKey() int16 {
 	return 2
 }
@@ -181,16 +244,29 @@ func (r *OffsetResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *OffsetResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *OffsetResponse) HeaderVersion() int16 {
+	if r.isFlexible() {
+		return 1
+	}
 	return 0
 }
 
 func (r *OffsetResponse) IsValidVersion() bool {
-	return r.Version >= 0 && r.Version <= 4
+	return r.Version >= 0 && r.Version <= 7
 }
 
 func (r *OffsetResponse) RequiredVersion() KafkaVersion {
 	switch r.Version {
+	case 7:
+		return V3_0_0_0
+	case 6:
+		return V2_5_0_0
+	case 5:
+		return V2_2_0_0
 	case 4:
 		return V2_1_0_0
 	case 3:
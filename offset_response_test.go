@@ -133,3 +133,35 @@ func TestOffsetResponseV4(t *testing.T) {
 
 	testVersionDecodable(t, "v4", &response, offsetResponseV4, 4)
 }
+
+var offsetResponseV7 = []byte{
+	0x00, 0x00, 0x00, 0x00, // throttle time
+	0x02,                         // compact array length of topics
+	0x05, 0x64, 0x6e, 0x77, 0x65, // compact topic name
+	0x02,                   // compact array length of partitions
+	0x00, 0x00, 0x00, 0x09, // partitionID
+	0x00, 0x00, // err
+	0x00, 0x00, 0x01, 0x58, 0x1A, 0xE6, 0x48, 0x86, // timestamp
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x2a, // offset
+	0x00, 0x00, 0x00, 0x03, // leaderEpoch
+	0x00, // partition tagged fields
+	0x00, // topic tagged fields
+	0x00, // tagged fields
+}
+
+func TestOffsetResponseV7(t *testing.T) {
+	response := OffsetResponse{}
+
+	testVersionDecodable(t, "v7", &response, offsetResponseV7, 7)
+
+	block := response.GetBlock("dnwe", 9)
+	if block == nil {
+		t.Fatal("Decoding did not produce a block for dnwe/9")
+	}
+	if block.Offset != 42 || block.Timestamp != 1477920049286 || block.LeaderEpoch != 3 {
+		t.Errorf("Decoding produced an invalid block %+v", block)
+	}
+	if response.HeaderVersion() != 1 {
+		t.Errorf("expected flexible header version 1, got %d", response.HeaderVersion())
+	}
+}
@@ -0,0 +1,261 @@
+package sarama
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// OffsetStore fetches and commits consumed partition offsets on behalf of an
+// OffsetManager. Configure one through Config.Consumer.Offsets.Store to keep
+// offsets outside of Kafka, for example in the same database transaction as
+// the data they describe.
+type OffsetStore interface {
+	// FetchOffset returns the offset stored for the given partition of group.
+	// If no offset has been stored yet, it must return an offset of -1 and no
+	// error, in which case Consumer.Offsets.Initial is used.
+	FetchOffset(group, topic string, partition int32) (*StoredOffset, error)
+
+	// CommitOffsets stores offsets for group and returns the per-partition
+	// results. A non-nil error means that none of the offsets were stored.
+	CommitOffsets(group string, offsets []*StoredOffset) ([]*OffsetCommitResult, error)
+}
+
+// StoredOffset is the offset of a single partition as kept by an OffsetStore.
+type StoredOffset struct {
+	Topic       string `json:"topic"`
+	Partition   int32  `json:"partition"`
+	Offset      int64  `json:"offset"`
+	LeaderEpoch int32  `json:"leader_epoch"`
+	Metadata    string `json:"metadata"`
+}
+
+// kafkaOffsetStore stores offsets in Kafka through the group coordinator,
+// outside of any group generation.
+type kafkaOffsetStore struct {
+	client Client
+
+	lock     sync.Mutex
+	managers map[string]*offsetManager
+}
+
+// NewKafkaOffsetStore creates an OffsetStore that stores offsets in Kafka
+// through the group coordinator, like an OffsetManager without a configured
+// Store does. Commits are not bound to a group generation, so it should not be
+// used while the group has active members. It is still necessary to call
+// Close() on the underlying client when finished with the store.
+func NewKafkaOffsetStore(client Client) (OffsetStore, error) {
+	if client.Closed() {
+		return nil, ErrClosedClient
+	}
+	return &kafkaOffsetStore{
+		client:   client,
+		managers: make(map[string]*offsetManager),
+	}, nil
+}
+
+// manager returns an offsetManager for group that manages no partitions and
+// is only used for its coordinator handling and request construction.
+func (s *kafkaOffsetStore) manager(group string) *offsetManager {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	om := s.managers[group]
+	if om == nil {
+		om = &offsetManager{
+			client:     s.client,
+			conf:       s.client.Config(),
+			group:      group,
+			generation: GroupGenerationUndefined,
+			poms:       make(map[string]map[int32]*partitionOffsetManager),
+			closing:    make(chan none),
+			closed:     make(chan none),
+		}
+		s.managers[group] = om
+	}
+	return om
+}
+
+func (s *kafkaOffsetStore) FetchOffset(group, topic string, partition int32) (*StoredOffset, error) {
+	om := s.manager(group)
+	offset, leaderEpoch, metadata, err := om.fetchInitialOffset(topic, partition, om.conf.Metadata.Retry.Max)
+	if err != nil {
+		return nil, err
+	}
+	return &StoredOffset{
+		Topic:       topic,
+		Partition:   partition,
+		Offset:      offset,
+		LeaderEpoch: leaderEpoch,
+		Metadata:    metadata,
+	}, nil
+}
+
+func (s *kafkaOffsetStore) CommitOffsets(group string, offsets []*StoredOffset) ([]*OffsetCommitResult, error) {
+	if len(offsets) == 0 {
+		return nil, nil
+	}
+
+	om := s.manager(group)
+	req := om.newCommitRequest()
+	var commitTimestamp int64
+	if req.Version == 1 {
+		commitTimestamp = ReceiveTime
+	}
+	for _, offset := range offsets {
+		req.AddBlockWithLeaderEpoch(offset.Topic, offset.Partition, offset.Offset, offset.LeaderEpoch, commitTimestamp, offset.Metadata)
+	}
+
+	broker, err := om.coordinator()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := broker.CommitOffset(req)
+	if err != nil {
+		om.releaseCoordinator(broker)
+		_ = broker.Close()
+		return nil, err
+	}
+
+	results := make([]*OffsetCommitResult, 0, len(offsets))
+	for _, offset := range offsets {
+		result := &OffsetCommitResult{
+			Topic:       offset.Topic,
+			Partition:   offset.Partition,
+			Offset:      offset.Offset,
+			LeaderEpoch: offset.LeaderEpoch,
+			Metadata:    offset.Metadata,
+		}
+		results = append(results, result)
+
+		kerr, ok := resp.Errors[offset.Topic][offset.Partition]
+		if !ok {
+			result.Err = ErrIncompleteResponse
+			continue
+		}
+		switch kerr {
+		case ErrNoError:
+		case ErrNotLeaderForPartition, ErrLeaderNotAvailable,
+			ErrConsumerCoordinatorNotAvailable, ErrNotCoordinatorForConsumer:
+			om.releaseCoordinator(broker)
+			result.Err = kerr
+		default:
+			result.Err = kerr
+		}
+	}
+	return results, nil
+}
+
+// fileOffsetStore keeps offsets of all groups in a single JSON file.
+type fileOffsetStore struct {
+	path string
+
+	lock    sync.Mutex
+	offsets map[string]map[string]map[int32]*StoredOffset
+}
+
+// NewFileOffsetStore creates an OffsetStore that keeps offsets in the JSON file
+// at path, which is created on the first commit if it does not exist. Every
+// commit rewrites the file atomically. It is meant as a reference
+// implementation and for single-process use only.
+func NewFileOffsetStore(path string) (OffsetStore, error) {
+	s := &fileOffsetStore{
+		path:    path,
+		offsets: make(map[string]map[string]map[int32]*StoredOffset),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.offsets); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileOffsetStore) FetchOffset(group, topic string, partition int32) (*StoredOffset, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if stored := s.offsets[group][topic][partition]; stored != nil {
+		offset := *stored
+		return &offset, nil
+	}
+	return &StoredOffset{
+		Topic:       topic,
+		Partition:   partition,
+		Offset:      -1,
+		LeaderEpoch: -1,
+	}, nil
+}
+
+func (s *fileOffsetStore) CommitOffsets(group string, offsets []*StoredOffset) ([]*OffsetCommitResult, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// apply to a copy of the group so a failed write leaves the state untouched
+	topics := make(map[string]map[int32]*StoredOffset, len(s.offsets[group]))
+	for topic, partitions := range s.offsets[group] {
+		topics[topic] = make(map[int32]*StoredOffset, len(partitions))
+		for partition, stored := range partitions {
+			topics[topic][partition] = stored
+		}
+	}
+
+	results := make([]*OffsetCommitResult, 0, len(offsets))
+	for _, offset := range offsets {
+		if topics[offset.Topic] == nil {
+			topics[offset.Topic] = make(map[int32]*StoredOffset)
+		}
+		stored := *offset
+		topics[offset.Topic][offset.Partition] = &stored
+		results = append(results, &OffsetCommitResult{
+			Topic:       offset.Topic,
+			Partition:   offset.Partition,
+			Offset:      offset.Offset,
+			LeaderEpoch: offset.LeaderEpoch,
+			Metadata:    offset.Metadata,
+		})
+	}
+
+	previous := s.offsets[group]
+	s.offsets[group] = topics
+	if err := s.write(); err != nil {
+		s.offsets[group] = previous
+		return nil, err
+	}
+	return results, nil
+}
+
+// s.lock must be held by caller
+func (s *fileOffsetStore) write() error {
+	data, err := json.Marshal(s.offsets)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,107 @@
+package sarama
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileOffsetStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "offsets.json")
+
+	store, err := NewFileOffsetStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := store.FetchOffset("group", "my_topic", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Offset != -1 {
+		t.Errorf("expected offset -1 for unknown partition, got %d", stored.Offset)
+	}
+
+	results, err := store.CommitOffsets("group", []*StoredOffset{
+		{Topic: "my_topic", Partition: 0, Offset: 42, LeaderEpoch: 3, Metadata: "meta"},
+		{Topic: "my_topic", Partition: 1, Offset: 7},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Error(result.Err)
+		}
+	}
+
+	// reopen to make sure the offsets were persisted
+	store, err = NewFileOffsetStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err = store.FetchOffset("group", "my_topic", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Offset != 42 || stored.LeaderEpoch != 3 || stored.Metadata != "meta" {
+		t.Errorf("unexpected stored offset %+v", stored)
+	}
+	stored, err = store.FetchOffset("other_group", "my_topic", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Offset != -1 {
+		t.Errorf("expected offsets to be scoped by group, got %d", stored.Offset)
+	}
+}
+
+func TestOffsetManagerWithOffsetStore(t *testing.T) {
+	store, err := NewFileOffsetStore(filepath.Join(t.TempDir(), "offsets.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CommitOffsets("group", []*StoredOffset{{Topic: "my_topic", Partition: 0, Offset: 5, Metadata: "original_meta"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewTestConfig()
+	conf.Consumer.Offsets.AutoCommit.Enable = false
+	conf.Consumer.Offsets.Store = store
+	om := &offsetManager{
+		conf:    conf,
+		group:   "group",
+		store:   store,
+		poms:    make(map[string]map[int32]*partitionOffsetManager),
+		closing: make(chan none),
+		closed:  make(chan none),
+	}
+
+	pom, err := om.ManagePartition("my_topic", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset, meta := pom.NextOffset()
+	if offset != 5 || meta != "original_meta" {
+		t.Errorf("expected initial offset 5/original_meta, got %d/%s", offset, meta)
+	}
+
+	pom.MarkOffset(10, "modified_meta")
+	if err := om.CommitSync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := store.FetchOffset("group", "my_topic", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Offset != 10 || stored.Metadata != "modified_meta" {
+		t.Errorf("unexpected stored offset %+v", stored)
+	}
+
+	safeClose(t, om)
+	safeClose(t, pom)
+}
@@ -1,6 +1,10 @@
 package sarama
 
-import "github.com/rcrowley/go-metrics"
+import (
+	"errors"
+
+	"github.com/rcrowley/go-metrics"
+)
 
 // RequiredAcks is used in Produce Requests to tell the broker how many replica acknowledgements
 // it must see before responding. Any of the constants defined here are valid. On broker versions
@@ -214,6 +218,28 @@ func (r *ProduceRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *ProduceRequest) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *ProduceRequest) checkVersion(v int16) error {
+	if r.TransactionalID != nil && v < 3 {
+		return errors.New("transactions require version 3")
+	}
+	for _, partitions := range r.Records {
+		for _, records := range partitions {
+			switch {
+			case records.RecordBatch == nil:
+			case v < 3:
+				return errors.New("record batches require version 3")
+			case records.RecordBatch.Codec == CompressionZSTD && v < 7:
+				return errors.New("ZSTD compression requires version 7")
+			}
+		}
+	}
+	return nil
+}
+
 func (r *ProduceRequest) HeaderVersion() int16 {
 	return 1
 }
@@ -171,6 +171,10 @@ func (r *ProduceResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *ProduceResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ProduceResponse) HeaderVersion() int16 {
 	return 0
 }
@@ -3,6 +3,7 @@ package sarama
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -17,18 +18,42 @@ type produceSet struct {
 	msgs          map[string]map[int32]*partitionSet
 	producerID    int64
 	producerEpoch int16
+	// version is the version of the ProduceRequest built from the set, which
+	// decides the format of the messages.
+	version int16
 
 	bufferBytes int
 	bufferCount int
 }
 
-func newProduceSet(parent *asyncProducer) *produceSet {
+func newProduceSet(parent *asyncProducer, version int16) *produceSet {
 	pid, epoch := parent.txnmgr.getProducerID()
 	return &produceSet{
 		msgs:          make(map[string]map[int32]*partitionSet),
 		parent:        parent,
 		producerID:    pid,
 		producerEpoch: epoch,
+		version:       version,
+	}
+}
+
+// produceRequestVersion returns the version of the ProduceRequest allowed by
+// the Version of conf, which may be lowered to the version supported by the
+// broker.
+func produceRequestVersion(conf *Config) int16 {
+	switch {
+	case conf.versionCeiling().IsAtLeast(V2_1_0_0):
+		return 7
+	case conf.versionCeiling().IsAtLeast(V2_0_0_0):
+		return 6
+	case conf.versionCeiling().IsAtLeast(V1_0_0_0):
+		return 5
+	case conf.versionCeiling().IsAtLeast(V0_11_0_0):
+		return 3
+	case conf.versionCeiling().IsAtLeast(V0_10_0_0):
+		return 2
+	default:
+		return 0
 	}
 }
 
@@ -36,6 +61,17 @@ func (ps *produceSet) add(msg *ProducerMessage) error {
 	var err error
 	var key, val []byte
 
+	// the message sets of the versions older than 3 have no room for the
+	// features which require record batches
+	switch {
+	case ps.version < 3 && len(msg.Headers) > 0:
+		return fmt.Errorf("%w: producing headers requires ProduceRequest v3, the broker supports v%d", ErrUnsupportedVersion, ps.version)
+	case ps.version < 3 && ps.parent.conf.Producer.Idempotent:
+		return fmt.Errorf("%w: the idempotent producer requires ProduceRequest v3, the broker supports v%d", ErrUnsupportedVersion, ps.version)
+	case ps.version < 7 && ps.parent.conf.Producer.Compression == CompressionZSTD:
+		return fmt.Errorf("%w: ZSTD compression requires ProduceRequest v7, the broker supports v%d", ErrUnsupportedVersion, ps.version)
+	}
+
 	if msg.Key != nil {
 		if key, err = msg.Key.Encode(); err != nil {
 			return err
@@ -64,7 +100,7 @@ func (ps *produceSet) add(msg *ProducerMessage) error {
 
 	set := partitions[msg.Partition]
 	if set == nil {
-		if ps.parent.conf.Version.IsAtLeast(V0_11_0_0) {
+		if ps.version >= 3 {
 			batch := &RecordBatch{
 				FirstTimestamp:   timestamp,
 				Version:          2,
@@ -84,7 +120,7 @@ func (ps *produceSet) add(msg *ProducerMessage) error {
 		partitions[msg.Partition] = set
 	}
 
-	if ps.parent.conf.Version.IsAtLeast(V0_11_0_0) {
+	if ps.version >= 3 {
 		if ps.parent.conf.Producer.Idempotent && msg.sequenceNumber < set.recordsToSend.RecordBatch.FirstSequence {
 			return errors.New("assertion failed: message out of sequence added to a batch")
 		}
@@ -93,7 +129,7 @@ func (ps *produceSet) add(msg *ProducerMessage) error {
 	// Past this point we can't return an error, because we've already added the message to the set.
 	set.msgs = append(set.msgs, msg)
 
-	if ps.parent.conf.Version.IsAtLeast(V0_11_0_0) {
+	if ps.version >= 3 {
 		// We are being conservative here to avoid having to prep encode the record
 		size += maximumRecordOverhead
 		rec := &Record{
@@ -112,7 +148,7 @@ func (ps *produceSet) add(msg *ProducerMessage) error {
 		set.recordsToSend.RecordBatch.addRecord(rec)
 	} else {
 		msgToSend := &Message{Codec: CompressionNone, Key: key, Value: val}
-		if ps.parent.conf.Version.IsAtLeast(V0_10_0_0) {
+		if ps.version >= 2 {
 			msgToSend.Timestamp = timestamp
 			msgToSend.Version = 1
 		}
@@ -131,24 +167,10 @@ func (ps *produceSet) buildRequest() *ProduceRequest {
 	req := &ProduceRequest{
 		RequiredAcks: ps.parent.conf.Producer.RequiredAcks,
 		Timeout:      int32(ps.parent.conf.Producer.Timeout / time.Millisecond),
+		Version:      ps.version,
 	}
-	if ps.parent.conf.Version.IsAtLeast(V0_10_0_0) {
-		req.Version = 2
-	}
-	if ps.parent.conf.Version.IsAtLeast(V0_11_0_0) {
-		req.Version = 3
-		if ps.parent.IsTransactional() {
-			req.TransactionalID = &ps.parent.conf.Producer.Transaction.ID
-		}
-	}
-	if ps.parent.conf.Version.IsAtLeast(V1_0_0_0) {
-		req.Version = 5
-	}
-	if ps.parent.conf.Version.IsAtLeast(V2_0_0_0) {
-		req.Version = 6
-	}
-	if ps.parent.conf.Version.IsAtLeast(V2_1_0_0) {
-		req.Version = 7
+	if req.Version >= 3 && ps.parent.IsTransactional() {
+		req.TransactionalID = &ps.parent.conf.Producer.Transaction.ID
 	}
 
 	for topic, partitionSets := range ps.msgs {
@@ -183,7 +205,7 @@ func (ps *produceSet) buildRequest() *ProduceRequest {
 				// set and no key. When the server sees a message with a compression codec, it
 				// decompresses the payload and treats the result as its message set.
 
-				if ps.parent.conf.Version.IsAtLeast(V0_10_0_0) {
+				if req.Version >= 2 {
 					// If our version is 0.10 or later, assign relative offsets
 					// to the inner messages. This lets the broker avoid
 					// recompressing the message set.
@@ -205,7 +227,7 @@ func (ps *produceSet) buildRequest() *ProduceRequest {
 					Value:            payload,
 					Set:              set.recordsToSend.MsgSet, // Provide the underlying message set for accurate metrics
 				}
-				if ps.parent.conf.Version.IsAtLeast(V0_10_0_0) {
+				if req.Version >= 2 {
 					compMsg.Version = 1
 					compMsg.Timestamp = set.recordsToSend.MsgSet.Messages[0].Msg.Timestamp
 				}
@@ -241,7 +263,7 @@ func (ps *produceSet) dropPartition(topic string, partition int32) []*ProducerMe
 
 func (ps *produceSet) wouldOverflow(msg *ProducerMessage) bool {
 	version := 1
-	if ps.parent.conf.Version.IsAtLeast(V0_11_0_0) {
+	if ps.version >= 3 {
 		version = 2
 	}
 
@@ -13,7 +13,7 @@ func makeProduceSet() (*asyncProducer, *produceSet) {
 		conf:   conf,
 		txnmgr: txnmgr,
 	}
-	return parent, newProduceSet(parent)
+	return parent, newProduceSet(parent, produceRequestVersion(conf))
 }
 
 func safeAddMessage(t *testing.T, ps *produceSet, msg *ProducerMessage) {
@@ -178,6 +178,7 @@ func TestProduceSetCompressedRequestBuilding(t *testing.T) {
 	parent.conf.Producer.Timeout = 10 * time.Second
 	parent.conf.Producer.Compression = CompressionGZIP
 	parent.conf.Version = V0_10_0_0
+	ps = newProduceSet(parent, produceRequestVersion(parent.conf))
 
 	msg := &ProducerMessage{
 		Topic:     "t1",
@@ -222,6 +223,7 @@ func TestProduceSetV3RequestBuilding(t *testing.T) {
 	parent.conf.Producer.RequiredAcks = WaitForAll
 	parent.conf.Producer.Timeout = 10 * time.Second
 	parent.conf.Version = V0_11_0_0
+	ps = newProduceSet(parent, produceRequestVersion(parent.conf))
 
 	now := time.Now()
 	msg := &ProducerMessage{
@@ -299,7 +301,7 @@ func TestProduceSetIdempotentRequestBuilding(t *testing.T) {
 			producerEpoch: pEpoch,
 		},
 	}
-	ps := newProduceSet(parent)
+	ps := newProduceSet(parent, produceRequestVersion(config))
 
 	now := time.Now()
 	msg := &ProducerMessage{
@@ -372,11 +374,12 @@ func TestProduceSetIdempotentRequestBuilding(t *testing.T) {
 }
 
 func TestProduceSetConsistentTimestamps(t *testing.T) {
-	parent, ps1 := makeProduceSet()
-	ps2 := newProduceSet(parent)
+	parent, _ := makeProduceSet()
 	parent.conf.Producer.RequiredAcks = WaitForAll
 	parent.conf.Producer.Timeout = 10 * time.Second
 	parent.conf.Version = V0_11_0_0
+	ps1 := newProduceSet(parent, produceRequestVersion(parent.conf))
+	ps2 := newProduceSet(parent, produceRequestVersion(parent.conf))
 
 	msg1 := &ProducerMessage{
 		Topic:          "t1",
@@ -0,0 +1,336 @@
+package sarama
+
+import (
+	"sync"
+	"time"
+)
+
+// RangeBound is the start or the end of a PartitionRange. It is either an
+// offset, which may be OffsetOldest or OffsetNewest, or a timestamp.
+type RangeBound struct {
+	Offset    int64
+	Timestamp time.Time
+}
+
+// OffsetBound returns a RangeBound at the given offset, which may be
+// OffsetOldest or OffsetNewest.
+func OffsetBound(offset int64) RangeBound {
+	return RangeBound{Offset: offset}
+}
+
+// TimestampBound returns a RangeBound at the first record whose timestamp is
+// equal to or later than t.
+func TimestampBound(t time.Time) RangeBound {
+	return RangeBound{Timestamp: t}
+}
+
+// PartitionRange is the part of a partition read by a RangeReader. Start is
+// inclusive and End is exclusive. An End of OffsetNewest, or a timestamp later
+// than the last record, stops at the high water mark as of the creation of the
+// RangeReader.
+type PartitionRange struct {
+	Start RangeBound
+	End   RangeBound
+}
+
+// RangeOrder specifies how a RangeReader orders the messages of different
+// partitions.
+type RangeOrder int
+
+const (
+	// RangeOrderPerPartition returns messages as soon as they are consumed.
+	// Messages of the same partition are returned in offset order, but messages
+	// of different partitions are interleaved arbitrarily.
+	RangeOrderPerPartition RangeOrder = iota
+	// RangeOrderByTimestamp merges the messages of all partitions by their
+	// timestamp, breaking ties by partition. A message is only returned once
+	// every unfinished partition has a message buffered, so a slow partition
+	// slows down all others.
+	RangeOrderByTimestamp
+)
+
+// RangeReader reads bounded ranges of the partitions of a topic and stops once
+// every partition reached the end of its range, which makes it suitable for
+// backfills and replays. You MUST call Close() on a RangeReader to avoid leaks,
+// even if it finished on its own.
+type RangeReader interface {
+	// Messages returns the read channel for the messages within the ranges. It
+	// is closed once every partition reached the end of its range, or after
+	// Close was called.
+	Messages() <-chan *ConsumerMessage
+
+	// Errors returns a read channel of errors that occurred during consuming, if
+	// enabled through the config's Consumer.Return.Errors setting. It is closed
+	// after the Messages channel.
+	Errors() <-chan *ConsumerError
+
+	// Close stops reading, if it has not finished yet, and releases all
+	// resources. It does not close the underlying client.
+	Close() error
+}
+
+type rangeReader struct {
+	consumer Consumer
+	order    RangeOrder
+
+	messages chan *ConsumerMessage
+	errors   chan *ConsumerError
+
+	closing   chan none
+	closeOnce sync.Once
+	done      chan none
+
+	partitionsWG sync.WaitGroup
+	errorsWG     sync.WaitGroup
+}
+
+// NewRangeReader creates a RangeReader for the given ranges of the partitions
+// of topic, using the given client. Start and end bounds given as timestamps or
+// as OffsetOldest/OffsetNewest are resolved with Client.GetOffset. Partitions
+// whose range is empty are skipped.
+//
+// Consumer.Return.PartitionEOF must be enabled in the client's config, as a
+// range whose end is a control record or lies in a compacted gap only
+// completes on the PartitionEOF event.
+func NewRangeReader(client Client, topic string, ranges map[int32]PartitionRange, order RangeOrder) (RangeReader, error) {
+	if !client.Config().Consumer.Return.PartitionEOF {
+		return nil, ConfigurationError("RangeReader requires Consumer.Return.PartitionEOF to be enabled")
+	}
+
+	type resolvedRange struct {
+		partition  int32
+		start, end int64
+	}
+
+	resolved := make([]resolvedRange, 0, len(ranges))
+	for partition, pr := range ranges {
+		start, end, err := resolveRange(client, topic, partition, pr)
+		if err != nil {
+			return nil, err
+		}
+		if start < end {
+			resolved = append(resolved, resolvedRange{partition, start, end})
+		}
+	}
+
+	consumer, err := NewConsumerFromClient(client)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := client.Config()
+	r := &rangeReader{
+		consumer: consumer,
+		order:    order,
+		messages: make(chan *ConsumerMessage, conf.ChannelBufferSize),
+		errors:   make(chan *ConsumerError, conf.ChannelBufferSize),
+		closing:  make(chan none),
+		done:     make(chan none),
+	}
+
+	var outputs []chan *ConsumerMessage
+	for _, rr := range resolved {
+		pc, err := consumer.ConsumePartition(topic, rr.partition, rr.start)
+		if err != nil {
+			close(r.closing)
+			r.partitionsWG.Wait()
+			r.errorsWG.Wait()
+			_ = consumer.Close()
+			return nil, err
+		}
+
+		out := r.messages
+		if order == RangeOrderByTimestamp {
+			out = make(chan *ConsumerMessage, conf.ChannelBufferSize)
+			outputs = append(outputs, out)
+		}
+
+		r.errorsWG.Add(1)
+		go withRecover(func() {
+			defer r.errorsWG.Done()
+			for err := range pc.Errors() {
+				select {
+				case r.errors <- err:
+				case <-r.closing:
+				}
+			}
+		})
+
+		end := rr.end
+		r.partitionsWG.Add(1)
+		go withRecover(func() {
+			defer r.partitionsWG.Done()
+			r.consumeRange(pc, end, out)
+			if out != r.messages {
+				close(out)
+			}
+		})
+	}
+
+	go withRecover(func() { r.run(outputs) })
+
+	return r, nil
+}
+
+// resolveRange turns the bounds of pr into a start offset and an exclusive end
+// offset.
+func resolveRange(client Client, topic string, partition int32, pr PartitionRange) (int64, int64, error) {
+	newest, err := client.GetOffset(topic, partition, OffsetNewest)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	resolve := func(bound RangeBound) (int64, error) {
+		timestamp := bound.Offset
+		if !bound.Timestamp.IsZero() {
+			timestamp = bound.Timestamp.UnixNano() / int64(time.Millisecond)
+		} else if bound.Offset >= 0 {
+			return bound.Offset, nil
+		} else if bound.Offset == OffsetNewest {
+			return newest, nil
+		}
+
+		offset, err := client.GetOffset(topic, partition, timestamp)
+		if err != nil {
+			return -1, err
+		}
+		if offset < 0 {
+			// no record at or after the timestamp
+			return newest, nil
+		}
+		return offset, nil
+	}
+
+	start, err := resolve(pr.Start)
+	if err != nil {
+		return -1, -1, err
+	}
+	end, err := resolve(pr.End)
+	if err != nil {
+		return -1, -1, err
+	}
+	if end > newest {
+		end = newest
+	}
+	return start, end, nil
+}
+
+// consumeRange forwards the messages of pc with an offset below end to out.
+func (r *rangeReader) consumeRange(pc PartitionConsumer, end int64, out chan<- *ConsumerMessage) {
+	defer func() {
+		pc.AsyncClose()
+		for range pc.Messages() {
+		}
+		for range pc.Events() {
+		}
+	}()
+
+	events := pc.Events()
+	for {
+		select {
+		case msg, ok := <-pc.Messages():
+			if !ok || msg.Offset >= end {
+				return
+			}
+			select {
+			case out <- msg:
+			case <-r.closing:
+				return
+			}
+			if msg.Offset >= end-1 {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if eof, isEOF := event.(*PartitionEOF); isEOF && eof.Offset >= end {
+				return
+			}
+		case <-r.closing:
+			return
+		}
+	}
+}
+
+func (r *rangeReader) run(outputs []chan *ConsumerMessage) {
+	defer close(r.done)
+
+	if r.order == RangeOrderByTimestamp {
+		r.merge(outputs)
+	}
+	r.partitionsWG.Wait()
+	close(r.messages)
+
+	r.errorsWG.Wait()
+	close(r.errors)
+
+	if err := r.consumer.Close(); err != nil {
+		Logger.Println("range reader failed to close consumer:", err)
+	}
+}
+
+// merge forwards the messages of outputs to r.messages in timestamp order.
+func (r *rangeReader) merge(outputs []chan *ConsumerMessage) {
+	heads := make([]*ConsumerMessage, len(outputs))
+	for {
+		next := -1
+		for i, output := range outputs {
+			if output == nil {
+				continue
+			}
+			if heads[i] == nil {
+				msg, ok := <-output
+				if !ok {
+					outputs[i] = nil
+					continue
+				}
+				heads[i] = msg
+			}
+			if next < 0 || rangeMessageBefore(heads[i], heads[next]) {
+				next = i
+			}
+		}
+		if next < 0 {
+			return
+		}
+
+		select {
+		case r.messages <- heads[next]:
+			heads[next] = nil
+		case <-r.closing:
+			// unblock the partitions that are still forwarding messages
+			for _, output := range outputs {
+				if output != nil {
+					for range output {
+					}
+				}
+			}
+			return
+		}
+	}
+}
+
+func rangeMessageBefore(a, b *ConsumerMessage) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.Before(b.Timestamp)
+	}
+	return a.Partition < b.Partition
+}
+
+func (r *rangeReader) Messages() <-chan *ConsumerMessage {
+	return r.messages
+}
+
+func (r *rangeReader) Errors() <-chan *ConsumerError {
+	return r.errors
+}
+
+func (r *rangeReader) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.closing)
+	})
+	<-r.done
+	return nil
+}
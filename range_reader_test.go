@@ -0,0 +1,136 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type rangeTestPartitionConsumer struct {
+	PartitionConsumer
+	messages chan *ConsumerMessage
+	errors   chan *ConsumerError
+	events   chan PartitionEvent
+	closed   bool
+}
+
+func newRangeTestPartitionConsumer(msgs ...*ConsumerMessage) *rangeTestPartitionConsumer {
+	pc := &rangeTestPartitionConsumer{
+		messages: make(chan *ConsumerMessage, len(msgs)),
+		errors:   make(chan *ConsumerError),
+		events:   make(chan PartitionEvent, 1),
+	}
+	for _, msg := range msgs {
+		pc.messages <- msg
+	}
+	return pc
+}
+
+func (pc *rangeTestPartitionConsumer) AsyncClose() {
+	if !pc.closed {
+		pc.closed = true
+		close(pc.messages)
+		close(pc.errors)
+		close(pc.events)
+	}
+}
+
+func (pc *rangeTestPartitionConsumer) Messages() <-chan *ConsumerMessage { return pc.messages }
+func (pc *rangeTestPartitionConsumer) Errors() <-chan *ConsumerError     { return pc.errors }
+func (pc *rangeTestPartitionConsumer) Events() <-chan PartitionEvent     { return pc.events }
+
+func TestRangeReaderConsumeRangeStopsAtEnd(t *testing.T) {
+	r := &rangeReader{closing: make(chan none)}
+	pc := newRangeTestPartitionConsumer(
+		&ConsumerMessage{Offset: 3},
+		&ConsumerMessage{Offset: 4},
+		&ConsumerMessage{Offset: 5},
+	)
+	out := make(chan *ConsumerMessage, 3)
+
+	r.consumeRange(pc, 5, out)
+	close(out)
+
+	var offsets []int64
+	for msg := range out {
+		offsets = append(offsets, msg.Offset)
+	}
+	if len(offsets) != 2 || offsets[0] != 3 || offsets[1] != 4 {
+		t.Errorf("expected offsets [3 4], got %v", offsets)
+	}
+	if !pc.closed {
+		t.Error("expected partition consumer to be closed")
+	}
+}
+
+func TestRangeReaderConsumeRangeStopsAtEOF(t *testing.T) {
+	r := &rangeReader{closing: make(chan none)}
+	pc := newRangeTestPartitionConsumer(&ConsumerMessage{Offset: 3})
+	out := make(chan *ConsumerMessage, 1)
+
+	// the last offset of the range is a control record, so only EOF tells us we are done
+	pc.events <- &PartitionEOF{Offset: 5}
+	done := make(chan none)
+	go func() {
+		r.consumeRange(pc, 5, out)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("range did not complete on PartitionEOF")
+	}
+}
+
+func TestRangeReaderMergeByTimestamp(t *testing.T) {
+	base := time.Now()
+	r := &rangeReader{
+		messages: make(chan *ConsumerMessage, 5),
+		closing:  make(chan none),
+	}
+
+	p0 := make(chan *ConsumerMessage, 3)
+	p0 <- &ConsumerMessage{Partition: 0, Offset: 0, Timestamp: base}
+	p0 <- &ConsumerMessage{Partition: 0, Offset: 1, Timestamp: base.Add(3 * time.Second)}
+	close(p0)
+	p1 := make(chan *ConsumerMessage, 3)
+	p1 <- &ConsumerMessage{Partition: 1, Offset: 0, Timestamp: base}
+	p1 <- &ConsumerMessage{Partition: 1, Offset: 1, Timestamp: base.Add(time.Second)}
+	p1 <- &ConsumerMessage{Partition: 1, Offset: 2, Timestamp: base.Add(5 * time.Second)}
+	close(p1)
+
+	r.merge([]chan *ConsumerMessage{p1, p0})
+	close(r.messages)
+
+	expected := []struct {
+		partition int32
+		offset    int64
+	}{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {1, 2}}
+	i := 0
+	for msg := range r.messages {
+		if msg.Partition != expected[i].partition || msg.Offset != expected[i].offset {
+			t.Errorf("message %d: expected %d/%d, got %d/%d", i, expected[i].partition, expected[i].offset, msg.Partition, msg.Offset)
+		}
+		i++
+	}
+	if i != len(expected) {
+		t.Errorf("expected %d messages, got %d", len(expected), i)
+	}
+}
+
+type rangeTestClient struct {
+	Client
+	conf *Config
+}
+
+func (c *rangeTestClient) Config() *Config { return c.conf }
+
+func TestNewRangeReaderRequiresPartitionEOF(t *testing.T) {
+	client := &rangeTestClient{conf: NewTestConfig()}
+
+	_, err := NewRangeReader(client, "my_topic", map[int32]PartitionRange{0: {Start: OffsetBound(0), End: OffsetBound(10)}}, RangeOrderPerPartition)
+	if !errors.As(err, new(ConfigurationError)) {
+		t.Errorf("expected a ConfigurationError, got %v", err)
+	}
+}
@@ -0,0 +1,546 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	leaderThrottledRateConfig       = "leader.replication.throttled.rate"
+	followerThrottledRateConfig     = "follower.replication.throttled.rate"
+	leaderThrottledReplicasConfig   = "leader.replication.throttled.replicas"
+	followerThrottledReplicasConfig = "follower.replication.throttled.replicas"
+)
+
+// ReassignmentPlanOptions configures ClusterAdmin.PlanReassignments.
+type ReassignmentPlanOptions struct {
+	// Brokers restricts the brokers replicas may be placed on. All brokers of the
+	// cluster are used if it is empty.
+	Brokers []int32
+	// ExcludeBrokers are removed from the target brokers, for example to
+	// decommission them.
+	ExcludeBrokers []int32
+	// IgnoreRacks places replicas without regard to the rack of the brokers.
+	// Racks are also ignored if any of the target brokers has no rack.
+	IgnoreRacks bool
+}
+
+// ReassignmentPlan is a partition reassignment generated by
+// ClusterAdmin.PlanReassignments. It only contains the partitions whose
+// replicas change.
+type ReassignmentPlan struct {
+	// Current is the replica assignment of the partitions before the
+	// reassignment, indexed by topic and partition.
+	Current map[string]map[int32][]int32
+	// Proposed is the replica assignment of the partitions after the
+	// reassignment. The first replica of each partition is its preferred leader.
+	Proposed map[string]map[int32][]int32
+}
+
+// Partitions returns the number of partitions in the plan.
+func (p *ReassignmentPlan) Partitions() int {
+	n := 0
+	for _, partitions := range p.Proposed {
+		n += len(partitions)
+	}
+	return n
+}
+
+// Moves returns the number of replicas the plan copies to a new broker.
+func (p *ReassignmentPlan) Moves() int {
+	n := 0
+	for topic, partitions := range p.Proposed {
+		for partition, replicas := range partitions {
+			n += len(addedReplicas(p.Current[topic][partition], replicas))
+		}
+	}
+	return n
+}
+
+// brokers returns the IDs of all brokers holding a current or proposed replica.
+func (p *ReassignmentPlan) brokers() []int32 {
+	seen := make(map[int32]bool)
+	for _, assignment := range []map[string]map[int32][]int32{p.Current, p.Proposed} {
+		for _, partitions := range assignment {
+			for _, replicas := range partitions {
+				for _, replica := range replicas {
+					seen[replica] = true
+				}
+			}
+		}
+	}
+	return sortedBrokerIDs(seen)
+}
+
+// ReassignmentProgress is the progress of a ReassignmentPlan as reported by
+// ClusterAdmin.ReassignmentProgress.
+type ReassignmentProgress struct {
+	// Total is the number of partitions in the plan.
+	Total int
+	// Completed is the number of partitions that are no longer being reassigned.
+	Completed int
+	// Ongoing contains the status of the partitions still being reassigned.
+	Ongoing map[string]map[int32]*PartitionReplicaReassignmentsStatus
+}
+
+// Done reports whether no partition of the plan is being reassigned anymore.
+func (p *ReassignmentProgress) Done() bool {
+	return p.Completed == p.Total
+}
+
+func (ca *clusterAdmin) PlanReassignments(topics []string, options *ReassignmentPlanOptions) (*ReassignmentPlan, error) {
+	if options == nil {
+		options = &ReassignmentPlanOptions{}
+	}
+
+	metadata, err := ca.DescribeTopics(topics)
+	if err != nil {
+		return nil, err
+	}
+	brokers, _, err := ca.DescribeCluster()
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]map[int32][]int32, len(metadata))
+	for _, topic := range metadata {
+		if !errors.Is(topic.Err, ErrNoError) {
+			return nil, fmt.Errorf("failed to describe topic %s: %w", topic.Name, topic.Err)
+		}
+		partitions := make(map[int32][]int32, len(topic.Partitions))
+		for _, partition := range topic.Partitions {
+			partitions[partition.ID] = partition.Replicas
+		}
+		current[topic.Name] = partitions
+	}
+
+	racks := make(map[int32]string, len(brokers))
+	for _, b := range brokers {
+		racks[b.ID()] = b.Rack()
+	}
+
+	targets := options.Brokers
+	if len(targets) == 0 {
+		for id := range racks {
+			targets = append(targets, id)
+		}
+	}
+	excluded := make(map[int32]bool, len(options.ExcludeBrokers))
+	for _, id := range options.ExcludeBrokers {
+		excluded[id] = true
+	}
+	targetSet := make(map[int32]bool, len(targets))
+	for _, id := range targets {
+		if _, ok := racks[id]; !ok {
+			return nil, ConfigurationError(fmt.Sprintf("broker %d is not part of the cluster", id))
+		}
+		if !excluded[id] {
+			targetSet[id] = true
+		}
+	}
+
+	proposed, err := planReassignments(current, racks, sortedBrokerIDs(targetSet), !options.IgnoreRacks)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &ReassignmentPlan{
+		Current:  make(map[string]map[int32][]int32),
+		Proposed: proposed,
+	}
+	for topic, partitions := range proposed {
+		plan.Current[topic] = make(map[int32][]int32, len(partitions))
+		for partition := range partitions {
+			plan.Current[topic][partition] = current[topic][partition]
+		}
+	}
+	return plan, nil
+}
+
+func (ca *clusterAdmin) ExecuteReassignments(plan *ReassignmentPlan, throttleRate int64) error {
+	if !ca.conf.versionCeiling().IsAtLeast(V2_4_0_0) {
+		return ConfigurationError("reassigning partitions requires Version >= V2_4_0_0")
+	}
+
+	if throttleRate > 0 {
+		if err := ca.setReassignmentThrottles(plan, throttleRate); err != nil {
+			return err
+		}
+	}
+
+	request := &AlterPartitionReassignmentsRequest{
+		TimeoutMs: int32(60000),
+		Version:   int16(0),
+	}
+	for topic, partitions := range plan.Proposed {
+		for partition, replicas := range partitions {
+			request.AddBlock(topic, partition, replicas)
+		}
+	}
+
+	return ca.alterPartitionReassignments(request)
+}
+
+func (ca *clusterAdmin) ReassignmentProgress(plan *ReassignmentPlan) (*ReassignmentProgress, error) {
+	request := &ListPartitionReassignmentsRequest{
+		TimeoutMs: int32(60000),
+		Version:   int16(0),
+	}
+	for topic, partitions := range plan.Proposed {
+		ids := make([]int32, 0, len(partitions))
+		for partition := range partitions {
+			ids = append(ids, partition)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		request.AddBlock(topic, ids)
+	}
+
+	rsp, err := ca.listPartitionReassignments(request)
+	if err != nil {
+		return nil, err
+	}
+	if !errors.Is(rsp.ErrorCode, ErrNoError) {
+		return nil, rsp.ErrorCode
+	}
+
+	progress := &ReassignmentProgress{
+		Total:   plan.Partitions(),
+		Ongoing: make(map[string]map[int32]*PartitionReplicaReassignmentsStatus),
+	}
+	ongoing := 0
+	for topic, partitions := range rsp.TopicStatus {
+		for partition, status := range partitions {
+			if _, ok := plan.Proposed[topic][partition]; !ok {
+				continue
+			}
+			if progress.Ongoing[topic] == nil {
+				progress.Ongoing[topic] = make(map[int32]*PartitionReplicaReassignmentsStatus)
+			}
+			progress.Ongoing[topic][partition] = status
+			ongoing++
+		}
+	}
+	progress.Completed = progress.Total - ongoing
+	return progress, nil
+}
+
+func (ca *clusterAdmin) AwaitReassignments(ctx context.Context, plan *ReassignmentPlan, pollInterval time.Duration, onProgress func(*ReassignmentProgress)) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		progress, err := ca.ReassignmentProgress(plan)
+		if err != nil {
+			return err
+		}
+		if onProgress != nil {
+			onProgress(progress)
+		}
+		if progress.Done() {
+			break
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return ca.ClearReassignmentThrottles(plan)
+}
+
+func (ca *clusterAdmin) ClearReassignmentThrottles(plan *ReassignmentPlan) error {
+	remove := IncrementalAlterConfigsEntry{Operation: IncrementalAlterConfigsOperationDelete}
+	return ca.alterReassignmentThrottles(plan,
+		map[string]IncrementalAlterConfigsEntry{
+			leaderThrottledRateConfig:   remove,
+			followerThrottledRateConfig: remove,
+		},
+		func(topic string) map[string]IncrementalAlterConfigsEntry {
+			return map[string]IncrementalAlterConfigsEntry{
+				leaderThrottledReplicasConfig:   remove,
+				followerThrottledReplicasConfig: remove,
+			}
+		})
+}
+
+// setReassignmentThrottles limits the replication traffic of the brokers
+// involved in the plan to throttleRate bytes per second. As in Kafka's
+// reassignment tool, the current replicas of the moved partitions are throttled
+// as leaders and the added replicas as followers.
+func (ca *clusterAdmin) setReassignmentThrottles(plan *ReassignmentPlan, throttleRate int64) error {
+	rate := strconv.FormatInt(throttleRate, 10)
+	return ca.alterReassignmentThrottles(plan,
+		map[string]IncrementalAlterConfigsEntry{
+			leaderThrottledRateConfig:   {Operation: IncrementalAlterConfigsOperationSet, Value: &rate},
+			followerThrottledRateConfig: {Operation: IncrementalAlterConfigsOperationSet, Value: &rate},
+		},
+		func(topic string) map[string]IncrementalAlterConfigsEntry {
+			var leaders, followers []string
+			partitions := make([]int32, 0, len(plan.Proposed[topic]))
+			for partition := range plan.Proposed[topic] {
+				partitions = append(partitions, partition)
+			}
+			sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+
+			for _, partition := range partitions {
+				current := plan.Current[topic][partition]
+				for _, replica := range current {
+					leaders = append(leaders, fmt.Sprintf("%d:%d", partition, replica))
+				}
+				for _, replica := range addedReplicas(current, plan.Proposed[topic][partition]) {
+					followers = append(followers, fmt.Sprintf("%d:%d", partition, replica))
+				}
+			}
+
+			leaderReplicas := strings.Join(leaders, ",")
+			followerReplicas := strings.Join(followers, ",")
+			return map[string]IncrementalAlterConfigsEntry{
+				leaderThrottledReplicasConfig:   {Operation: IncrementalAlterConfigsOperationSet, Value: &leaderReplicas},
+				followerThrottledReplicasConfig: {Operation: IncrementalAlterConfigsOperationSet, Value: &followerReplicas},
+			}
+		})
+}
+
+// alterReassignmentThrottles applies brokerEntries to every broker involved in
+// the plan and the entries returned by topicEntries to every topic of the plan.
+func (ca *clusterAdmin) alterReassignmentThrottles(plan *ReassignmentPlan, brokerEntries map[string]IncrementalAlterConfigsEntry, topicEntries func(topic string) map[string]IncrementalAlterConfigsEntry) error {
+	// Dynamic broker configs must be sent to the broker in question
+	for _, id := range plan.brokers() {
+		b, err := ca.findBroker(id)
+		if err != nil {
+			return err
+		}
+		err = ca.incrementalAlterConfigs(b, []*IncrementalAlterConfigsResource{{
+			Type:          BrokerResource,
+			Name:          strconv.Itoa(int(id)),
+			ConfigEntries: brokerEntries,
+		}})
+		if err != nil {
+			return err
+		}
+	}
+
+	topics := make([]string, 0, len(plan.Proposed))
+	for topic := range plan.Proposed {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	resources := make([]*IncrementalAlterConfigsResource, 0, len(topics))
+	for _, topic := range topics {
+		resources = append(resources, &IncrementalAlterConfigsResource{
+			Type:          TopicResource,
+			Name:          topic,
+			ConfigEntries: topicEntries(topic),
+		})
+	}
+	if len(resources) == 0 {
+		return nil
+	}
+
+	b, err := ca.findAnyBroker()
+	if err != nil {
+		return err
+	}
+	return ca.incrementalAlterConfigs(b, resources)
+}
+
+func (ca *clusterAdmin) incrementalAlterConfigs(b *Broker, resources []*IncrementalAlterConfigsResource) error {
+	_ = b.Open(ca.client.Config())
+	rsp, err := b.IncrementalAlterConfigs(&IncrementalAlterConfigsRequest{Resources: resources})
+	if err != nil {
+		return err
+	}
+
+	for _, rspResource := range rsp.Resources {
+		if rspResource.ErrorMsg != "" {
+			return fmt.Errorf("failed to alter config of %s: %s", rspResource.Name, rspResource.ErrorMsg)
+		}
+		if rspResource.ErrorCode != 0 {
+			return fmt.Errorf("failed to alter config of %s: %w", rspResource.Name, KError(rspResource.ErrorCode))
+		}
+	}
+	return nil
+}
+
+// plannedPartition is the state of a single partition in planReassignments.
+type plannedPartition struct {
+	topic     string
+	partition int32
+	current   []int32
+	replicas  []int32
+}
+
+// planReassignments moves the replicas of the current assignment onto the
+// target brokers, which must be sorted. It keeps every replica that is already
+// on a target broker, unless that violates rack awareness, and places the
+// missing replicas on the least loaded target brokers. If rackAware is set and
+// all target brokers have a rack, the replicas of a partition are spread evenly
+// across racks. Finally the preferred leaders of the partitions that lost theirs
+// are chosen to balance leadership across the target brokers. Only the
+// partitions whose replicas change are returned.
+func planReassignments(current map[string]map[int32][]int32, racks map[int32]string, targets []int32, rackAware bool) (map[string]map[int32][]int32, error) {
+	targetSet := make(map[int32]bool, len(targets))
+	distinctRacks := make(map[string]bool)
+	for _, id := range targets {
+		targetSet[id] = true
+		if racks[id] == "" {
+			rackAware = false
+		}
+		distinctRacks[racks[id]] = true
+	}
+
+	topics := make([]string, 0, len(current))
+	for topic := range current {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	var planned []*plannedPartition
+	for _, topic := range topics {
+		partitions := make([]int32, 0, len(current[topic]))
+		for partition := range current[topic] {
+			partitions = append(partitions, partition)
+		}
+		sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+
+		for _, partition := range partitions {
+			replicas := current[topic][partition]
+			if len(replicas) > len(targets) {
+				return nil, ConfigurationError(fmt.Sprintf("cannot place %d replicas of %s-%d on %d brokers",
+					len(replicas), topic, partition, len(targets)))
+			}
+			planned = append(planned, &plannedPartition{topic: topic, partition: partition, current: replicas})
+		}
+	}
+
+	// maxPerRack is the number of replicas of a partition a single rack may hold
+	maxPerRack := func(p *plannedPartition) int {
+		if !rackAware {
+			return len(p.current)
+		}
+		return (len(p.current) + len(distinctRacks) - 1) / len(distinctRacks)
+	}
+
+	// Keep the replicas that may stay where they are
+	load := make(map[int32]int, len(targets))
+	for _, p := range planned {
+		perRack := make(map[string]int)
+		for _, replica := range p.current {
+			if !targetSet[replica] || containsInt32(p.replicas, replica) || perRack[racks[replica]] >= maxPerRack(p) {
+				continue
+			}
+			p.replicas = append(p.replicas, replica)
+			perRack[racks[replica]]++
+			load[replica]++
+		}
+	}
+
+	// Place the missing replicas on the least loaded brokers
+	for _, p := range planned {
+		perRack := make(map[string]int)
+		for _, replica := range p.replicas {
+			perRack[racks[replica]]++
+		}
+		for len(p.replicas) < len(p.current) {
+			best := int32(-1)
+			for _, ignoreRack := range []bool{false, true} {
+				for _, id := range targets {
+					if containsInt32(p.replicas, id) || (!ignoreRack && perRack[racks[id]] >= maxPerRack(p)) {
+						continue
+					}
+					if best < 0 || load[id] < load[best] {
+						best = id
+					}
+				}
+				if best >= 0 {
+					break
+				}
+			}
+			p.replicas = append(p.replicas, best)
+			perRack[racks[best]]++
+			load[best]++
+		}
+	}
+
+	// Keep the preferred leaders that stay and balance the others
+	leaders := make(map[int32]int, len(targets))
+	var leaderless []*plannedPartition
+	for _, p := range planned {
+		if len(p.current) > 0 && len(p.replicas) > 0 && p.replicas[0] == p.current[0] {
+			leaders[p.replicas[0]]++
+		} else if len(p.replicas) > 0 {
+			leaderless = append(leaderless, p)
+		}
+	}
+	for _, p := range leaderless {
+		best := 0
+		for i, replica := range p.replicas {
+			if leaders[replica] < leaders[p.replicas[best]] {
+				best = i
+			}
+		}
+		leader := p.replicas[best]
+		copy(p.replicas[1:best+1], p.replicas[:best])
+		p.replicas[0] = leader
+		leaders[leader]++
+	}
+
+	proposed := make(map[string]map[int32][]int32)
+	for _, p := range planned {
+		if int32SlicesEqual(p.current, p.replicas) {
+			continue
+		}
+		if proposed[p.topic] == nil {
+			proposed[p.topic] = make(map[int32][]int32)
+		}
+		proposed[p.topic][p.partition] = p.replicas
+	}
+	return proposed, nil
+}
+
+// addedReplicas returns the replicas of proposed that are not in current.
+func addedReplicas(current, proposed []int32) []int32 {
+	var added []int32
+	for _, replica := range proposed {
+		if !containsInt32(current, replica) {
+			added = append(added, replica)
+		}
+	}
+	return added
+}
+
+func sortedBrokerIDs(ids map[int32]bool) []int32 {
+	sorted := make([]int32, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+func containsInt32(s []int32, v int32) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func int32SlicesEqual(a, b []int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,248 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanReassignmentsDecommission(t *testing.T) {
+	racks := map[int32]string{1: "a", 2: "b", 3: "c", 4: "a"}
+	current := map[string]map[int32][]int32{
+		"my_topic": {
+			0: {1, 2},
+			1: {2, 4},
+			2: {4, 3},
+			3: {3, 1},
+		},
+	}
+
+	proposed, err := planReassignments(current, racks, []int32{1, 2, 3}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]map[int32][]int32{
+		"my_topic": {
+			1: {2, 1},
+			2: {3, 2},
+		},
+	}
+	if !reflect.DeepEqual(proposed, expected) {
+		t.Errorf("Expected plan %v, got %v", expected, proposed)
+	}
+}
+
+func TestPlanReassignmentsFixesRackViolations(t *testing.T) {
+	racks := map[int32]string{1: "a", 2: "b", 3: "c", 4: "a"}
+	current := map[string]map[int32][]int32{
+		"my_topic": {
+			0: {1, 4},
+			1: {2, 3},
+		},
+	}
+
+	proposed, err := planReassignments(current, racks, []int32{1, 2, 3, 4}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replicas := proposed["my_topic"][0]
+	if len(replicas) != 2 || replicas[0] != 1 || racks[replicas[1]] == "a" {
+		t.Errorf("Expected partition 0 to keep broker 1 and move to another rack, got %v", replicas)
+	}
+	if _, ok := proposed["my_topic"][1]; ok {
+		t.Errorf("Expected partition 1 to stay where it is, got %v", proposed["my_topic"][1])
+	}
+
+	proposed, err = planReassignments(current, racks, []int32{1, 2, 3, 4}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proposed) != 0 {
+		t.Errorf("Expected no moves without rack awareness, got %v", proposed)
+	}
+}
+
+func TestPlanReassignmentsBalancesLeaders(t *testing.T) {
+	current := map[string]map[int32][]int32{
+		"my_topic": {
+			0: {3, 1},
+			1: {3, 2},
+			2: {3, 1},
+			3: {3, 2},
+		},
+	}
+
+	proposed, err := planReassignments(current, map[int32]string{}, []int32{1, 2}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaders := make(map[int32]int)
+	for partition, replicas := range proposed["my_topic"] {
+		if len(replicas) != 2 || replicas[0] == replicas[1] || containsInt32(replicas, 3) {
+			t.Errorf("Unexpected replicas %v for partition %d", replicas, partition)
+		}
+		leaders[replicas[0]]++
+	}
+	if leaders[1] != 2 || leaders[2] != 2 {
+		t.Errorf("Expected 2 leaders on each broker, got %v", leaders)
+	}
+}
+
+func TestPlanReassignmentsNotEnoughBrokers(t *testing.T) {
+	current := map[string]map[int32][]int32{
+		"my_topic": {0: {1, 2, 3}},
+	}
+
+	if _, err := planReassignments(current, map[int32]string{}, []int32{1, 2}, false); err == nil {
+		t.Error("Expected an error when there are fewer brokers than replicas")
+	}
+}
+
+func TestClusterAdminReassignments(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	secondBroker := NewMockBroker(t, 2)
+	defer secondBroker.Close()
+	thirdBroker := NewMockBroker(t, 3)
+	defer thirdBroker.Close()
+
+	metadata := &MetadataResponse{Version: 9, ControllerID: seedBroker.BrokerID()}
+	metadata.AddBroker(seedBroker.Addr(), seedBroker.BrokerID())
+	metadata.AddBroker(secondBroker.Addr(), secondBroker.BrokerID())
+	metadata.AddBroker(thirdBroker.Addr(), thirdBroker.BrokerID())
+	metadata.AddTopicPartition("my_topic", 0, 1, []int32{1, 3}, []int32{1, 3}, nil, ErrNoError)
+	metadata.AddTopicPartition("my_topic", 1, 3, []int32{3, 2}, []int32{3, 2}, nil, ErrNoError)
+
+	ongoing := &ListPartitionReassignmentsResponse{}
+	ongoing.AddBlock("my_topic", 1, []int32{2, 1, 3}, []int32{1}, []int32{3})
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest":                 NewMockApiVersionsResponse(t),
+		"MetadataRequest":                    NewMockWrapper(metadata),
+		"IncrementalAlterConfigsRequest":     NewMockIncrementalAlterConfigsResponse(t),
+		"AlterPartitionReassignmentsRequest": NewMockAlterPartitionReassignmentsResponse(t),
+		"ListPartitionReassignmentsRequest":  NewMockSequence(ongoing, &ListPartitionReassignmentsResponse{}),
+	})
+	for _, b := range []*MockBroker{secondBroker, thirdBroker} {
+		b.SetHandlerByMap(map[string]MockResponse{
+			"ApiVersionsRequest":             NewMockApiVersionsResponse(t),
+			"IncrementalAlterConfigsRequest": NewMockIncrementalAlterConfigsResponse(t),
+		})
+	}
+
+	config := NewTestConfig()
+	config.Version = V2_4_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	plan, err := admin.PlanReassignments([]string{"my_topic"}, &ReassignmentPlanOptions{
+		ExcludeBrokers: []int32{3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]map[int32][]int32{
+		"my_topic": {
+			0: {1, 2},
+			1: {2, 1},
+		},
+	}
+	if !reflect.DeepEqual(plan.Proposed, expected) {
+		t.Fatalf("Expected plan %v, got %v", expected, plan.Proposed)
+	}
+	if plan.Moves() != 2 {
+		t.Errorf("Expected 2 moves, got %d", plan.Moves())
+	}
+
+	if err := admin.ExecuteReassignments(plan, 1000); err != nil {
+		t.Fatal(err)
+	}
+
+	var brokerThrottles int
+	var topicThrottles *IncrementalAlterConfigsResource
+	for _, b := range []*MockBroker{seedBroker, secondBroker, thirdBroker} {
+		for _, rr := range b.History() {
+			request, ok := rr.Request.(*IncrementalAlterConfigsRequest)
+			if !ok {
+				continue
+			}
+			for _, resource := range request.Resources {
+				if resource.Type == TopicResource {
+					topicThrottles = resource
+				} else if *resource.ConfigEntries[leaderThrottledRateConfig].Value == "1000" {
+					brokerThrottles++
+				}
+			}
+		}
+	}
+	if brokerThrottles != 3 {
+		t.Errorf("Expected throttles on 3 brokers, got %d", brokerThrottles)
+	}
+	if topicThrottles == nil {
+		t.Fatal("Expected topic throttles")
+	}
+	if v := *topicThrottles.ConfigEntries[leaderThrottledReplicasConfig].Value; v != "0:1,0:3,1:3,1:2" {
+		t.Errorf("Unexpected leader throttled replicas %s", v)
+	}
+	if v := *topicThrottles.ConfigEntries[followerThrottledReplicasConfig].Value; v != "0:2,1:1" {
+		t.Errorf("Unexpected follower throttled replicas %s", v)
+	}
+
+	var progress []*ReassignmentProgress
+	err = admin.AwaitReassignments(context.Background(), plan, time.Millisecond, func(p *ReassignmentProgress) {
+		progress = append(progress, p)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(progress) != 2 {
+		t.Fatalf("Expected 2 progress reports, got %d", len(progress))
+	}
+	if progress[0].Total != 2 || progress[0].Completed != 1 || progress[0].Ongoing["my_topic"][1] == nil {
+		t.Errorf("Unexpected first progress %+v", progress[0])
+	}
+	if !progress[1].Done() {
+		t.Errorf("Expected the reassignment to be done, got %+v", progress[1])
+	}
+
+	var cleared int
+	for _, b := range []*MockBroker{seedBroker, secondBroker, thirdBroker} {
+		for _, rr := range b.History() {
+			request, ok := rr.Request.(*IncrementalAlterConfigsRequest)
+			if !ok {
+				continue
+			}
+			for _, resource := range request.Resources {
+				for _, entry := range resource.ConfigEntries {
+					if entry.Operation == IncrementalAlterConfigsOperationDelete {
+						cleared++
+					}
+				}
+			}
+		}
+	}
+	if cleared != 8 {
+		t.Errorf("Expected 8 cleared throttle configs, got %d", cleared)
+	}
+
+	// a stalled reassignment is awaited until the context is done
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest":                NewMockApiVersionsResponse(t),
+		"MetadataRequest":                   NewMockWrapper(metadata),
+		"ListPartitionReassignmentsRequest": NewMockWrapper(ongoing),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := admin.AwaitReassignments(ctx, plan, time.Millisecond, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context deadline to be exceeded, got %v", err)
+	}
+}
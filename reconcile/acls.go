@@ -0,0 +1,296 @@
+package reconcile
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kcore-io/sarama"
+)
+
+var (
+	// ErrApplyACLs is returned by ApplyACLs when some changes of the plan fail.
+	ErrApplyACLs = errors.New("reconcile: failed to apply ACL plan")
+
+	// ErrDeleteAllACLs is returned by PlanACLs when the plan would delete every
+	// ACL in scope, which usually means that the declared ACLs failed to load.
+	// Set ACLOptions.AllowDeleteAll to plan it anyway.
+	ErrDeleteAllACLs = errors.New("reconcile: refusing to delete all ACLs")
+)
+
+// clusterResourceName is the only resource name of the cluster resource type.
+const clusterResourceName = "kafka-cluster"
+
+// ACL is a declared access control entry. The operation, permission, resource
+// type and pattern type are unmarshaled from their names, for example "Read",
+// "Allow", "Topic" and "Prefixed".
+type ACL struct {
+	Principal string `yaml:"principal" json:"principal"`
+	// Host is the host the principal may connect from, "*" if empty.
+	Host      string              `yaml:"host,omitempty" json:"host,omitempty"`
+	Operation sarama.AclOperation `yaml:"operation" json:"operation"`
+	// Permission is AclPermissionAllow if unset.
+	Permission   sarama.AclPermissionType `yaml:"permission,omitempty" json:"permission,omitempty"`
+	ResourceType sarama.AclResourceType   `yaml:"resourceType" json:"resourceType"`
+	// ResourceName is "kafka-cluster" if unset for the cluster resource type.
+	ResourceName string `yaml:"resourceName,omitempty" json:"resourceName,omitempty"`
+	// PatternType is AclPatternLiteral if unset.
+	PatternType sarama.AclResourcePatternType `yaml:"patternType,omitempty" json:"patternType,omitempty"`
+}
+
+func (a ACL) String() string {
+	return fmt.Sprintf("%s %s %s on %s:%s:%s from %s",
+		a.Principal, a.Permission.String(), a.Operation.String(),
+		a.ResourceType.String(), a.PatternType.String(), a.ResourceName, a.Host)
+}
+
+// normalize fills in the defaults of a declared ACL and validates it.
+func (a ACL) normalize() (ACL, error) {
+	if a.Host == "" {
+		a.Host = "*"
+	}
+	if a.Permission == sarama.AclPermissionUnknown {
+		a.Permission = sarama.AclPermissionAllow
+	}
+	if a.PatternType == sarama.AclPatternUnknown {
+		a.PatternType = sarama.AclPatternLiteral
+	}
+	if a.ResourceType == sarama.AclResourceCluster && a.ResourceName == "" {
+		a.ResourceName = clusterResourceName
+	}
+
+	switch {
+	case a.Principal == "":
+		return a, sarama.ConfigurationError(fmt.Sprintf("ACL %s has no principal", a))
+	case a.Operation == sarama.AclOperationUnknown || a.Operation == sarama.AclOperationAny:
+		return a, sarama.ConfigurationError(fmt.Sprintf("ACL %s has no operation", a))
+	case a.Permission == sarama.AclPermissionAny:
+		return a, sarama.ConfigurationError(fmt.Sprintf("ACL %s has no permission", a))
+	case a.ResourceType == sarama.AclResourceUnknown || a.ResourceType == sarama.AclResourceAny:
+		return a, sarama.ConfigurationError(fmt.Sprintf("ACL %s has no resource type", a))
+	case a.ResourceName == "":
+		return a, sarama.ConfigurationError(fmt.Sprintf("ACL %s has no resource name", a))
+	case a.PatternType != sarama.AclPatternLiteral && a.PatternType != sarama.AclPatternPrefixed:
+		return a, sarama.ConfigurationError(fmt.Sprintf("ACL %s must have a literal or prefixed pattern type", a))
+	}
+	return a, nil
+}
+
+// filter returns the AclFilter that matches exactly this ACL and no other.
+func (a ACL) filter() sarama.AclFilter {
+	return sarama.AclFilter{
+		ResourceType:              a.ResourceType,
+		ResourceName:              &a.ResourceName,
+		ResourcePatternTypeFilter: a.PatternType,
+		Principal:                 &a.Principal,
+		Host:                      &a.Host,
+		Operation:                 a.Operation,
+		PermissionType:            a.Permission,
+	}
+}
+
+// ACLOptions configures PlanACLs.
+type ACLOptions struct {
+	// Principals restricts the plan to the ACLs of these principals. ACLs of
+	// other principals are neither deleted nor reported as drift. All ACLs of
+	// the cluster are in scope if it is empty.
+	Principals []string
+	// Delete plans the deletion of the ACLs in scope that are not declared.
+	// Otherwise they are reported as drift.
+	Delete bool
+	// AllowDeleteAll allows a plan that deletes every ACL in scope, for example
+	// because no ACL is declared.
+	AllowDeleteAll bool
+}
+
+// ACLPlan contains the changes needed to reach the declared ACLs.
+type ACLPlan struct {
+	Creates []ACL
+	Deletes []ACL
+	// Drift contains the ACLs in scope that are not declared, if
+	// ACLOptions.Delete is not set.
+	Drift []*Drift
+}
+
+// Empty reports whether the plan contains no changes. It may still report
+// drift.
+func (p *ACLPlan) Empty() bool {
+	return len(p.Creates) == 0 && len(p.Deletes) == 0
+}
+
+// String formats the plan for human review, one change per line.
+func (p *ACLPlan) String() string {
+	var b strings.Builder
+	for _, acl := range p.Creates {
+		fmt.Fprintf(&b, "+ create ACL %s\n", acl)
+	}
+	for _, acl := range p.Deletes {
+		fmt.Fprintf(&b, "- delete ACL %s\n", acl)
+	}
+	for _, drift := range p.Drift {
+		fmt.Fprintf(&b, "! %s\n", drift)
+	}
+	return b.String()
+}
+
+// PlanACLs diffs the declared ACLs against the ACLs of the cluster, as returned
+// by ListAcls, and returns the plan to reconcile them. Declared ACLs of
+// principals outside of ACLOptions.Principals are rejected.
+func PlanACLs(admin sarama.ClusterAdmin, declared []ACL, options *ACLOptions) (*ACLPlan, error) {
+	if options == nil {
+		options = &ACLOptions{}
+	}
+
+	scope := make(map[string]bool, len(options.Principals))
+	for _, principal := range options.Principals {
+		scope[principal] = true
+	}
+
+	desired := make(map[ACL]bool, len(declared))
+	for _, acl := range declared {
+		acl, err := acl.normalize()
+		if err != nil {
+			return nil, err
+		}
+		if len(scope) > 0 && !scope[acl.Principal] {
+			return nil, sarama.ConfigurationError(fmt.Sprintf("ACL %s is outside of the reconciled principals", acl))
+		}
+		desired[acl] = true
+	}
+
+	current, err := listACLs(admin, options.Principals)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := diffACLs(desired, current, options.Delete)
+	if len(plan.Deletes) > 0 && len(plan.Deletes) == len(current) && !options.AllowDeleteAll {
+		return nil, ErrDeleteAllACLs
+	}
+	return plan, nil
+}
+
+// listACLs returns every ACL of the given principals, or of the whole cluster
+// if principals is empty.
+func listACLs(admin sarama.ClusterAdmin, principals []string) (map[ACL]bool, error) {
+	filter := sarama.AclFilter{
+		ResourceType:              sarama.AclResourceAny,
+		ResourcePatternTypeFilter: sarama.AclPatternAny,
+		Operation:                 sarama.AclOperationAny,
+		PermissionType:            sarama.AclPermissionAny,
+	}
+
+	var resourceACLs []sarama.ResourceAcls
+	if len(principals) == 0 {
+		acls, err := admin.ListAcls(filter)
+		if err != nil {
+			return nil, err
+		}
+		resourceACLs = acls
+	}
+	for _, principal := range principals {
+		principal := principal
+		filter.Principal = &principal
+		acls, err := admin.ListAcls(filter)
+		if err != nil {
+			return nil, err
+		}
+		resourceACLs = append(resourceACLs, acls...)
+	}
+
+	current := make(map[ACL]bool)
+	for _, resourceACL := range resourceACLs {
+		for _, acl := range resourceACL.Acls {
+			current[ACL{
+				Principal:    acl.Principal,
+				Host:         acl.Host,
+				Operation:    acl.Operation,
+				Permission:   acl.PermissionType,
+				ResourceType: resourceACL.ResourceType,
+				ResourceName: resourceACL.ResourceName,
+				PatternType:  resourceACL.ResourcePatternType,
+			}] = true
+		}
+	}
+	return current, nil
+}
+
+func diffACLs(desired, current map[ACL]bool, remove bool) *ACLPlan {
+	plan := &ACLPlan{}
+	for acl := range desired {
+		if !current[acl] {
+			plan.Creates = append(plan.Creates, acl)
+		}
+	}
+	for acl := range current {
+		if desired[acl] {
+			continue
+		}
+		if remove {
+			plan.Deletes = append(plan.Deletes, acl)
+		} else {
+			plan.Drift = append(plan.Drift, &Drift{Resource: acl.String(), Reason: "ACL exists but is not declared"})
+		}
+	}
+
+	sortACLs(plan.Creates)
+	sortACLs(plan.Deletes)
+	sort.Slice(plan.Drift, func(i, j int) bool { return plan.Drift[i].Resource < plan.Drift[j].Resource })
+	return plan
+}
+
+func sortACLs(acls []ACL) {
+	sort.Slice(acls, func(i, j int) bool { return acls[i].String() < acls[j].String() })
+}
+
+// ApplyACLs executes a plan returned by PlanACLs. Each ACL is deleted with a
+// filter that matches exactly that ACL, so applying a plan again, or after its
+// ACLs were changed by someone else, has no further effect. It attempts every
+// change and returns the errors of those that failed wrapped in ErrApplyACLs.
+func ApplyACLs(admin sarama.ClusterAdmin, plan *ACLPlan) error {
+	var errs []error
+
+	if len(plan.Creates) > 0 {
+		byResource := make(map[sarama.Resource]*sarama.ResourceAcls)
+		var resourceACLs []*sarama.ResourceAcls
+		for _, acl := range plan.Creates {
+			resource := sarama.Resource{
+				ResourceType:        acl.ResourceType,
+				ResourceName:        acl.ResourceName,
+				ResourcePatternType: acl.PatternType,
+			}
+			if byResource[resource] == nil {
+				byResource[resource] = &sarama.ResourceAcls{Resource: resource}
+				resourceACLs = append(resourceACLs, byResource[resource])
+			}
+			byResource[resource].Acls = append(byResource[resource].Acls, &sarama.Acl{
+				Principal:      acl.Principal,
+				Host:           acl.Host,
+				Operation:      acl.Operation,
+				PermissionType: acl.Permission,
+			})
+		}
+		if err := admin.CreateACLs(resourceACLs); err != nil {
+			errs = append(errs, fmt.Errorf("create ACLs: %w", err))
+		}
+	}
+
+	for _, acl := range plan.Deletes {
+		matching, err := admin.DeleteACL(acl.filter(), false)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("delete ACL %s: %w", acl, err))
+			continue
+		}
+		for _, match := range matching {
+			if !errors.Is(match.Err, sarama.ErrNoError) {
+				errs = append(errs, fmt.Errorf("delete ACL %s: %w", acl, match.Err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return sarama.Wrap(ErrApplyACLs, errs...)
+	}
+	return nil
+}
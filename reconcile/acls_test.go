@@ -0,0 +1,193 @@
+package reconcile
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kcore-io/sarama"
+)
+
+func (a *fakeAdmin) ListAcls(filter sarama.AclFilter) ([]sarama.ResourceAcls, error) {
+	a.aclFilters = append(a.aclFilters, filter)
+	var matching []sarama.ResourceAcls
+	for _, resourceACL := range a.acls {
+		var acls []*sarama.Acl
+		for _, acl := range resourceACL.Acls {
+			if filter.Principal == nil || *filter.Principal == acl.Principal {
+				acls = append(acls, acl)
+			}
+		}
+		if len(acls) > 0 {
+			matching = append(matching, sarama.ResourceAcls{Resource: resourceACL.Resource, Acls: acls})
+		}
+	}
+	return matching, nil
+}
+
+func (a *fakeAdmin) CreateACLs(resourceACLs []*sarama.ResourceAcls) error {
+	a.createdACLs = append(a.createdACLs, resourceACLs...)
+	return nil
+}
+
+func (a *fakeAdmin) DeleteACL(filter sarama.AclFilter, validateOnly bool) ([]sarama.MatchingAcl, error) {
+	a.deleteFilter = append(a.deleteFilter, filter)
+	return []sarama.MatchingAcl{{Err: sarama.ErrNoError}}, nil
+}
+
+func newFakeACLAdmin() *fakeAdmin {
+	return &fakeAdmin{
+		acls: []sarama.ResourceAcls{
+			{
+				Resource: sarama.Resource{
+					ResourceType:        sarama.AclResourceTopic,
+					ResourceName:        "orders",
+					ResourcePatternType: sarama.AclPatternLiteral,
+				},
+				Acls: []*sarama.Acl{
+					{Principal: "User:alice", Host: "*", Operation: sarama.AclOperationRead, PermissionType: sarama.AclPermissionAllow},
+					{Principal: "User:bob", Host: "*", Operation: sarama.AclOperationWrite, PermissionType: sarama.AclPermissionAllow},
+				},
+			},
+			{
+				Resource: sarama.Resource{
+					ResourceType:        sarama.AclResourceGroup,
+					ResourceName:        "billing-",
+					ResourcePatternType: sarama.AclPatternPrefixed,
+				},
+				Acls: []*sarama.Acl{
+					{Principal: "User:alice", Host: "*", Operation: sarama.AclOperationRead, PermissionType: sarama.AclPermissionAllow},
+				},
+			},
+		},
+	}
+}
+
+func TestPlanACLs(t *testing.T) {
+	admin := newFakeACLAdmin()
+	declared := []ACL{
+		{Principal: "User:alice", Operation: sarama.AclOperationRead, ResourceType: sarama.AclResourceTopic, ResourceName: "orders"},
+		{Principal: "User:alice", Operation: sarama.AclOperationDescribe, ResourceType: sarama.AclResourceCluster},
+	}
+
+	plan, err := PlanACLs(admin, declared, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedCreates := []ACL{{
+		Principal:    "User:alice",
+		Host:         "*",
+		Operation:    sarama.AclOperationDescribe,
+		Permission:   sarama.AclPermissionAllow,
+		ResourceType: sarama.AclResourceCluster,
+		ResourceName: "kafka-cluster",
+		PatternType:  sarama.AclPatternLiteral,
+	}}
+	if !reflect.DeepEqual(plan.Creates, expectedCreates) {
+		t.Errorf("Expected creates %v, got %v", expectedCreates, plan.Creates)
+	}
+	if len(plan.Deletes) != 0 {
+		t.Errorf("Expected no deletes without Delete, got %v", plan.Deletes)
+	}
+	if len(plan.Drift) != 2 {
+		t.Errorf("Expected 2 undeclared ACLs as drift, got %v", plan.Drift)
+	}
+}
+
+func TestPlanACLsWithDeleteInScope(t *testing.T) {
+	admin := newFakeACLAdmin()
+	declared := []ACL{
+		{Principal: "User:alice", Operation: sarama.AclOperationRead, ResourceType: sarama.AclResourceTopic, ResourceName: "orders"},
+	}
+
+	plan, err := PlanACLs(admin, declared, &ACLOptions{
+		Principals: []string{"User:alice"},
+		Delete:     true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.Creates) != 0 {
+		t.Errorf("Expected no creates, got %v", plan.Creates)
+	}
+	if len(plan.Deletes) != 1 || plan.Deletes[0].ResourceName != "billing-" || plan.Deletes[0].PatternType != sarama.AclPatternPrefixed {
+		t.Errorf("Expected to delete the billing- group ACL, got %v", plan.Deletes)
+	}
+	if len(admin.aclFilters) != 1 || *admin.aclFilters[0].Principal != "User:alice" {
+		t.Errorf("Expected ACLs to be listed for User:alice only, got %+v", admin.aclFilters)
+	}
+
+	if _, err := PlanACLs(admin, []ACL{{
+		Principal: "User:bob", Operation: sarama.AclOperationRead, ResourceType: sarama.AclResourceTopic, ResourceName: "orders",
+	}}, &ACLOptions{Principals: []string{"User:alice"}}); err == nil {
+		t.Error("Expected an error for an ACL outside of the reconciled principals")
+	}
+}
+
+func TestPlanACLsRefusesToDeleteAll(t *testing.T) {
+	admin := newFakeACLAdmin()
+
+	if _, err := PlanACLs(admin, nil, &ACLOptions{Delete: true}); !errors.Is(err, ErrDeleteAllACLs) {
+		t.Errorf("Expected ErrDeleteAllACLs, got %v", err)
+	}
+
+	plan, err := PlanACLs(admin, nil, &ACLOptions{Delete: true, AllowDeleteAll: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Deletes) != 3 {
+		t.Errorf("Expected to delete 3 ACLs, got %v", plan.Deletes)
+	}
+}
+
+func TestPlanACLsInvalidDeclaration(t *testing.T) {
+	admin := newFakeACLAdmin()
+
+	for _, acl := range []ACL{
+		{Operation: sarama.AclOperationRead, ResourceType: sarama.AclResourceTopic, ResourceName: "orders"},
+		{Principal: "User:alice", ResourceType: sarama.AclResourceTopic, ResourceName: "orders"},
+		{Principal: "User:alice", Operation: sarama.AclOperationRead, ResourceName: "orders"},
+		{Principal: "User:alice", Operation: sarama.AclOperationRead, ResourceType: sarama.AclResourceTopic},
+		{Principal: "User:alice", Operation: sarama.AclOperationRead, ResourceType: sarama.AclResourceTopic, ResourceName: "orders", PatternType: sarama.AclPatternMatch},
+	} {
+		if _, err := PlanACLs(admin, []ACL{acl}, nil); err == nil {
+			t.Errorf("Expected an error for %v", acl)
+		}
+	}
+}
+
+func TestApplyACLs(t *testing.T) {
+	admin := newFakeACLAdmin()
+	plan := &ACLPlan{
+		Creates: []ACL{
+			{Principal: "User:alice", Host: "*", Operation: sarama.AclOperationRead, Permission: sarama.AclPermissionAllow,
+				ResourceType: sarama.AclResourceTopic, ResourceName: "payments", PatternType: sarama.AclPatternLiteral},
+			{Principal: "User:alice", Host: "*", Operation: sarama.AclOperationDescribe, Permission: sarama.AclPermissionAllow,
+				ResourceType: sarama.AclResourceTopic, ResourceName: "payments", PatternType: sarama.AclPatternLiteral},
+		},
+		Deletes: []ACL{
+			{Principal: "User:bob", Host: "*", Operation: sarama.AclOperationWrite, Permission: sarama.AclPermissionAllow,
+				ResourceType: sarama.AclResourceTopic, ResourceName: "orders", PatternType: sarama.AclPatternLiteral},
+		},
+	}
+
+	if err := ApplyACLs(admin, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(admin.createdACLs) != 1 || len(admin.createdACLs[0].Acls) != 2 || admin.createdACLs[0].ResourceName != "payments" {
+		t.Errorf("Expected both ACLs to be created on the payments topic, got %+v", admin.createdACLs)
+	}
+
+	if len(admin.deleteFilter) != 1 {
+		t.Fatalf("Expected 1 delete, got %d", len(admin.deleteFilter))
+	}
+	filter := admin.deleteFilter[0]
+	if filter.ResourceType != sarama.AclResourceTopic || *filter.ResourceName != "orders" ||
+		filter.ResourcePatternTypeFilter != sarama.AclPatternLiteral || *filter.Principal != "User:bob" ||
+		*filter.Host != "*" || filter.Operation != sarama.AclOperationWrite || filter.PermissionType != sarama.AclPermissionAllow {
+		t.Errorf("Expected an exact delete filter, got %+v", filter)
+	}
+}
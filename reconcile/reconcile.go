@@ -0,0 +1,50 @@
+/*
+Package reconcile applies a declared desired state to a Kafka cluster through a
+sarama.ClusterAdmin, for example topic definitions or ACLs kept in version
+control.
+
+Reconciling is done in two steps. First a plan is computed by diffing the
+declared state against the state of the cluster. The plan lists the changes
+needed to reach the declared state, as well as the drift that cannot be fixed
+automatically. The plan can then be reviewed and executed. Topic plans can
+also be executed as a dry run that is only validated by the brokers.
+
+The declared types carry yaml and json struct tags, so they can be unmarshaled
+directly from configuration files:
+
+	var topics []reconcile.Topic
+	if err := yaml.Unmarshal(data, &topics); err != nil {
+		return err
+	}
+	plan, err := reconcile.PlanTopics(admin, topics, &reconcile.TopicOptions{PruneConfigs: true})
+	if err != nil {
+		return err
+	}
+	fmt.Print(plan)
+	return reconcile.ApplyTopics(admin, plan, false)
+
+NOTE: this package currently does not fall under the API stability
+guarantee of Sarama as it is still considered experimental.
+*/
+package reconcile
+
+import "strings"
+
+// Drift is a difference between the declared state and the state of the
+// cluster.
+type Drift struct {
+	// Resource is the name of the resource that drifted, for example a topic.
+	Resource string
+	// Reason describes the difference.
+	Reason string
+}
+
+func (d *Drift) String() string {
+	return d.Resource + ": " + d.Reason
+}
+
+// isInternalTopic reports whether topic is an internal topic of Kafka, such as
+// __consumer_offsets, which is never managed.
+func isInternalTopic(topic string) bool {
+	return strings.HasPrefix(topic, "__")
+}
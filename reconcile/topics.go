@@ -0,0 +1,365 @@
+package reconcile
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kcore-io/sarama"
+)
+
+var (
+	// ErrApplyTopics is returned by ApplyTopics when some changes of the plan
+	// fail.
+	ErrApplyTopics = errors.New("reconcile: failed to apply topic plan")
+
+	// ErrDeleteAllTopics is returned by PlanTopics when the plan would delete
+	// every topic of the cluster that is not internal or ignored, which
+	// usually means that the declared topics failed to load. Set
+	// TopicOptions.AllowDeleteAll to plan it anyway.
+	ErrDeleteAllTopics = errors.New("reconcile: refusing to delete all topics")
+)
+
+// Topic is the declared state of a topic.
+type Topic struct {
+	Name string `yaml:"name" json:"name"`
+	// Partitions is the number of partitions. Zero uses the default of the
+	// brokers when the topic is created and is otherwise not compared.
+	Partitions int32 `yaml:"partitions,omitempty" json:"partitions,omitempty"`
+	// ReplicationFactor is the replication factor. Zero uses the default of the
+	// brokers when the topic is created and is otherwise not compared.
+	ReplicationFactor int16 `yaml:"replicationFactor,omitempty" json:"replicationFactor,omitempty"`
+	// Configs are the configs set on the topic, for example retention.ms.
+	Configs map[string]string `yaml:"configs,omitempty" json:"configs,omitempty"`
+}
+
+// TopicOptions configures PlanTopics.
+type TopicOptions struct {
+	// Delete plans the deletion of the topics of the cluster that are not
+	// declared. Otherwise they are reported as drift.
+	Delete bool
+	// PruneConfigs plans the deletion of the configs set on a declared topic
+	// that are not declared. Otherwise they are reported as drift.
+	PruneConfigs bool
+	// Ignore reports whether a topic of the cluster that is not declared is
+	// managed elsewhere, so that it is neither deleted nor reported as drift.
+	// Internal topics whose names start with "__" are always ignored.
+	Ignore func(topic string) bool
+	// AllowDeleteAll allows a plan that deletes every topic of the cluster
+	// that is not internal or ignored, for example because no topic is
+	// declared.
+	AllowDeleteAll bool
+}
+
+// PartitionIncrease is a topic whose number of partitions is increased.
+type PartitionIncrease struct {
+	Topic string
+	From  int32
+	To    int32
+}
+
+// ConfigChange is a config of a topic that is set or deleted.
+type ConfigChange struct {
+	Topic string
+	Name  string
+	// Current is the value set on the topic, or nil if it is not set.
+	Current *string
+	// Desired is the declared value, or nil if the config is deleted.
+	Desired *string
+}
+
+// TopicPlan contains the changes needed to reach the declared topics, ordered
+// by topic name.
+type TopicPlan struct {
+	Creates            []*Topic
+	PartitionIncreases []*PartitionIncrease
+	ConfigChanges      []*ConfigChange
+	Deletes            []string
+	// Drift contains the differences the plan does not fix, such as a changed
+	// replication factor or fewer declared partitions than the topic has.
+	Drift []*Drift
+}
+
+// Empty reports whether the plan contains no changes. It may still report
+// drift.
+func (p *TopicPlan) Empty() bool {
+	return len(p.Creates) == 0 && len(p.PartitionIncreases) == 0 &&
+		len(p.ConfigChanges) == 0 && len(p.Deletes) == 0
+}
+
+// String formats the plan for human review, one change per line.
+func (p *TopicPlan) String() string {
+	var b strings.Builder
+	for _, topic := range p.Creates {
+		fmt.Fprintf(&b, "+ create topic %s (partitions: %d, replication factor: %d)\n",
+			topic.Name, topic.Partitions, topic.ReplicationFactor)
+	}
+	for _, increase := range p.PartitionIncreases {
+		fmt.Fprintf(&b, "~ increase partitions of %s from %d to %d\n", increase.Topic, increase.From, increase.To)
+	}
+	for _, change := range p.ConfigChanges {
+		switch {
+		case change.Desired == nil:
+			fmt.Fprintf(&b, "- delete config %s of %s (was %q)\n", change.Name, change.Topic, *change.Current)
+		case change.Current == nil:
+			fmt.Fprintf(&b, "~ set config %s of %s to %q\n", change.Name, change.Topic, *change.Desired)
+		default:
+			fmt.Fprintf(&b, "~ set config %s of %s from %q to %q\n", change.Name, change.Topic, *change.Current, *change.Desired)
+		}
+	}
+	for _, topic := range p.Deletes {
+		fmt.Fprintf(&b, "- delete topic %s\n", topic)
+	}
+	for _, drift := range p.Drift {
+		fmt.Fprintf(&b, "! %s\n", drift)
+	}
+	return b.String()
+}
+
+// PlanTopics diffs the declared topics against the topics of the cluster, as
+// returned by ListTopics and DescribeConfig, and returns the plan to reconcile
+// them.
+func PlanTopics(admin sarama.ClusterAdmin, declared []Topic, options *TopicOptions) (*TopicPlan, error) {
+	if options == nil {
+		options = &TopicOptions{}
+	}
+
+	byName := make(map[string]*Topic, len(declared))
+	for i := range declared {
+		topic := &declared[i]
+		if topic.Name == "" {
+			return nil, sarama.ErrInvalidTopic
+		}
+		if _, ok := byName[topic.Name]; ok {
+			return nil, sarama.ConfigurationError(fmt.Sprintf("topic %s is declared more than once", topic.Name))
+		}
+		byName[topic.Name] = topic
+	}
+
+	current, err := admin.ListTopics()
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make(map[string]map[string]string)
+	for name := range byName {
+		if _, ok := current[name]; !ok {
+			continue
+		}
+		entries, err := admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: name})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe configs of topic %s: %w", name, err)
+		}
+		configs[name] = topicConfigs(entries)
+	}
+
+	plan := diffTopics(byName, current, configs, options)
+	if len(plan.Deletes) > 0 && !options.AllowDeleteAll {
+		managed := 0
+		for name := range current {
+			if !isInternalTopic(name) && (options.Ignore == nil || !options.Ignore(name)) {
+				managed++
+			}
+		}
+		if len(plan.Deletes) == managed {
+			return nil, ErrDeleteAllTopics
+		}
+	}
+	return plan, nil
+}
+
+// topicConfigs returns the configs set on a topic, excluding the defaults
+// inherited from the brokers.
+func topicConfigs(entries []sarama.ConfigEntry) map[string]string {
+	configs := make(map[string]string)
+	for _, entry := range entries {
+		if entry.Sensitive {
+			continue
+		}
+		// DescribeConfigs version 0 does not report the source of a config
+		if entry.Source == sarama.SourceTopic || (entry.Source == sarama.SourceUnknown && !entry.Default) {
+			configs[entry.Name] = entry.Value
+		}
+	}
+	return configs
+}
+
+func diffTopics(declared map[string]*Topic, current map[string]sarama.TopicDetail, configs map[string]map[string]string, options *TopicOptions) *TopicPlan {
+	plan := &TopicPlan{}
+
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		topic := declared[name]
+		detail, ok := current[name]
+		if !ok {
+			plan.Creates = append(plan.Creates, topic)
+			continue
+		}
+
+		if topic.Partitions > detail.NumPartitions {
+			plan.PartitionIncreases = append(plan.PartitionIncreases, &PartitionIncrease{
+				Topic: name,
+				From:  detail.NumPartitions,
+				To:    topic.Partitions,
+			})
+		} else if topic.Partitions > 0 && topic.Partitions < detail.NumPartitions {
+			plan.Drift = append(plan.Drift, &Drift{
+				Resource: name,
+				Reason: fmt.Sprintf("topic has %d partitions but %d are declared, partitions cannot be removed",
+					detail.NumPartitions, topic.Partitions),
+			})
+		}
+
+		if topic.ReplicationFactor > 0 && topic.ReplicationFactor != detail.ReplicationFactor {
+			plan.Drift = append(plan.Drift, &Drift{
+				Resource: name,
+				Reason: fmt.Sprintf("replication factor is %d but %d is declared",
+					detail.ReplicationFactor, topic.ReplicationFactor),
+			})
+		}
+
+		set := configs[name]
+		for _, config := range sortedConfigNames(topic.Configs) {
+			desired := topic.Configs[config]
+			value, ok := set[config]
+			if ok && value == desired {
+				continue
+			}
+			change := &ConfigChange{Topic: name, Name: config, Desired: &desired}
+			if ok {
+				change.Current = &value
+			}
+			plan.ConfigChanges = append(plan.ConfigChanges, change)
+		}
+		for _, config := range sortedConfigNames(set) {
+			if _, ok := topic.Configs[config]; ok {
+				continue
+			}
+			value := set[config]
+			if options.PruneConfigs {
+				plan.ConfigChanges = append(plan.ConfigChanges, &ConfigChange{Topic: name, Name: config, Current: &value})
+			} else {
+				plan.Drift = append(plan.Drift, &Drift{
+					Resource: name,
+					Reason:   fmt.Sprintf("config %s is set to %q but not declared", config, value),
+				})
+			}
+		}
+	}
+
+	names = make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := declared[name]; ok || isInternalTopic(name) || (options.Ignore != nil && options.Ignore(name)) {
+			continue
+		}
+		if options.Delete {
+			plan.Deletes = append(plan.Deletes, name)
+		} else {
+			plan.Drift = append(plan.Drift, &Drift{Resource: name, Reason: "topic exists but is not declared"})
+		}
+	}
+
+	return plan
+}
+
+// ApplyTopics executes a plan returned by PlanTopics. If validateOnly is set,
+// the creates, partition increases and config changes are only validated by
+// the brokers, and the deletes, which cannot be validated, are skipped. It
+// attempts every change and returns the errors of those that failed wrapped in
+// ErrApplyTopics. Config changes require Kafka 2.3 or later.
+func ApplyTopics(admin sarama.ClusterAdmin, plan *TopicPlan, validateOnly bool) error {
+	var errs []error
+
+	if len(plan.Creates) > 0 {
+		details := make(map[string]*sarama.TopicDetail, len(plan.Creates))
+		for _, topic := range plan.Creates {
+			detail := &sarama.TopicDetail{NumPartitions: -1, ReplicationFactor: -1}
+			if topic.Partitions > 0 {
+				detail.NumPartitions = topic.Partitions
+			}
+			if topic.ReplicationFactor > 0 {
+				detail.ReplicationFactor = topic.ReplicationFactor
+			}
+			if len(topic.Configs) > 0 {
+				detail.ConfigEntries = make(map[string]*string, len(topic.Configs))
+				for name, value := range topic.Configs {
+					value := value
+					detail.ConfigEntries[name] = &value
+				}
+			}
+			details[topic.Name] = detail
+		}
+
+		results, err := admin.CreateTopics(details, &sarama.CreateTopicsOptions{ValidateOnly: validateOnly})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("create topics: %w", err))
+		}
+		for _, topic := range plan.Creates {
+			if result, ok := results[topic.Name]; ok && result.Err != nil {
+				errs = append(errs, fmt.Errorf("create topic %s: %w", topic.Name, result.Err))
+			}
+		}
+	}
+
+	for _, increase := range plan.PartitionIncreases {
+		if err := admin.CreatePartitions(increase.Topic, increase.To, nil, validateOnly); err != nil {
+			errs = append(errs, fmt.Errorf("increase partitions of %s: %w", increase.Topic, err))
+		}
+	}
+
+	entries := make(map[string]map[string]sarama.IncrementalAlterConfigsEntry)
+	var topics []string
+	for _, change := range plan.ConfigChanges {
+		if entries[change.Topic] == nil {
+			entries[change.Topic] = make(map[string]sarama.IncrementalAlterConfigsEntry)
+			topics = append(topics, change.Topic)
+		}
+		entry := sarama.IncrementalAlterConfigsEntry{Operation: sarama.IncrementalAlterConfigsOperationDelete}
+		if change.Desired != nil {
+			entry = sarama.IncrementalAlterConfigsEntry{Operation: sarama.IncrementalAlterConfigsOperationSet, Value: change.Desired}
+		}
+		entries[change.Topic][change.Name] = entry
+	}
+	for _, topic := range topics {
+		if err := admin.IncrementalAlterConfig(sarama.TopicResource, topic, entries[topic], validateOnly); err != nil {
+			errs = append(errs, fmt.Errorf("alter configs of %s: %w", topic, err))
+		}
+	}
+
+	if len(plan.Deletes) > 0 && !validateOnly {
+		results, err := admin.DeleteTopics(plan.Deletes)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("delete topics: %w", err))
+		}
+		for _, topic := range plan.Deletes {
+			if err := results[topic]; err != nil {
+				errs = append(errs, fmt.Errorf("delete topic %s: %w", topic, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return sarama.Wrap(ErrApplyTopics, errs...)
+	}
+	return nil
+}
+
+func sortedConfigNames(configs map[string]string) []string {
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,263 @@
+package reconcile
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kcore-io/sarama"
+)
+
+// fakeAdmin implements the parts of sarama.ClusterAdmin used by this package
+// and records the changes applied to it.
+type fakeAdmin struct {
+	sarama.ClusterAdmin
+
+	topics  map[string]sarama.TopicDetail
+	configs map[string][]sarama.ConfigEntry
+
+	created      map[string]*sarama.TopicDetail
+	createErrs   map[string]error
+	partitions   map[string]int32
+	altered      map[string]map[string]sarama.IncrementalAlterConfigsEntry
+	deleted      []string
+	validateOnly []bool
+
+	acls         []sarama.ResourceAcls
+	aclFilters   []sarama.AclFilter
+	createdACLs  []*sarama.ResourceAcls
+	deleteFilter []sarama.AclFilter
+}
+
+func (a *fakeAdmin) ListTopics() (map[string]sarama.TopicDetail, error) {
+	return a.topics, nil
+}
+
+func (a *fakeAdmin) DescribeConfig(resource sarama.ConfigResource) ([]sarama.ConfigEntry, error) {
+	return a.configs[resource.Name], nil
+}
+
+func (a *fakeAdmin) CreateTopics(topics map[string]*sarama.TopicDetail, options *sarama.CreateTopicsOptions) (map[string]*sarama.CreateTopicResult, error) {
+	a.created = topics
+	a.validateOnly = append(a.validateOnly, options.ValidateOnly)
+	results := make(map[string]*sarama.CreateTopicResult)
+	for topic := range topics {
+		results[topic] = &sarama.CreateTopicResult{Err: a.createErrs[topic]}
+	}
+	return results, nil
+}
+
+func (a *fakeAdmin) CreatePartitions(topic string, count int32, assignment [][]int32, validateOnly bool) error {
+	if a.partitions == nil {
+		a.partitions = make(map[string]int32)
+	}
+	a.partitions[topic] = count
+	a.validateOnly = append(a.validateOnly, validateOnly)
+	return nil
+}
+
+func (a *fakeAdmin) IncrementalAlterConfig(resourceType sarama.ConfigResourceType, name string, entries map[string]sarama.IncrementalAlterConfigsEntry, validateOnly bool) error {
+	if a.altered == nil {
+		a.altered = make(map[string]map[string]sarama.IncrementalAlterConfigsEntry)
+	}
+	a.altered[name] = entries
+	a.validateOnly = append(a.validateOnly, validateOnly)
+	return nil
+}
+
+func (a *fakeAdmin) DeleteTopics(topics []string) (map[string]error, error) {
+	a.deleted = topics
+	results := make(map[string]error)
+	for _, topic := range topics {
+		results[topic] = nil
+	}
+	return results, nil
+}
+
+func newFakeAdmin() *fakeAdmin {
+	return &fakeAdmin{
+		topics: map[string]sarama.TopicDetail{
+			"orders":             {NumPartitions: 3, ReplicationFactor: 3},
+			"payments":           {NumPartitions: 6, ReplicationFactor: 2},
+			"legacy":             {NumPartitions: 1, ReplicationFactor: 1},
+			"__consumer_offsets": {NumPartitions: 50, ReplicationFactor: 3},
+		},
+		configs: map[string][]sarama.ConfigEntry{
+			"orders": {
+				{Name: "retention.ms", Value: "86400000", Source: sarama.SourceTopic},
+				{Name: "cleanup.policy", Value: "compact", Source: sarama.SourceTopic},
+				{Name: "segment.bytes", Value: "1073741824", Source: sarama.SourceStaticBroker},
+			},
+			"payments": {
+				{Name: "retention.ms", Value: "604800000", Source: sarama.SourceDefault, Default: true},
+			},
+		},
+	}
+}
+
+func TestPlanTopics(t *testing.T) {
+	admin := newFakeAdmin()
+	declared := []Topic{
+		{Name: "orders", Partitions: 6, ReplicationFactor: 3, Configs: map[string]string{"retention.ms": "3600000"}},
+		{Name: "payments", Partitions: 3, ReplicationFactor: 3},
+		{Name: "refunds", Partitions: 2, ReplicationFactor: 3, Configs: map[string]string{"retention.ms": "3600000"}},
+	}
+
+	plan, err := PlanTopics(admin, declared, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.Creates) != 1 || plan.Creates[0].Name != "refunds" {
+		t.Errorf("Expected to create refunds, got %v", plan.Creates)
+	}
+	expectedIncreases := []*PartitionIncrease{{Topic: "orders", From: 3, To: 6}}
+	if !reflect.DeepEqual(plan.PartitionIncreases, expectedIncreases) {
+		t.Errorf("Expected partition increases %v, got %v", expectedIncreases, plan.PartitionIncreases)
+	}
+	if len(plan.ConfigChanges) != 1 || plan.ConfigChanges[0].Name != "retention.ms" ||
+		*plan.ConfigChanges[0].Current != "86400000" || *plan.ConfigChanges[0].Desired != "3600000" {
+		t.Errorf("Unexpected config changes %v", plan.ConfigChanges)
+	}
+	if len(plan.Deletes) != 0 {
+		t.Errorf("Expected no deletes, got %v", plan.Deletes)
+	}
+
+	expectedDrift := []string{
+		"orders: config cleanup.policy is set to \"compact\" but not declared",
+		"payments: topic has 6 partitions but 3 are declared, partitions cannot be removed",
+		"payments: replication factor is 2 but 3 is declared",
+		"legacy: topic exists but is not declared",
+	}
+	var drift []string
+	for _, d := range plan.Drift {
+		drift = append(drift, d.String())
+	}
+	if !reflect.DeepEqual(drift, expectedDrift) {
+		t.Errorf("Expected drift %q, got %q", expectedDrift, drift)
+	}
+}
+
+func TestPlanTopicsWithDeleteAndPrune(t *testing.T) {
+	admin := newFakeAdmin()
+	declared := []Topic{
+		{Name: "orders", Configs: map[string]string{"retention.ms": "86400000"}},
+		{Name: "payments"},
+	}
+
+	plan, err := PlanTopics(admin, declared, &TopicOptions{
+		Delete:       true,
+		PruneConfigs: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.ConfigChanges) != 1 || plan.ConfigChanges[0].Name != "cleanup.policy" || plan.ConfigChanges[0].Desired != nil {
+		t.Errorf("Expected to delete cleanup.policy, got %v", plan.ConfigChanges)
+	}
+	if !reflect.DeepEqual(plan.Deletes, []string{"legacy"}) {
+		t.Errorf("Expected to delete legacy, got %v", plan.Deletes)
+	}
+	if len(plan.Drift) != 0 {
+		t.Errorf("Expected no drift, got %v", plan.Drift)
+	}
+
+	plan, err = PlanTopics(admin, declared, &TopicOptions{
+		Delete: true,
+		Ignore: func(topic string) bool { return topic == "legacy" },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Deletes) != 0 {
+		t.Errorf("Expected ignored topics not to be deleted, got %v", plan.Deletes)
+	}
+}
+
+func TestPlanTopicsRefusesToDeleteAll(t *testing.T) {
+	admin := newFakeAdmin()
+
+	if _, err := PlanTopics(admin, nil, &TopicOptions{Delete: true}); !errors.Is(err, ErrDeleteAllTopics) {
+		t.Errorf("Expected ErrDeleteAllTopics, got %v", err)
+	}
+
+	plan, err := PlanTopics(admin, nil, &TopicOptions{Delete: true, AllowDeleteAll: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(plan.Deletes, []string{"legacy", "orders", "payments"}) {
+		t.Errorf("Expected to delete every topic but __consumer_offsets, got %v", plan.Deletes)
+	}
+}
+
+func TestPlanTopicsInvalidDeclaration(t *testing.T) {
+	admin := newFakeAdmin()
+
+	if _, err := PlanTopics(admin, []Topic{{Name: ""}}, nil); !errors.Is(err, sarama.ErrInvalidTopic) {
+		t.Errorf("Expected ErrInvalidTopic, got %v", err)
+	}
+	if _, err := PlanTopics(admin, []Topic{{Name: "orders"}, {Name: "orders"}}, nil); err == nil {
+		t.Error("Expected an error for a duplicate topic")
+	}
+}
+
+func TestApplyTopics(t *testing.T) {
+	admin := newFakeAdmin()
+	retention := "3600000"
+	current := "compact"
+	plan := &TopicPlan{
+		Creates:            []*Topic{{Name: "refunds", Partitions: 2, Configs: map[string]string{"retention.ms": retention}}},
+		PartitionIncreases: []*PartitionIncrease{{Topic: "orders", From: 3, To: 6}},
+		ConfigChanges: []*ConfigChange{
+			{Topic: "orders", Name: "retention.ms", Desired: &retention},
+			{Topic: "orders", Name: "cleanup.policy", Current: &current},
+		},
+		Deletes: []string{"legacy"},
+	}
+
+	if err := ApplyTopics(admin, plan, false); err != nil {
+		t.Fatal(err)
+	}
+
+	refunds := admin.created["refunds"]
+	if refunds == nil || refunds.NumPartitions != 2 || refunds.ReplicationFactor != -1 || *refunds.ConfigEntries["retention.ms"] != retention {
+		t.Errorf("Unexpected created topic %+v", refunds)
+	}
+	if admin.partitions["orders"] != 6 {
+		t.Errorf("Expected orders to have 6 partitions, got %d", admin.partitions["orders"])
+	}
+	entries := admin.altered["orders"]
+	if entries["retention.ms"].Operation != sarama.IncrementalAlterConfigsOperationSet || *entries["retention.ms"].Value != retention {
+		t.Errorf("Unexpected retention.ms change %+v", entries["retention.ms"])
+	}
+	if entries["cleanup.policy"].Operation != sarama.IncrementalAlterConfigsOperationDelete {
+		t.Errorf("Unexpected cleanup.policy change %+v", entries["cleanup.policy"])
+	}
+	if !reflect.DeepEqual(admin.deleted, []string{"legacy"}) {
+		t.Errorf("Expected to delete legacy, got %v", admin.deleted)
+	}
+}
+
+func TestApplyTopicsValidateOnly(t *testing.T) {
+	admin := newFakeAdmin()
+	admin.createErrs = map[string]error{"refunds": sarama.ErrInvalidReplicationFactor}
+	retention := "3600000"
+	plan := &TopicPlan{
+		Creates:            []*Topic{{Name: "refunds", Partitions: 2, ReplicationFactor: 5}},
+		PartitionIncreases: []*PartitionIncrease{{Topic: "orders", From: 3, To: 6}},
+		ConfigChanges:      []*ConfigChange{{Topic: "orders", Name: "retention.ms", Desired: &retention}},
+		Deletes:            []string{"legacy"},
+	}
+
+	err := ApplyTopics(admin, plan, true)
+	if !errors.Is(err, ErrApplyTopics) || !errors.Is(err, sarama.ErrInvalidReplicationFactor) {
+		t.Errorf("Expected the create error wrapped in ErrApplyTopics, got %v", err)
+	}
+	if !reflect.DeepEqual(admin.validateOnly, []bool{true, true, true}) {
+		t.Errorf("Expected every change to be validated only, got %v", admin.validateOnly)
+	}
+	if admin.deleted != nil {
+		t.Errorf("Expected no deletes when validating, got %v", admin.deleted)
+	}
+}
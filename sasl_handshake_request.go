@@ -29,6 +29,10 @@ func (r *SaslHandshakeRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *SaslHandshakeRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *SaslHandshakeRequest) HeaderVersion() int16 {
 	return 1
 }
@@ -34,6 +34,10 @@ func (r *SaslHandshakeResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *SaslHandshakeResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *SaslHandshakeResponse) HeaderVersion() int16 {
 	return 0
 }
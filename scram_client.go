@@ -0,0 +1,151 @@
+package sarama
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// scramClient is a SCRAMClient implementing the client side of the SCRAM
+// exchange of RFC 5802, as used by Kafka. User names and passwords are not
+// normalized with SASLprep, which Kafka does not do either.
+type scramClient struct {
+	formatter scramFormatter
+
+	user    string
+	pass    string
+	authzID string
+	nonce   string
+
+	step            int
+	clientFirstBare string
+	serverSignature []byte
+}
+
+// newSCRAMClientGeneratorFunc returns a Net.SASL.SCRAMClientGeneratorFunc
+// creating scramClients for the given mechanism.
+func newSCRAMClientGeneratorFunc(mechanism ScramMechanismType) func() SCRAMClient {
+	return func() SCRAMClient {
+		return &scramClient{formatter: scramFormatter{mechanism: mechanism}}
+	}
+}
+
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	if c.nonce == "" {
+		buf := make([]byte, 24)
+		if _, err := rand.Read(buf); err != nil {
+			return err
+		}
+		c.nonce = base64.RawStdEncoding.EncodeToString(buf)
+	}
+	c.user, c.pass, c.authzID = userName, password, authzID
+	c.step = 0
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	c.step++
+	switch c.step {
+	case 1:
+		c.clientFirstBare = "n=" + scramEscape(c.user) + ",r=" + c.nonce
+		return c.gs2Header() + c.clientFirstBare, nil
+	case 2:
+		return c.clientFinal(challenge)
+	case 3:
+		return "", c.verifyServerFinal(challenge)
+	default:
+		return "", errors.New("kafka: unexpected SCRAM challenge after the end of the exchange")
+	}
+}
+
+func (c *scramClient) Done() bool {
+	return c.step >= 3
+}
+
+func (c *scramClient) gs2Header() string {
+	if c.authzID == "" {
+		return "n,,"
+	}
+	return "n,a=" + scramEscape(c.authzID) + ","
+}
+
+func (c *scramClient) clientFinal(serverFirst string) (string, error) {
+	attrs := scramAttributes(serverFirst)
+	if e, ok := attrs["e"]; ok {
+		return "", fmt.Errorf("kafka: SCRAM authentication failed: %s", e)
+	}
+	nonce := attrs["r"]
+	if !strings.HasPrefix(nonce, c.nonce) || len(nonce) == len(c.nonce) {
+		return "", errors.New("kafka: invalid SCRAM server nonce")
+	}
+	salt, err := base64.StdEncoding.DecodeString(attrs["s"])
+	if err != nil || len(salt) == 0 {
+		return "", errors.New("kafka: invalid SCRAM salt")
+	}
+	iterations, err := strconv.Atoi(attrs["i"])
+	if err != nil || iterations <= 0 {
+		return "", errors.New("kafka: invalid SCRAM iteration count")
+	}
+
+	saltedPassword, err := c.formatter.saltedPassword([]byte(c.pass), salt, iterations)
+	if err != nil {
+		return "", err
+	}
+	clientKey, err := c.formatter.hmac(saltedPassword, []byte("Client Key"))
+	if err != nil {
+		return "", err
+	}
+	storedKey, err := c.formatter.hash(clientKey)
+	if err != nil {
+		return "", err
+	}
+	serverKey, err := c.formatter.hmac(saltedPassword, []byte("Server Key"))
+	if err != nil {
+		return "", err
+	}
+
+	clientFinalWithoutProof := "c=" + base64.StdEncoding.EncodeToString([]byte(c.gs2Header())) + ",r=" + nonce
+	authMessage := []byte(c.clientFirstBare + "," + serverFirst + "," + clientFinalWithoutProof)
+
+	clientSignature, err := c.formatter.hmac(storedKey, authMessage)
+	if err != nil {
+		return "", err
+	}
+	if c.serverSignature, err = c.formatter.hmac(serverKey, authMessage); err != nil {
+		return "", err
+	}
+	c.formatter.xor(clientSignature, clientKey)
+
+	return clientFinalWithoutProof + ",p=" + base64.StdEncoding.EncodeToString(clientSignature), nil
+}
+
+func (c *scramClient) verifyServerFinal(serverFinal string) error {
+	attrs := scramAttributes(serverFinal)
+	if e, ok := attrs["e"]; ok {
+		return fmt.Errorf("kafka: SCRAM authentication failed: %s", e)
+	}
+	signature, err := base64.StdEncoding.DecodeString(attrs["v"])
+	if err != nil || !hmac.Equal(signature, c.serverSignature) {
+		return errors.New("kafka: invalid SCRAM server signature")
+	}
+	return nil
+}
+
+// scramAttributes parses the comma separated attributes of a SCRAM message.
+func scramAttributes(msg string) map[string]string {
+	attrs := make(map[string]string)
+	for _, attr := range strings.Split(msg, ",") {
+		if len(attr) >= 2 && attr[1] == '=' {
+			attrs[attr[:1]] = attr[2:]
+		}
+	}
+	return attrs
+}
+
+func scramEscape(s string) string {
+	return strings.NewReplacer("=", "=3D", ",", "=2C").Replace(s)
+}
@@ -0,0 +1,79 @@
+package sarama
+
+import "testing"
+
+func TestSCRAMClient(t *testing.T) {
+	// test vector of RFC 7677
+	client := &scramClient{formatter: scramFormatter{mechanism: SCRAM_MECHANISM_SHA_256}, nonce: "rOprNGfwEbeRWgbNEkqO"}
+	if err := client.Begin("user", "pencil", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		challenge, response string
+	}{
+		{"", "n,,n=user,r=rOprNGfwEbeRWgbNEkqO"},
+		{
+			"r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096",
+			"c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ=",
+		},
+		{"v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4=", ""},
+	}
+	for i, step := range steps {
+		if client.Done() {
+			t.Fatalf("Expected the exchange not to be done before step %d", i)
+		}
+		response, err := client.Step(step.challenge)
+		if err != nil {
+			t.Fatalf("Step %d: %v", i, err)
+		}
+		if response != step.response {
+			t.Errorf("Step %d: expected %q, got %q", i, step.response, response)
+		}
+	}
+	if !client.Done() {
+		t.Error("Expected the exchange to be done")
+	}
+}
+
+func TestSCRAMClientErrors(t *testing.T) {
+	serverFirst := "r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"
+	for _, challenges := range [][]string{
+		{"e=unknown-user"},
+		{"r=other,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"},
+		{"r=rOprNGfwEbeRWgbNEkqO%hv,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=zero"},
+		{serverFirst, "v=invalid"},
+		{serverFirst, "e=invalid-proof"},
+	} {
+		client := &scramClient{formatter: scramFormatter{mechanism: SCRAM_MECHANISM_SHA_256}, nonce: "rOprNGfwEbeRWgbNEkqO"}
+		if err := client.Begin("user", "pencil", ""); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Step(""); err != nil {
+			t.Fatal(err)
+		}
+		var err error
+		for _, challenge := range challenges {
+			if _, err = client.Step(challenge); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			t.Errorf("Expected an error for %v", challenges)
+		}
+	}
+
+	// the authorization identity is sent in the GS2 header and the channel
+	// binding, and names are escaped
+	client := newSCRAMClientGeneratorFunc(SCRAM_MECHANISM_SHA_512)()
+	if err := client.Begin("a=b,c", "secret", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	first, err := client.Step("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "n,a=admin,n=a=3Db=2Cc,r="; len(first) <= len(expected) || first[:len(expected)] != expected {
+		t.Errorf("Unexpected client first message %q", first)
+	}
+}
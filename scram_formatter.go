@@ -76,3 +76,16 @@ func (s scramFormatter) saltedPassword(password []byte, salt []byte, iterations
 
 	return result, nil
 }
+
+func (s scramFormatter) hash(data []byte) ([]byte, error) {
+	switch s.mechanism {
+	case SCRAM_MECHANISM_SHA_256:
+		sum := sha256.Sum256(data)
+		return sum[:], nil
+	case SCRAM_MECHANISM_SHA_512:
+		sum := sha512.Sum512(data)
+		return sum[:], nil
+	default:
+		return nil, ErrUnknownScramMechanism
+	}
+}
@@ -1,5 +1,7 @@
 package sarama
 
+import "errors"
+
 type SyncGroupRequestAssignment struct {
 	// MemberId contains the ID of the member to assign.
 	MemberId string
@@ -119,6 +121,17 @@ func (r *SyncGroupRequest) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *SyncGroupRequest) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *SyncGroupRequest) checkVersion(v int16) error {
+	if r.GroupInstanceId != nil && v < 3 {
+		return errors.New("the group instance ID requires version 3")
+	}
+	return nil
+}
+
 func (r *SyncGroupRequest) HeaderVersion() int16 {
 	return 1
 }
@@ -55,6 +55,10 @@ func (r *SyncGroupResponse) APIVersion() int16 {
 	return r.Version
 }
 
+func (r *SyncGroupResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *SyncGroupResponse) HeaderVersion() int16 {
 	return 0
 }
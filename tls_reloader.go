@@ -0,0 +1,225 @@
+package sarama
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSReloader loads a client certificate, its key and a CA bundle from PEM
+// files and reloads them whenever the files change, e.g. when they are
+// rotated by a sidecar. The tls.Config returned by Config serves the current
+// material through GetClientCertificate and VerifyConnection, so new broker
+// connections use the new material without restarting the Client.
+type TLSReloader struct {
+	certFile, keyFile, caFile string
+
+	reloadLock sync.Mutex
+	lock       sync.RWMutex
+	cert       *tls.Certificate
+	roots      *x509.CertPool
+	contents   [3][]byte
+	loaded     bool
+	clients    []Client
+
+	closer, closed chan none
+}
+
+// NewTLSReloader loads the client certificate and key from certFile and
+// keyFile, and the CA bundle from caFile, and checks the files for changes
+// every interval. certFile and keyFile are optional if the brokers do not
+// require client authentication, and caFile is optional to verify the brokers
+// against the system roots instead. A zero interval disables watching, Reload
+// must then be called to pick up new material.
+func NewTLSReloader(certFile, keyFile, caFile string, interval time.Duration) (*TLSReloader, error) {
+	if (certFile == "") != (keyFile == "") {
+		return nil, ConfigurationError("both a certificate and a key file must be provided for client authentication")
+	}
+	if interval < 0 {
+		return nil, ConfigurationError("the TLS reload interval must be >= 0")
+	}
+
+	r := &TLSReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		closer:   make(chan none),
+		closed:   make(chan none),
+	}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	if interval > 0 {
+		go withRecover(func() { r.watch(interval) })
+	} else {
+		close(r.closed)
+	}
+	return r, nil
+}
+
+// Config returns a tls.Config that serves the current material of the
+// reloader, to be used as Config.Net.TLS.Config. When a CA file is used, the
+// broker certificates are verified in VerifyConnection against the current CA
+// bundle, including the host name, instead of through RootCAs.
+func (r *TLSReloader) Config() *tls.Config {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if r.certFile != "" {
+		config.GetClientCertificate = r.getClientCertificate
+	}
+	if r.caFile != "" {
+		// Verification is not skipped, it is done by VerifyConnection
+		config.InsecureSkipVerify = true //nolint:gosec
+		config.VerifyConnection = r.verifyConnection
+	}
+	return config
+}
+
+// DrainOnReload closes the broker connections of client whenever new material
+// is loaded, so that they are reopened with the new material. Requests in
+// flight complete before a connection is closed.
+func (r *TLSReloader) DrainOnReload(client Client) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.clients = append(r.clients, client)
+}
+
+// Reload reloads the files, and returns whether any of them changed. If the
+// new material is invalid, for example because the certificate was rotated
+// but not yet its key, the current material is kept and an error is returned.
+func (r *TLSReloader) Reload() (bool, error) {
+	r.reloadLock.Lock()
+	defer r.reloadLock.Unlock()
+
+	var contents [3][]byte
+	for i, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return false, err
+		}
+		contents[i] = data
+	}
+
+	changed := !r.loaded
+	for i := range contents {
+		changed = changed || !bytes.Equal(contents[i], r.contents[i])
+	}
+	if !changed {
+		return false, nil
+	}
+
+	var cert *tls.Certificate
+	if r.certFile != "" {
+		c, err := tls.X509KeyPair(contents[0], contents[1])
+		if err != nil {
+			return false, fmt.Errorf("failed to load client certificate %s and key %s: %w", r.certFile, r.keyFile, err)
+		}
+		cert = &c
+	}
+	var roots *x509.CertPool
+	if r.caFile != "" {
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(contents[2]) {
+			return false, fmt.Errorf("failed to load CA certificates from %s", r.caFile)
+		}
+	}
+
+	r.lock.Lock()
+	initial := !r.loaded
+	r.cert, r.roots, r.contents, r.loaded = cert, roots, contents, true
+	clients := r.clients
+	r.lock.Unlock()
+
+	if !initial {
+		Logger.Println("Reloaded TLS certificates")
+		for _, client := range clients {
+			drainBrokerConnections(client)
+		}
+	}
+	return true, nil
+}
+
+// Close stops watching the files.
+func (r *TLSReloader) Close() error {
+	select {
+	case <-r.closed:
+		return nil
+	default:
+	}
+	close(r.closer)
+	<-r.closed
+	return nil
+}
+
+func (r *TLSReloader) watch(interval time.Duration) {
+	defer close(r.closed)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := r.Reload(); err != nil {
+				Logger.Printf("Failed to reload TLS certificates, keeping the current ones: %v\n", err)
+			}
+		case <-r.closer:
+			return
+		}
+	}
+}
+
+func (r *TLSReloader) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.cert, nil
+}
+
+func (r *TLSReloader) verifyConnection(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("tls: broker did not provide a certificate")
+	}
+
+	r.lock.RLock()
+	roots := r.roots
+	r.lock.RUnlock()
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       state.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range state.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := state.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// drainBrokerConnections closes the connections to the brokers of client,
+// including the dedicated coordinator connections, which are reopened on
+// their next use.
+func drainBrokerConnections(client Client) {
+	if client.Closed() {
+		return
+	}
+	brokers := client.Brokers()
+	if c, ok := client.(interface{ allBrokers() []*Broker }); ok {
+		brokers = c.allBrokers()
+	}
+	for _, broker := range brokers {
+		safeAsyncClose(broker)
+	}
+}
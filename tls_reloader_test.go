@@ -0,0 +1,287 @@
+package sarama
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM encoded certificate and key signed by the CA.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		Subject:      pkix.Name{CommonName: name},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func writeTestFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestTLSListener returns a listener that requires client certificates
+// signed by ca and reports the common name of each client certificate.
+func newTestTLSListener(t *testing.T, ca *testCA) (net.Listener, chan string) {
+	t.Helper()
+	hostCert, hostKey := ca.issue(t, "host", x509.ExtKeyUsageServerAuth)
+	cert, err := tls.X509KeyPair(hostCert, hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	clientNames := make(chan string, 10)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+		VerifyPeerCertificate: func(_ [][]byte, chains [][]*x509.Certificate) error {
+			clientNames <- chains[0][0].Subject.CommonName
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return listener, clientNames
+}
+
+func dialTestTLS(t *testing.T, addr string, config *tls.Config) error {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr, validServerNameTLS(addr, config))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Handshake()
+}
+
+func TestTLSReloader(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	listener, clientNames := newTestTLSListener(t, ca)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	caFile := filepath.Join(dir, "ca.crt")
+
+	certPEM, keyPEM := ca.issue(t, "client-1", x509.ExtKeyUsageClientAuth)
+	writeTestFile(t, certFile, certPEM)
+	writeTestFile(t, keyFile, keyPEM)
+	writeTestFile(t, caFile, ca.pem)
+
+	reloader, err := NewTLSReloader(certFile, keyFile, caFile, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, reloader)
+	config := reloader.Config()
+
+	if err := dialTestTLS(t, listener.Addr().String(), config); err != nil {
+		t.Fatal(err)
+	}
+	if name := <-clientNames; name != "client-1" {
+		t.Errorf("Expected client-1 to connect, got %s", name)
+	}
+
+	if changed, err := reloader.Reload(); err != nil || changed {
+		t.Errorf("Expected no change, got %v %v", changed, err)
+	}
+
+	// the certificate is rotated before its key, the current material is kept
+	certPEM, keyPEM = ca.issue(t, "client-2", x509.ExtKeyUsageClientAuth)
+	writeTestFile(t, certFile, certPEM)
+	if _, err := reloader.Reload(); err == nil {
+		t.Error("Expected an error for a mismatched certificate and key")
+	}
+	if err := dialTestTLS(t, listener.Addr().String(), config); err != nil {
+		t.Fatal(err)
+	}
+	if name := <-clientNames; name != "client-1" {
+		t.Errorf("Expected client-1 to connect, got %s", name)
+	}
+
+	writeTestFile(t, keyFile, keyPEM)
+	if changed, err := reloader.Reload(); err != nil || !changed {
+		t.Fatalf("Expected a change, got %v %v", changed, err)
+	}
+	if err := dialTestTLS(t, listener.Addr().String(), config); err != nil {
+		t.Fatal(err)
+	}
+	if name := <-clientNames; name != "client-2" {
+		t.Errorf("Expected client-2 to connect, got %s", name)
+	}
+
+	// the brokers are no longer trusted with a different CA bundle
+	writeTestFile(t, caFile, newTestCA(t, "other-ca").pem)
+	if changed, err := reloader.Reload(); err != nil || !changed {
+		t.Fatalf("Expected a change, got %v %v", changed, err)
+	}
+	var verifyErr x509.UnknownAuthorityError
+	if err := dialTestTLS(t, listener.Addr().String(), config); !errors.As(err, &verifyErr) {
+		t.Errorf("Expected an unknown authority error, got %v", err)
+	}
+}
+
+func TestTLSReloaderWatchAndDrain(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	listener, clientNames := newTestTLSListener(t, ca)
+
+	seedBroker := NewMockBrokerListener(t, 1, listener)
+	defer seedBroker.Close()
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+	})
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	caFile := filepath.Join(dir, "ca.crt")
+
+	certPEM, keyPEM := ca.issue(t, "client-1", x509.ExtKeyUsageClientAuth)
+	writeTestFile(t, certFile, certPEM)
+	writeTestFile(t, keyFile, keyPEM)
+	writeTestFile(t, caFile, ca.pem)
+
+	reloader, err := NewTLSReloader(certFile, keyFile, caFile, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, reloader)
+
+	config := NewTestConfig()
+	config.Net.TLS.Enable = true
+	config.Net.TLS.Config = reloader.Config()
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+	reloader.DrainOnReload(client)
+
+	broker, err := client.Broker(seedBroker.BrokerID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	for name := range clientNames {
+		if name != "client-1" {
+			t.Fatalf("Expected client-1 to connect, got %s", name)
+		}
+		if len(clientNames) == 0 {
+			break
+		}
+	}
+
+	certPEM, keyPEM = ca.issue(t, "client-2", x509.ExtKeyUsageClientAuth)
+	writeTestFile(t, keyFile, keyPEM)
+	writeTestFile(t, certFile, certPEM)
+
+	timeout := time.After(5 * time.Second)
+	for {
+		if connected, _ := broker.Connected(); !connected {
+			break
+		}
+		select {
+		case <-timeout:
+			t.Fatal("Expected the broker connection to be drained after the certificates were rotated")
+		default:
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	broker, err = client.Broker(seedBroker.BrokerID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if name := <-clientNames; name != "client-2" {
+		t.Errorf("Expected the broker to reconnect as client-2, got %s", name)
+	}
+}
+
+func TestTLSReloaderConfigValidation(t *testing.T) {
+	var configErr ConfigurationError
+	if _, err := NewTLSReloader("client.crt", "", "", 0); !errors.As(err, &configErr) {
+		t.Errorf("Expected a ConfigurationError, got %v", err)
+	}
+	if _, err := NewTLSReloader("", "", filepath.Join(t.TempDir(), "missing.crt"), 0); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist, got %v", err)
+	}
+}
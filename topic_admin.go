@@ -0,0 +1,225 @@
+package sarama
+
+import (
+	"errors"
+	"time"
+)
+
+// CreateTopicsOptions configures ClusterAdmin.CreateTopics.
+type CreateTopicsOptions struct {
+	// ValidateOnly checks that the topics can be created as specified, but does
+	// not create them.
+	ValidateOnly bool
+}
+
+// CreateTopicResult is the result of creating a single topic with
+// ClusterAdmin.CreateTopics.
+type CreateTopicResult struct {
+	// Err is the error of the topic, which is a *TopicError if the broker
+	// rejected it.
+	Err error
+	// Detail contains the partition count, replication factor and configuration
+	// the broker applied to the topic. It is nil before Kafka 2.4.
+	Detail *CreatedTopicDetail
+}
+
+func (ca *clusterAdmin) CreateTopics(topics map[string]*TopicDetail, options *CreateTopicsOptions) (map[string]*CreateTopicResult, error) {
+	if options == nil {
+		options = &CreateTopicsOptions{}
+	}
+	for topic, detail := range topics {
+		if topic == "" {
+			return nil, ErrInvalidTopic
+		}
+		if detail == nil {
+			return nil, errors.New("you must specify topic details")
+		}
+	}
+
+	request := &CreateTopicsRequest{
+		TopicDetails: topics,
+		ValidateOnly: options.ValidateOnly,
+		Timeout:      ca.conf.Admin.Timeout,
+	}
+
+	if ca.conf.versionCeiling().IsAtLeast(V2_4_0_0) {
+		// Version 5 is the first flexible version and returns the applied
+		// configuration, partition count and replication factor.
+		request.Version = 5
+	} else if ca.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
+		// Version 3 is the same as version 2 (brokers response before throttling)
+		request.Version = 3
+	} else if ca.conf.versionCeiling().IsAtLeast(V0_11_0_0) {
+		// Version 2 is the same as version 1 (response has ThrottleTime)
+		request.Version = 2
+	} else if ca.conf.versionCeiling().IsAtLeast(V0_10_2_0) {
+		// Version 1 adds validateOnly.
+		request.Version = 1
+	}
+
+	var rsp *CreateTopicsResponse
+	err := ca.retryOnError(isErrNotController, func() error {
+		b, err := ca.Controller()
+		if err != nil {
+			return err
+		}
+
+		rsp, err = b.CreateTopics(request)
+		if err != nil {
+			return err
+		}
+
+		for _, topicErr := range rsp.TopicErrors {
+			if errors.Is(topicErr.Err, ErrNotController) {
+				_, _ = ca.refreshController()
+				return ErrNotController
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]*CreateTopicResult, len(topics))
+	var created []string
+	for topic := range topics {
+		result := &CreateTopicResult{Detail: rsp.CreatedTopics[topic]}
+		results[topic] = result
+
+		topicErr, ok := rsp.TopicErrors[topic]
+		if !ok {
+			result.Err = ErrIncompleteResponse
+		} else if !errors.Is(topicErr.Err, ErrNoError) {
+			result.Err = topicErr
+		} else {
+			created = append(created, topic)
+		}
+	}
+
+	if !options.ValidateOnly && len(created) > 0 {
+		for _, topic := range ca.waitForTopicMetadata(created, true) {
+			results[topic].Err = ErrRequestTimedOut
+		}
+		if err := ca.client.RefreshMetadata(created...); err != nil {
+			Logger.Printf("admin/request failed to refresh metadata of created topics: %v\n", err)
+		}
+	}
+
+	return results, nil
+}
+
+func (ca *clusterAdmin) DeleteTopics(topics []string) (map[string]error, error) {
+	for _, topic := range topics {
+		if topic == "" {
+			return nil, ErrInvalidTopic
+		}
+	}
+
+	request := &DeleteTopicsRequest{
+		Topics:  topics,
+		Timeout: ca.conf.Admin.Timeout,
+	}
+
+	// Versions 0, 1, 2, and 3 are the same.
+	if ca.conf.versionCeiling().IsAtLeast(V2_1_0_0) {
+		request.Version = 3
+	} else if ca.conf.versionCeiling().IsAtLeast(V2_0_0_0) {
+		request.Version = 2
+	} else if ca.conf.versionCeiling().IsAtLeast(V0_11_0_0) {
+		request.Version = 1
+	}
+
+	var rsp *DeleteTopicsResponse
+	err := ca.retryOnError(isErrNotController, func() error {
+		b, err := ca.Controller()
+		if err != nil {
+			return err
+		}
+
+		rsp, err = b.DeleteTopics(request)
+		if err != nil {
+			return err
+		}
+
+		for _, topicErr := range rsp.TopicErrorCodes {
+			if errors.Is(topicErr, ErrNotController) {
+				_, _ = ca.refreshController()
+				return ErrNotController
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]error, len(topics))
+	var deleted []string
+	for _, topic := range topics {
+		topicErr, ok := rsp.TopicErrorCodes[topic]
+		if !ok {
+			results[topic] = ErrIncompleteResponse
+		} else if !errors.Is(topicErr, ErrNoError) {
+			results[topic] = topicErr
+		} else {
+			results[topic] = nil
+			deleted = append(deleted, topic)
+		}
+	}
+
+	if len(deleted) > 0 {
+		for _, topic := range ca.waitForTopicMetadata(deleted, false) {
+			results[topic] = ErrRequestTimedOut
+		}
+	}
+
+	return results, nil
+}
+
+// waitForTopicMetadata polls the metadata of the controller until every topic
+// exists with a leader for each of its partitions, or, if exists is false, no
+// longer exists. It gives up after Admin.Timeout and returns the topics that
+// did not reach that state.
+func (ca *clusterAdmin) waitForTopicMetadata(topics []string, exists bool) []string {
+	deadline := time.Now().Add(ca.conf.Admin.Timeout)
+	for {
+		metadata, err := ca.DescribeTopics(topics)
+		if err == nil {
+			byName := make(map[string]*TopicMetadata, len(metadata))
+			for _, topic := range metadata {
+				byName[topic.Name] = topic
+			}
+
+			var pending []string
+			for _, topic := range topics {
+				if topicMetadataExists(byName[topic]) != exists {
+					pending = append(pending, topic)
+				}
+			}
+			if len(pending) == 0 {
+				return nil
+			}
+			topics = pending
+		}
+
+		if time.Now().Add(ca.conf.Admin.Retry.Backoff).After(deadline) {
+			return topics
+		}
+		time.Sleep(ca.conf.Admin.Retry.Backoff)
+	}
+}
+
+// topicMetadataExists reports whether topic exists and all of its partitions
+// have a leader.
+func topicMetadataExists(topic *TopicMetadata) bool {
+	if topic == nil || !errors.Is(topic.Err, ErrNoError) || len(topic.Partitions) == 0 {
+		return false
+	}
+	for _, partition := range topic.Partitions {
+		if partition.Leader < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,219 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClusterAdminCreateTopics(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()).
+			SetLeader("my_topic", 1, seedBroker.BrokerID()),
+		"CreateTopicsRequest": NewMockCreateTopicsResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_4_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	retention := "3600000"
+	results, err := admin.CreateTopics(map[string]*TopicDetail{
+		"my_topic": {
+			NumPartitions:     2,
+			ReplicationFactor: 1,
+			ConfigEntries:     map[string]*string{"retention.ms": &retention},
+		},
+		"_reserved": {NumPartitions: 1, ReplicationFactor: 1},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	created := results["my_topic"]
+	if created.Err != nil {
+		t.Fatalf("Unexpected error %v", created.Err)
+	}
+	if created.Detail == nil {
+		t.Fatal("Expected the created topic detail")
+	}
+	if created.Detail.NumPartitions != 2 || created.Detail.ReplicationFactor != 1 {
+		t.Errorf("Unexpected created topic detail %+v", created.Detail)
+	}
+	if len(created.Detail.Configs) != 1 || created.Detail.Configs[0].Name != "retention.ms" ||
+		*created.Detail.Configs[0].Value != retention || created.Detail.Configs[0].Source != SourceTopic {
+		t.Errorf("Unexpected created topic configs %+v", created.Detail.Configs)
+	}
+
+	var topicErr *TopicError
+	if !errors.As(results["_reserved"].Err, &topicErr) || !errors.Is(topicErr, ErrTopicAuthorizationFailed) {
+		t.Errorf("Expected ErrTopicAuthorizationFailed, got %v", results["_reserved"].Err)
+	}
+}
+
+func TestClusterAdminCreateTopicsTimesOutWaitingForMetadata(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"CreateTopicsRequest": NewMockCreateTopicsResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_0_0_0
+	config.Admin.Timeout = 50 * time.Millisecond
+	config.Admin.Retry.Backoff = 10 * time.Millisecond
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	results, err := admin.CreateTopics(map[string]*TopicDetail{
+		"my_topic": {NumPartitions: 1, ReplicationFactor: 1},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(results["my_topic"].Err, ErrRequestTimedOut) {
+		t.Errorf("Expected ErrRequestTimedOut, got %v", results["my_topic"].Err)
+	}
+	if results["my_topic"].Detail != nil {
+		t.Errorf("Expected no created topic detail before version 5, got %+v", results["my_topic"].Detail)
+	}
+}
+
+func TestClusterAdminCreateTopicsValidateOnly(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"CreateTopicsRequest": NewMockCreateTopicsResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_0_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	results, err := admin.CreateTopics(map[string]*TopicDetail{
+		"my_topic": {NumPartitions: 1, ReplicationFactor: 1},
+	}, &CreateTopicsOptions{ValidateOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results["my_topic"].Err != nil {
+		t.Errorf("Unexpected error %v", results["my_topic"].Err)
+	}
+}
+
+func TestClusterAdminCreateTopicsWithInvalidTopicDetail(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_0_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	if _, err := admin.CreateTopics(map[string]*TopicDetail{"my_topic": nil}, nil); err == nil {
+		t.Error("Expected an error for a nil topic detail")
+	}
+	if _, err := admin.CreateTopics(map[string]*TopicDetail{"": {}}, nil); !errors.Is(err, ErrInvalidTopic) {
+		t.Errorf("Expected ErrInvalidTopic, got %v", err)
+	}
+}
+
+func TestClusterAdminDeleteTopics(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"DeleteTopicsRequest": NewMockDeleteTopicsResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_1_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	results, err := admin.DeleteTopics([]string{"topic1", "topic2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	for topic, err := range results {
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", topic, err)
+		}
+	}
+}
+
+func TestClusterAdminDeleteTopicsWithError(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"DeleteTopicsRequest": NewMockDeleteTopicsResponse(t).SetError(ErrTopicDeletionDisabled),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_1_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	results, err := admin.DeleteTopics([]string{"topic1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(results["topic1"], ErrTopicDeletionDisabled) {
+		t.Errorf("Expected ErrTopicDeletionDisabled, got %v", results["topic1"])
+	}
+}
@@ -323,10 +323,10 @@ func (t *transactionManager) publishOffsetsToTxn(offsets topicPartitionOffsets,
 			ProducerID:      t.producerID,
 			GroupID:         groupId,
 		}
-		if t.client.Config().Version.IsAtLeast(V2_7_0_0) {
+		if t.client.Config().versionCeiling().IsAtLeast(V2_7_0_0) {
 			// Version 2 adds the support for new error code PRODUCER_FENCED.
 			request.Version = 2
-		} else if t.client.Config().Version.IsAtLeast(V2_0_0_0) {
+		} else if t.client.Config().versionCeiling().IsAtLeast(V2_0_0_0) {
 			// Version 1 is the same as version 0.
 			request.Version = 1
 		}
@@ -407,10 +407,10 @@ func (t *transactionManager) publishOffsetsToTxn(offsets topicPartitionOffsets,
 			GroupID:         groupId,
 			Topics:          offsets.mapToRequest(),
 		}
-		if t.client.Config().Version.IsAtLeast(V2_1_0_0) {
+		if t.client.Config().versionCeiling().IsAtLeast(V2_1_0_0) {
 			// Version 2 adds the committed leader epoch.
 			request.Version = 2
-		} else if t.client.Config().Version.IsAtLeast(V2_0_0_0) {
+		} else if t.client.Config().versionCeiling().IsAtLeast(V2_0_0_0) {
 			// Version 1 is the same as version 0.
 			request.Version = 1
 		}
@@ -483,8 +483,8 @@ func (t *transactionManager) initProducerId() (int64, int16, error) {
 		req.TransactionTimeout = t.transactionTimeout
 	}
 
-	if t.client.Config().Version.IsAtLeast(V2_5_0_0) {
-		if t.client.Config().Version.IsAtLeast(V2_7_0_0) {
+	if t.client.Config().versionCeiling().IsAtLeast(V2_5_0_0) {
+		if t.client.Config().versionCeiling().IsAtLeast(V2_7_0_0) {
 			// Version 4 adds the support for new error code PRODUCER_FENCED.
 			req.Version = 4
 		} else {
@@ -496,10 +496,10 @@ func (t *transactionManager) initProducerId() (int64, int16, error) {
 		t.coordinatorSupportsBumpingEpoch = true
 		req.ProducerID = t.producerID
 		req.ProducerEpoch = t.producerEpoch
-	} else if t.client.Config().Version.IsAtLeast(V2_4_0_0) {
+	} else if t.client.Config().versionCeiling().IsAtLeast(V2_4_0_0) {
 		// Version 2 is the first flexible version.
 		req.Version = 2
-	} else if t.client.Config().Version.IsAtLeast(V2_0_0_0) {
+	} else if t.client.Config().versionCeiling().IsAtLeast(V2_0_0_0) {
 		// Version 1 is the same as version 0.
 		req.Version = 1
 	}
@@ -644,10 +644,10 @@ func (t *transactionManager) endTxn(commit bool) error {
 			ProducerID:        t.producerID,
 			TransactionResult: commit,
 		}
-		if t.client.Config().Version.IsAtLeast(V2_7_0_0) {
+		if t.client.Config().versionCeiling().IsAtLeast(V2_7_0_0) {
 			// Version 2 adds the support for new error code PRODUCER_FENCED.
 			request.Version = 2
-		} else if t.client.Config().Version.IsAtLeast(V2_0_0_0) {
+		} else if t.client.Config().versionCeiling().IsAtLeast(V2_0_0_0) {
 			// Version 1 is the same as version 0.
 			request.Version = 1
 		}
@@ -821,10 +821,10 @@ func (t *transactionManager) publishTxnPartitions() error {
 			ProducerEpoch:   t.producerEpoch,
 			TopicPartitions: t.pendingPartitionsInCurrentTxn.mapToRequest(),
 		}
-		if t.client.Config().Version.IsAtLeast(V2_7_0_0) {
+		if t.client.Config().versionCeiling().IsAtLeast(V2_7_0_0) {
 			// Version 2 adds the support for new error code PRODUCER_FENCED.
 			request.Version = 2
-		} else if t.client.Config().Version.IsAtLeast(V2_0_0_0) {
+		} else if t.client.Config().versionCeiling().IsAtLeast(V2_0_0_0) {
 			// Version 1 is the same as version 0.
 			request.Version = 1
 		}
@@ -80,6 +80,10 @@ func (a *TxnOffsetCommitResponse) APIVersion() int16 {
 	return a.Version
 }
 
+func (a *TxnOffsetCommitResponse) setVersion(v int16) {
+	a.Version = v
+}
+
 func (a *TxnOffsetCommitResponse) HeaderVersion() int16 {
 	return 0
 }
@@ -46,7 +46,7 @@ func withRecover(fn func()) {
 func safeAsyncClose(b *Broker) {
 	tmp := b // local var prevents clobbering in goroutine
 	go withRecover(func() {
-		if connected, _ := tmp.Connected(); connected {
+		if connected, _ := tmp.Connected(); connected || tmp.closedForIdle() {
 			if err := tmp.Close(); err != nil {
 				Logger.Println("Error closing broker", tmp.ID(), ":", err)
 			}
@@ -199,6 +199,9 @@ var (
 	V3_5_0_0  = newKafkaVersion(3, 5, 0, 0)
 	V3_5_1_0  = newKafkaVersion(3, 5, 1, 0)
 	V3_6_0_0  = newKafkaVersion(3, 6, 0, 0)
+	V3_6_1_0  = newKafkaVersion(3, 6, 1, 0)
+	V3_7_0_0  = newKafkaVersion(3, 7, 0, 0)
+	V3_8_0_0  = newKafkaVersion(3, 8, 0, 0)
 
 	SupportedVersions = []KafkaVersion{
 		V0_8_2_0,
@@ -260,9 +263,12 @@ var (
 		V3_5_0_0,
 		V3_5_1_0,
 		V3_6_0_0,
+		V3_6_1_0,
+		V3_7_0_0,
+		V3_8_0_0,
 	}
 	MinVersion     = V0_8_2_0
-	MaxVersion     = V3_6_0_0
+	MaxVersion     = V3_8_0_0
 	DefaultVersion = V2_1_0_0
 
 	// reduced set of protocol versions to matrix test